@@ -0,0 +1,2 @@
+-- Which rate source (oracle, ecb, json, file) each rate was synced from
+ALTER TABLE "exchange_rate" ADD COLUMN "source" VARCHAR(32);
@@ -0,0 +1,3 @@
+-- Rows filled in by the sidecar's rate backfill, which the daily sync and
+-- retention cleanup never delete
+ALTER TABLE "exchange_rate" ADD COLUMN "backfilled" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,21 @@
+-- CreateTable
+CREATE TABLE IF NOT EXISTS "exchange_rate_quarantine" (
+    "id" TEXT NOT NULL,
+    "date" TIMESTAMP(3) NOT NULL,
+    "currency" TEXT NOT NULL,
+    "type" VARCHAR(32) NOT NULL,
+    "rate" DECIMAL(18,6) NOT NULL,
+    "previousRate" DECIMAL(18,6) NOT NULL,
+    "previousDate" TIMESTAMP(3) NOT NULL,
+    "source" VARCHAR(32),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "exchange_rate_quarantine_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX IF NOT EXISTS "exchange_rate_quarantine_currency_type_key" ON "exchange_rate_quarantine"("currency", "type");
+
+-- Only the sidecar writes quarantined rates; the app reads them for review
+REVOKE INSERT, UPDATE, DELETE ON "exchange_rate_quarantine" FROM retrospend_app;
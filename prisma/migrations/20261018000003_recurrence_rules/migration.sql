@@ -0,0 +1,11 @@
+-- AlterEnum
+ALTER TYPE "RecurringFrequency" ADD VALUE IF NOT EXISTS 'DAILY';
+ALTER TYPE "RecurringFrequency" ADD VALUE IF NOT EXISTS 'SEMI_MONTHLY';
+ALTER TYPE "RecurringFrequency" ADD VALUE IF NOT EXISTS 'LAST_BUSINESS_DAY';
+
+-- AlterTable
+ALTER TABLE "recurring_template" ADD COLUMN IF NOT EXISTS "interval" INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE "recurring_template" ADD COLUMN IF NOT EXISTS "anchorDay" INTEGER;
+ALTER TABLE "recurring_template" ADD COLUMN IF NOT EXISTS "endDate" TIMESTAMP(3);
+ALTER TABLE "recurring_template" ADD COLUMN IF NOT EXISTS "maxOccurrences" INTEGER;
+ALTER TABLE "recurring_template" ADD COLUMN IF NOT EXISTS "occurrenceCount" INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"retrospend-sidecar/db"
+	"retrospend-sidecar/tasks"
+)
+
+// runBackfillCommand implements `sidecar backfill-rates`, the command-line
+// form of POST /rates/backfill, and returns the process exit code.
+func runBackfillCommand(database *db.DB, sources []tasks.RateSource, args []string) int {
+	flags := flag.NewFlagSet("backfill-rates", flag.ContinueOnError)
+	from := flags.String("from", "", "first date to fill, YYYY-MM-DD (required)")
+	to := flags.String("to", "", "last date to fill, YYYY-MM-DD (default today)")
+	currencies := flags.String("currencies", "", "comma-separated currency codes, e.g. EUR,GBP (required)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sidecar backfill-rates -from YYYY-MM-DD [-to YYYY-MM-DD] -currencies EUR,GBP")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	req, err := tasks.ParseBackfillRequest(*from, *to, strings.Split(*currencies, ","))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := tasks.BackfillExchangeRates(ctx, database, sources, req, func(percent float64, message string) {
+		log.Printf("[%3.0f%%] %s", percent, message)
+	})
+	if err != nil {
+		log.Printf("❌ Rate backfill failed: %v", err)
+		return 1
+	}
+	for source, n := range report.BySource {
+		log.Printf("  - %s: %d rates", source, n)
+	}
+	log.Printf("✓ Rate backfill complete: %s", report)
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -14,13 +15,31 @@ type Config struct {
 	BackupRetentionDays int
 	BackupCron          string
 	// Importer (optional)
-	OllamaEndpoint    string
-	LLMModel          string
-	EnrichBatchSize   int
-	EnrichConcurrency int
-	PDFConcurrency    int
-	OpenRouterAPIKey  string
-	OpenRouterModel   string
+	OllamaEndpoint      string
+	LLMModel            string
+	EnrichBatchSize     int
+	EnrichConcurrency   int
+	PDFConcurrency      int
+	PDFMaxContextTokens int
+	PDFChunkOverlap     int
+	CSVStrictMode       bool
+	CSVMaxRejectedPct   int
+	MaxAmountUSD        int
+	MaxFutureDays       int
+	ExtraCurrencies     []string
+	RateMaxDaysAway     int
+	RateRetentionDays   int      // Daily exchange rates older than this are thinned to monthly
+	RateSources         []string // Exchange rate sources in priority order
+	RateOracleURL       string
+	RateECBURL          string
+	RateJSONLocation    string // URL or file path of the "json" source
+	RateJSONMapping     string // JSON-encoded mapping for the "json" source
+	RateFileDir         string // Directory of the "file" source
+	RateMaxChange       string // "type=percent" pairs, see tasks.ParseAnomalyThresholds
+	RateSafeDelete      bool   // Keep stale rates that are favorited or used by asset accounts
+	RecurringMaxCatchup int    // Missed occurrences a template catches up on per run; 0 for no limit
+	OpenRouterAPIKey    string
+	OpenRouterModel     string
 }
 
 func Load() (*Config, error) {
@@ -61,6 +80,11 @@ func Load() (*Config, error) {
 		model = "qwen2.5:7b"
 	}
 
+	rateSources := getEnvList("EXCHANGE_RATE_SOURCES")
+	if len(rateSources) == 0 {
+		rateSources = []string{"ORACLE", "ECB"}
+	}
+
 	openRouterAPIKey := os.Getenv("OPENROUTER_API_KEY")
 	openRouterModel := os.Getenv("OPENROUTER_MODEL")
 	if openRouterModel == "" {
@@ -78,6 +102,24 @@ func Load() (*Config, error) {
 		EnrichBatchSize:     getEnvInt("ENRICH_BATCH_SIZE", 20),
 		EnrichConcurrency:   getEnvInt("ENRICH_CONCURRENCY", 3),
 		PDFConcurrency:      getEnvInt("PDF_CONCURRENCY", 3),
+		PDFMaxContextTokens: getEnvInt("PDF_MAX_CONTEXT_TOKENS", 8192),
+		PDFChunkOverlap:     getEnvNonNegativeInt("PDF_CHUNK_OVERLAP", 2),
+		CSVStrictMode:       getEnvBool("CSV_STRICT_MODE", false),
+		CSVMaxRejectedPct:   getEnvNonNegativeInt("CSV_MAX_REJECTED_PERCENT", 20),
+		MaxAmountUSD:        getEnvNonNegativeInt("VALIDATION_MAX_AMOUNT_USD", 1000000),
+		MaxFutureDays:       getEnvNonNegativeInt("VALIDATION_MAX_FUTURE_DAYS", 7),
+		ExtraCurrencies:     getEnvList("VALIDATION_EXTRA_CURRENCIES"),
+		RateMaxDaysAway:     getEnvNonNegativeInt("RATE_MAX_DAYS_AWAY", 7),
+		RateRetentionDays:   getEnvNonNegativeInt("EXCHANGE_RATE_DAILY_RETENTION_DAYS", 730),
+		RateSources:         rateSources,
+		RateOracleURL:       os.Getenv("EXCHANGE_RATE_ORACLE_URL"),
+		RateECBURL:          os.Getenv("EXCHANGE_RATE_ECB_URL"),
+		RateJSONLocation:    os.Getenv("EXCHANGE_RATE_JSON_SOURCE"),
+		RateJSONMapping:     os.Getenv("EXCHANGE_RATE_JSON_MAPPING"),
+		RateFileDir:         os.Getenv("EXCHANGE_RATE_FILE_DIR"),
+		RateMaxChange:       os.Getenv("EXCHANGE_RATE_MAX_CHANGE"),
+		RateSafeDelete:      getEnvBool("EXCHANGE_RATE_SAFE_DELETE", true),
+		RecurringMaxCatchup: getEnvNonNegativeInt("RECURRING_MAX_CATCHUP", 366),
 		OpenRouterAPIKey:    openRouterAPIKey,
 		OpenRouterModel:     openRouterModel,
 	}, nil
@@ -94,3 +136,36 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return v
 }
+
+func getEnvNonNegativeInt(key string, defaultVal int) int {
+	s := os.Getenv(key)
+	if s == "" {
+		return defaultVal
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 {
+		return defaultVal
+	}
+	return v
+}
+
+// getEnvBool reads a boolean as strconv.ParseBool does ("true", "1",
+// "false", "0", ...), falling back to defaultVal when unset or invalid.
+func getEnvBool(key string, defaultVal bool) bool {
+	v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return defaultVal
+	}
+	return v
+}
+
+// getEnvList reads a comma-separated list, trimming and upper-casing entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,97 @@
+// Package currencies loads the currencies Retrospend supports from
+// currency.json (fiat) and crypto_currency.json. The web app builds
+// src/lib/currencies.ts from the same two files, so adding a currency there
+// adds it everywhere.
+package currencies
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Data describes one currency as listed in the JSON files.
+type Data struct {
+	Symbol        string  `json:"symbol"`
+	Name          string  `json:"name"`
+	SymbolNative  string  `json:"symbol_native"`
+	DecimalDigits int     `json:"decimal_digits"`
+	Rounding      float64 `json:"rounding"`
+	Code          string  `json:"code"`
+	NamePlural    string  `json:"name_plural"`
+}
+
+var (
+	fiat   map[string]Data
+	crypto map[string]Data
+)
+
+// searchDirs are tried in order for the JSON files: CURRENCY_DATA_DIR, then
+// the working directory, as in the Docker image, then the module root for
+// tests run from a package directory.
+func searchDirs() []string {
+	dirs := []string{".", "..", "../..", "../../.."}
+	if dir := os.Getenv("CURRENCY_DATA_DIR"); dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+func init() {
+	fiat = load("currency.json")
+	crypto = load("crypto_currency.json")
+}
+
+func load(name string) map[string]Data {
+	var data []byte
+	var err error
+	for _, dir := range searchDirs() {
+		data, err = os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		log.Printf("Warning: could not read %s: %v. Currency support will be limited.", name, err)
+		return nil
+	}
+
+	var list map[string]Data
+	if err := json.Unmarshal(data, &list); err != nil {
+		log.Printf("Warning: could not parse %s: %v. Currency support will be limited.", name, err)
+		return nil
+	}
+	return list
+}
+
+// Fiat returns the supported fiat currencies by code, or nil when
+// currency.json could not be loaded.
+func Fiat() map[string]Data {
+	return fiat
+}
+
+// CryptoLoaded reports whether crypto_currency.json was loaded. Callers that
+// delete data for unsupported cryptos must check it first, as without the
+// list every crypto looks unsupported.
+func CryptoLoaded() bool {
+	return crypto != nil
+}
+
+// IsCrypto reports whether code is a supported crypto asset.
+func IsCrypto(code string) bool {
+	_, ok := crypto[strings.ToUpper(code)]
+	return ok
+}
+
+// CryptoCodes returns the codes of the supported crypto assets, sorted.
+func CryptoCodes() []string {
+	codes := make([]string, 0, len(crypto))
+	for code := range crypto {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
@@ -0,0 +1,44 @@
+package currencies
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLoadsSharedFiles(t *testing.T) {
+	if _, ok := Fiat()["USD"]; !ok {
+		t.Fatal("expected currency.json to be loaded with USD")
+	}
+	if !CryptoLoaded() {
+		t.Fatal("expected crypto_currency.json to be loaded")
+	}
+	if !IsCrypto("BTC") || !IsCrypto("usdt") {
+		t.Error("expected BTC and USDT to be supported cryptos")
+	}
+	if IsCrypto("EUR") || IsCrypto("SHIB") {
+		t.Error("expected EUR and SHIB not to be supported cryptos")
+	}
+}
+
+func TestCryptoCodesSorted(t *testing.T) {
+	codes := CryptoCodes()
+	if len(codes) == 0 || !slices.IsSorted(codes) {
+		t.Errorf("expected sorted crypto codes, got %v", codes)
+	}
+}
+
+func TestFilesAgree(t *testing.T) {
+	for code, c := range Fiat() {
+		if c.Code != code {
+			t.Errorf("currency.json key %s has code %s", code, c.Code)
+		}
+		if IsCrypto(code) {
+			t.Errorf("%s is listed as both fiat and crypto", code)
+		}
+	}
+	for code, c := range crypto {
+		if c.Code != code {
+			t.Errorf("crypto_currency.json key %s has code %s", code, c.Code)
+		}
+	}
+}
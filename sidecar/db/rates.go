@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate is a row of exchange_rate. Rate is in units of Currency per
+// US dollar, except for crypto, which is quoted in US dollars per coin.
+type ExchangeRate struct {
+	Currency string          `json:"currency"`
+	Type     string          `json:"type"`
+	Rate     decimal.Decimal `json:"rate"`
+	Date     time.Time       `json:"date"`
+	Source   string          `json:"source,omitempty"` // Rate source it was synced from; empty for USD and older rows
+}
+
+// ToUSD converts an amount in the rate's currency to US dollars.
+func (r ExchangeRate) ToUSD(amount decimal.Decimal) decimal.Decimal {
+	if r.Type == "crypto" {
+		return amount.Mul(r.Rate)
+	}
+	return amount.DivRound(r.Rate, 8)
+}
+
+// FromUSD converts an amount in US dollars to the rate's currency.
+func (r ExchangeRate) FromUSD(amount decimal.Decimal) decimal.Decimal {
+	if r.Type == "crypto" {
+		return amount.DivRound(r.Rate, 8)
+	}
+	return amount.Mul(r.Rate)
+}
+
+// Conversion is an amount converted between two currencies through USD,
+// with the rate of each side.
+type Conversion struct {
+	Amount    decimal.Decimal `json:"amount"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Converted decimal.Decimal `json:"converted"`
+	Rate      decimal.Decimal `json:"rate"` // Units of To per unit of From
+	FromRate  ExchangeRate    `json:"fromRate"`
+	ToRate    ExchangeRate    `json:"toRate"`
+}
+
+// rateTypeOrder ranks rate types when a currency has several: crypto, then
+// blue, then official.
+const rateTypeOrder = `CASE WHEN type = 'crypto' THEN 0 WHEN type = 'blue' THEN 1 WHEN type = 'official' THEN 2 ELSE 3 END`
+
+// staleTypeDays is how far a rate type's latest rate may trail the newest
+// rate of its currency before BestRate stops preferring it, so a type that is
+// no longer synced does not outrank a fresh one.
+const staleTypeDays = 7
+
+// ErrNoRate is returned when no exchange rate is stored for a currency.
+var ErrNoRate = errors.New("no exchange rate")
+
+// RateAt returns the rate of a currency on a date: the latest rate of
+// preferredType on or before the date when there is one, otherwise BestRate.
+// An empty preferredType goes straight to BestRate.
+func (db *DB) RateAt(ctx context.Context, currency string, date time.Time, preferredType string) (ExchangeRate, error) {
+	if preferredType == "" || currency == "USD" {
+		return db.BestRate(ctx, currency, date)
+	}
+	rate, err := db.queryRate(ctx, `
+		SELECT currency, type, rate::text, date, COALESCE(source, '') FROM exchange_rate
+		WHERE currency = $1 AND date <= $2 AND rate > 0 AND type = $3
+		ORDER BY date DESC
+		LIMIT 1
+	`, currency, date, preferredType)
+	if errors.Is(err, ErrNoRate) {
+		return db.BestRate(ctx, currency, date)
+	}
+	return rate, err
+}
+
+// Convert converts an amount between two currencies on a date, through USD,
+// using RateAt for each side. This is the one conversion path shared by
+// recurring expenses and the /rates endpoints; imports convert with the same
+// ExchangeRate.ToUSD.
+func (db *DB) Convert(ctx context.Context, amount decimal.Decimal, from, to string, date time.Time, preferredType string) (Conversion, error) {
+	conv := Conversion{Amount: amount, From: from, To: to}
+	var err error
+	if conv.FromRate, err = db.RateAt(ctx, from, date, preferredType); err != nil {
+		return conv, err
+	}
+	if conv.ToRate, err = db.RateAt(ctx, to, date, preferredType); err != nil {
+		return conv, err
+	}
+	conv.Converted = conv.ToRate.FromUSD(conv.FromRate.ToUSD(amount))
+	conv.Rate = conv.ToRate.FromUSD(conv.FromRate.ToUSD(decimal.NewFromInt(1)))
+	return conv, nil
+}
+
+// BestRate returns the preferred rate for a currency on a date: the latest
+// rate on or before the date of the best ranked type, among the types whose
+// latest rate is within staleTypeDays of the currency's newest. USD is
+// always 1.
+func (db *DB) BestRate(ctx context.Context, currency string, date time.Time) (ExchangeRate, error) {
+	if currency == "USD" {
+		return ExchangeRate{Currency: "USD", Type: "official", Rate: decimal.NewFromInt(1), Date: date}, nil
+	}
+	return db.queryRate(ctx, `
+		WITH latest AS (
+			SELECT DISTINCT ON (type) currency, type, rate, date, source FROM exchange_rate
+			WHERE currency = $1 AND date <= $2 AND rate > 0
+			ORDER BY type, date DESC
+		)
+		SELECT currency, type, rate::text, date, COALESCE(source, '') FROM latest
+		WHERE date >= (SELECT max(date) FROM latest) - make_interval(days => $3::int)
+		ORDER BY `+rateTypeOrder+`, date DESC
+		LIMIT 1
+	`, currency, date, staleTypeDays)
+}
+
+// NearestRate is BestRate, falling back to the earliest rate after the date
+// when none precedes it, e.g. for statements older than the rate history.
+func (db *DB) NearestRate(ctx context.Context, currency string, date time.Time) (ExchangeRate, error) {
+	rate, err := db.BestRate(ctx, currency, date)
+	if !errors.Is(err, ErrNoRate) {
+		return rate, err
+	}
+	return db.queryRate(ctx, `
+		SELECT currency, type, rate::text, date, COALESCE(source, '') FROM exchange_rate
+		WHERE currency = $1 AND date > $2 AND rate > 0
+		ORDER BY `+rateTypeOrder+`, date ASC
+		LIMIT 1
+	`, currency, date)
+}
+
+func (db *DB) queryRate(ctx context.Context, query string, currency string, date time.Time, args ...any) (ExchangeRate, error) {
+	var rate ExchangeRate
+	var raw string
+	args = append([]any{currency, date}, args...)
+	err := db.Pool.QueryRow(ctx, query, args...).Scan(&rate.Currency, &rate.Type, &raw, &rate.Date, &rate.Source)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rate, fmt.Errorf("%w for %s", ErrNoRate, currency)
+	}
+	if err != nil {
+		return rate, fmt.Errorf("failed to query exchange rate for %s: %w", currency, err)
+	}
+	if rate.Rate, err = decimal.NewFromString(raw); err != nil {
+		return rate, fmt.Errorf("invalid exchange rate %q for %s: %w", raw, currency, err)
+	}
+	return rate, nil
+}
+
+// LatestUnitsPerUSD returns the most recent exchange rate of every currency
+// as units of the currency per US dollar. Where a currency has several rate
+// types, crypto is preferred, then blue, then official, as for recurring
+// expenses. Crypto rates are stored as USD per coin and are inverted.
+func (db *DB) LatestUnitsPerUSD(ctx context.Context) (map[string]decimal.Decimal, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT ON (currency) currency, type, rate::text
+		FROM exchange_rate
+		WHERE rate > 0
+		ORDER BY currency, `+rateTypeOrder+`, date DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := map[string]decimal.Decimal{"USD": decimal.NewFromInt(1)}
+	for rows.Next() {
+		var currency, rateType, raw string
+		if err := rows.Scan(&currency, &rateType, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rate, err := decimal.NewFromString(raw)
+		if err != nil || !rate.IsPositive() {
+			continue
+		}
+		if rateType == "crypto" {
+			rate = decimal.NewFromInt(1).DivRound(rate, 18)
+		}
+		rates[currency] = rate
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestExchangeRate_FiatQuotedPerUSD(t *testing.T) {
+	ars := ExchangeRate{Currency: "ARS", Type: "blue", Rate: dec("1000")}
+	if got := ars.ToUSD(dec("5000")); !got.Equal(dec("5")) {
+		t.Errorf("expected 5 USD, got %s", got)
+	}
+	if got := ars.FromUSD(dec("5")); !got.Equal(dec("5000")) {
+		t.Errorf("expected 5000 ARS, got %s", got)
+	}
+}
+
+func TestExchangeRate_CryptoQuotedInUSD(t *testing.T) {
+	btc := ExchangeRate{Currency: "BTC", Type: "crypto", Rate: dec("50000")}
+	if got := btc.ToUSD(dec("0.01")); !got.Equal(dec("500")) {
+		t.Errorf("expected 500 USD, got %s", got)
+	}
+	if got := btc.FromUSD(dec("500")); !got.Equal(dec("0.01")) {
+		t.Errorf("expected 0.01 BTC, got %s", got)
+	}
+}
@@ -6,5 +6,7 @@ import (
 )
 
 type BankAdapter interface {
-	Parse(file io.Reader) ([]models.NormalizedTransaction, error)
+	// Parse reads transactions from file. Rows that cannot be parsed are
+	// skipped and reported as warnings on metadata.
+	Parse(file io.Reader, metadata *models.ImportMetadata) ([]models.NormalizedTransaction, error)
 }
@@ -0,0 +1,217 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Negative amount notations recorded in CSVSchema.NegativeStyle.
+const (
+	NegativeLeadingMinus  = "leading_minus"  // -45.00, -$45.00, $-45.00
+	NegativeTrailingMinus = "trailing_minus" // 45.00-
+	NegativeParentheses   = "parentheses"    // (45.00)
+)
+
+// ErrEmptyAmount is returned by ParseAmount for blank cells.
+var ErrEmptyAmount = errors.New("empty amount")
+
+var (
+	creditSuffix = regexp.MustCompile(`(?i)\s*\bCR\.?\s*$`)
+	debitSuffix  = regexp.MustCompile(`(?i)\s*\bDR\.?\s*$`)
+)
+
+// ParseAmount parses a monetary amount written in any locale. Currency symbols
+// and codes ("$", "R$", "€", "EUR") before or after the number are ignored;
+// letters or digits anywhere else, as in "1e5", are an error. Negatives may be
+// written with a leading or trailing minus, in parentheses, or with a "CR"
+// suffix. decimalSep is "." or "," (empty means "."). thousandsSep is the
+// grouping character; empty accepts the other of "." and ",". Spaces and
+// apostrophes are always accepted as grouping. negativeStyle is the file's
+// notation for negatives: a trailing minus or parentheses in a file that uses
+// another one is an error, while a leading minus is always accepted. Empty
+// accepts every notation.
+func ParseAmount(raw string, decimalSep string, thousandsSep string, negativeStyle string) (decimal.Decimal, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return decimal.Zero, ErrEmptyAmount
+	}
+	if decimalSep == "" {
+		decimalSep = "."
+	}
+	if thousandsSep == "" {
+		thousandsSep = ","
+		if decimalSep == "," {
+			thousandsSep = "."
+		}
+	}
+
+	negative := false
+	if creditSuffix.MatchString(s) {
+		negative = true
+		s = creditSuffix.ReplaceAllString(s, "")
+	} else {
+		s = debitSuffix.ReplaceAllString(s, "")
+	}
+
+	var number strings.Builder
+	// Digits since the last grouping separator; -1 when no group is open.
+	// Groups must be exactly three digits and end before the decimal part.
+	groupDigits := -1
+	closeGroup := func() error {
+		if groupDigits != -1 && groupDigits != 3 {
+			return fmt.Errorf("misplaced thousands separator in amount %q", raw)
+		}
+		groupDigits = -1
+		return nil
+	}
+	seenDecimal := false
+	// Where the number stands: before its first digit, within it, or past it
+	// once a currency code follows
+	const (
+		beforeNumber = iota
+		inNumber
+		afterNumber
+	)
+	position := beforeNumber
+	style := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			if position == afterNumber {
+				return decimal.Zero, fmt.Errorf("unexpected digit %q after the number in amount %q", r, raw)
+			}
+			position = inNumber
+			number.WriteRune(r)
+			if groupDigits >= 0 {
+				groupDigits++
+			}
+		case r == '.' || r == ',':
+			sep := string(r)
+			if err := closeGroup(); err != nil {
+				return decimal.Zero, err
+			}
+			if sep == decimalSep {
+				number.WriteByte('.')
+				seenDecimal = true
+			} else if sep != thousandsSep || seenDecimal {
+				return decimal.Zero, fmt.Errorf("unexpected separator %q in amount %q", sep, raw)
+			} else {
+				groupDigits = 0
+			}
+		case r == '-' || r == '−': // ASCII hyphen-minus or Unicode minus
+			negative = true
+			if position == beforeNumber {
+				style = NegativeLeadingMinus
+			} else {
+				style = NegativeTrailingMinus
+			}
+		case r == '(' || r == ')':
+			negative = true
+			style = NegativeParentheses
+		case unicode.IsLetter(r):
+			// Currency codes come before or after the number, never inside it
+			if position == inNumber {
+				position = afterNumber
+			}
+		case r == '+', r == '\'', unicode.IsSpace(r), unicode.Is(unicode.Sc, r):
+			// Sign, grouping and currency symbols
+		default:
+			return decimal.Zero, fmt.Errorf("unexpected character %q in amount %q", r, raw)
+		}
+	}
+
+	if err := closeGroup(); err != nil {
+		return decimal.Zero, err
+	}
+	if negativeStyle != "" && style != "" && style != NegativeLeadingMinus && style != negativeStyle {
+		return decimal.Zero, fmt.Errorf("negative amount %q is written as %s, but the file uses %s", raw, style, negativeStyle)
+	}
+
+	digits := number.String()
+	if strings.Trim(digits, ".") == "" {
+		return decimal.Zero, fmt.Errorf("no digits in amount %q", raw)
+	}
+	if strings.Count(digits, ".") > 1 {
+		return decimal.Zero, fmt.Errorf("more than one decimal separator in amount %q", raw)
+	}
+
+	val, err := decimal.NewFromString(digits)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q: %w", raw, err)
+	}
+	if negative {
+		val = val.Neg()
+	}
+	return val, nil
+}
+
+// DetectNumberFormat infers the decimal and thousands separators from a
+// column of amounts. A value votes when its separators are unambiguous
+// ("1.234,56", "4,50", "1,234,567"); a single separator followed by exactly
+// three digits ("1,234") does not vote. Defaults to "." and ",".
+func DetectNumberFormat(values []string) (decimalSep string, thousandsSep string) {
+	commaVotes, dotVotes := 0, 0
+	for _, v := range values {
+		lastComma := strings.LastIndex(v, ",")
+		lastDot := strings.LastIndex(v, ".")
+		switch {
+		case lastComma >= 0 && lastDot >= 0:
+			if lastComma > lastDot {
+				commaVotes++
+			} else {
+				dotVotes++
+			}
+		case lastComma >= 0:
+			if strings.Count(v, ",") > 1 {
+				dotVotes++ // repeated commas are grouping
+			} else if digitsAfter(v, lastComma) != 3 {
+				commaVotes++
+			}
+		case lastDot >= 0:
+			if strings.Count(v, ".") > 1 {
+				commaVotes++
+			} else if digitsAfter(v, lastDot) != 3 {
+				dotVotes++
+			}
+		}
+	}
+
+	if commaVotes > dotVotes {
+		return ",", "."
+	}
+	return ".", ","
+}
+
+func digitsAfter(s string, idx int) int {
+	n := 0
+	for _, r := range s[idx+1:] {
+		if r < '0' || r > '9' {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// DetectNegativeStyle reports how a column of amounts writes negative values,
+// or "" when it has none.
+func DetectNegativeStyle(values []string) string {
+	style := ""
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		switch {
+		case strings.HasPrefix(v, "(") || strings.HasSuffix(v, ")"):
+			return NegativeParentheses
+		case strings.HasSuffix(v, "-"):
+			return NegativeTrailingMinus
+		case strings.Contains(v, "-") && style == "":
+			style = NegativeLeadingMinus
+		}
+	}
+	return style
+}
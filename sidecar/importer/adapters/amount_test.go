@@ -0,0 +1,114 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		raw       string
+		decimal   string
+		thousands string
+		want      string
+	}{
+		{"$1,234.56", ".", ",", "1234.56"},
+		{"-45.00", "", "", "-45"},
+		{"1.234,56", ",", ".", "1234.56"},
+		{"€12,50", ",", "", "12.50"},
+		{"R$ 30,00", ",", ".", "30"},
+		{"R$ -1.030,00", ",", ".", "-1030"},
+		{"(45.00)", ".", ",", "-45"},
+		{"45.00-", ".", ",", "-45"},
+		{"$-45.00", ".", ",", "-45"},
+		{"45.00 CR", ".", ",", "-45"},
+		{"45.00 DR", ".", ",", "45"},
+		{"EUR 1 234,56", ",", " ", "1234.56"},
+		{"CHF 1'234.50", ".", "'", "1234.50"},
+		{"12.50 USD", ".", ",", "12.50"},
+		{"−7,25", ",", "", "-7.25"}, // Unicode minus
+		{"0.00012345 BTC", ".", ",", "0.00012345"},
+		{"1.234.567,89012345", ",", ".", "1234567.89012345"},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.raw, tt.decimal, tt.thousands, "")
+		if err != nil {
+			t.Errorf("ParseAmount(%q): unexpected error: %v", tt.raw, err)
+			continue
+		}
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("ParseAmount(%q) = %v; expected %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount_Errors(t *testing.T) {
+	if _, err := ParseAmount("  ", ".", ",", ""); err != ErrEmptyAmount {
+		t.Errorf("expected ErrEmptyAmount for a blank cell, got %v", err)
+	}
+	for _, raw := range []string{"N/A", "1.234.56", "12#50", "1e5", "12abc34", "12.50 USD 3"} {
+		if _, err := ParseAmount(raw, ".", ",", ""); err == nil {
+			t.Errorf("ParseAmount(%q): expected an error", raw)
+		}
+	}
+	// A thousands separator that does not match the configured one is rejected
+	if _, err := ParseAmount("1.234,56", ".", ",", ""); err == nil {
+		t.Error("expected an error for a European amount parsed with US separators")
+	}
+}
+
+func TestParseAmount_NegativeStyle(t *testing.T) {
+	// A leading minus reads the same in every file
+	for _, style := range []string{"", NegativeLeadingMinus, NegativeTrailingMinus, NegativeParentheses} {
+		got, err := ParseAmount("-45.00", ".", ",", style)
+		if err != nil || !got.Equal(decimal.RequireFromString("-45")) {
+			t.Errorf("style %q: expected -45, got %v (err %v)", style, got, err)
+		}
+	}
+	if got, err := ParseAmount("45.00-", ".", ",", NegativeTrailingMinus); err != nil || !got.IsNegative() {
+		t.Errorf("expected a trailing minus to be negative, got %v (err %v)", got, err)
+	}
+	// Notations the file does not use are reported rather than guessed at
+	if _, err := ParseAmount("45.00-", ".", ",", NegativeParentheses); err == nil {
+		t.Error("expected an error for a trailing minus in a parentheses file")
+	}
+	if _, err := ParseAmount("(45.00)", ".", ",", NegativeLeadingMinus); err == nil {
+		t.Error("expected an error for parentheses in a leading-minus file")
+	}
+}
+
+func TestDetectNumberFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		values        []string
+		wantDecimal   string
+		wantThousands string
+	}{
+		{"US", []string{"1,234.56", "12.50"}, ".", ","},
+		{"European", []string{"1.234,56", "12,50"}, ",", "."},
+		{"decimal comma only", []string{"4,50", "-12,00"}, ",", "."},
+		{"ambiguous defaults to dot", []string{"1,234", "2,500"}, ".", ","},
+		{"grouped without decimals", []string{"1.234.567", "1.000"}, ",", "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, thou := DetectNumberFormat(tt.values)
+			if dec != tt.wantDecimal || thou != tt.wantThousands {
+				t.Errorf("got decimal=%q thousands=%q; expected %q and %q", dec, thou, tt.wantDecimal, tt.wantThousands)
+			}
+		})
+	}
+}
+
+func TestDetectNegativeStyle(t *testing.T) {
+	if got := DetectNegativeStyle([]string{"12.00", "(45.00)", "-3.00"}); got != NegativeParentheses {
+		t.Errorf("expected parentheses, got %q", got)
+	}
+	if got := DetectNegativeStyle([]string{"12.00", "45.00-"}); got != NegativeTrailingMinus {
+		t.Errorf("expected trailing minus, got %q", got)
+	}
+	if got := DetectNegativeStyle([]string{"12.00"}); got != "" {
+		t.Errorf("expected no style without negatives, got %q", got)
+	}
+}
@@ -0,0 +1,282 @@
+package adapters
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DateOrder is the order of day, month and year in a numeric date.
+type DateOrder int
+
+const (
+	OrderMDY DateOrder = iota // 03/04/2025 is March 4
+	OrderDMY                  // 03/04/2025 is 3 April
+	OrderYMD                  // 2025-03-04
+)
+
+func (o DateOrder) String() string {
+	switch o {
+	case OrderDMY:
+		return "DD/MM/YYYY"
+	case OrderYMD:
+		return "YYYY-MM-DD"
+	default:
+		return "MM/DD/YYYY"
+	}
+}
+
+// monthNames maps English, Spanish and Portuguese month names and
+// abbreviations to their month.
+var monthNames = map[string]time.Month{
+	"jan": time.January, "january": time.January, "ene": time.January, "enero": time.January, "janeiro": time.January,
+	"feb": time.February, "february": time.February, "febrero": time.February, "fev": time.February, "fevereiro": time.February,
+	"mar": time.March, "march": time.March, "marzo": time.March, "março": time.March, "marco": time.March,
+	"apr": time.April, "april": time.April, "abr": time.April, "abril": time.April,
+	"may": time.May, "mayo": time.May, "mai": time.May, "maio": time.May,
+	"jun": time.June, "june": time.June, "junio": time.June, "junho": time.June,
+	"jul": time.July, "july": time.July, "julio": time.July, "julho": time.July,
+	"aug": time.August, "august": time.August, "ago": time.August, "agosto": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September, "septiembre": time.September,
+	"set": time.September, "setiembre": time.September, "setembro": time.September,
+	"oct": time.October, "october": time.October, "octubre": time.October, "out": time.October, "outubro": time.October,
+	"nov": time.November, "november": time.November, "noviembre": time.November, "novembro": time.November,
+	"dec": time.December, "december": time.December, "dic": time.December, "diciembre": time.December,
+	"dez": time.December, "dezembro": time.December,
+}
+
+// timeSuffix matches a time of day after the date ("2025-03-04T10:00:00Z",
+// "03/04/2025 14:05", "03/04/2025 2:05 PM").
+var timeSuffix = regexp.MustCompile(`(?i)([T\s]+\d{1,2}:\d{2}(:\d{2}(\.\d+)?)?\s*([AP]\.?M\.?)?\s*(Z|[+-]\d{2}:?\d{2})?)$`)
+
+// ParseDateWithOrder parses a date in any common notation: numeric with "/",
+// "-", "." or spaces as separators, or with a month name ("4 Mar 2025",
+// "Mar 4, 2025", "4 de março de 2025"). order decides between DD/MM and MM/DD
+// for numeric dates; dates starting with a four-digit year are always Y-M-D,
+// and dates with a month name need no order at all.
+func ParseDateWithOrder(value string, order DateOrder) (time.Time, bool) {
+	value = timeSuffix.ReplaceAllString(strings.TrimSpace(value), "")
+
+	var numbers []string
+	var month time.Month
+	for _, token := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		switch {
+		case unicode.IsDigit(rune(token[0])):
+			numbers = append(numbers, token)
+		case token == "de" || token == "del":
+			// "4 de marzo de 2025"
+		default:
+			m, ok := monthNames[token]
+			if !ok || month != 0 {
+				return time.Time{}, false
+			}
+			month = m
+		}
+	}
+
+	var year, day int
+	if month != 0 {
+		if len(numbers) != 2 {
+			return time.Time{}, false
+		}
+		// The year is the four-digit number, or the last one ("04-Mar-25")
+		yearIdx := 1
+		if len(numbers[0]) == 4 {
+			yearIdx = 0
+		}
+		year, day = atoi(numbers[yearIdx]), atoi(numbers[1-yearIdx])
+		if len(numbers[yearIdx]) <= 2 {
+			year = expandYear(year)
+		}
+		return validDate(year, month, day)
+	}
+
+	if len(numbers) != 3 {
+		return time.Time{}, false
+	}
+	if len(numbers[0]) == 4 {
+		return validDate(atoi(numbers[0]), time.Month(atoi(numbers[1])), atoi(numbers[2]))
+	}
+	if order == OrderYMD || (len(numbers[2]) != 4 && len(numbers[2]) > 2) {
+		return time.Time{}, false
+	}
+	year = atoi(numbers[2])
+	if len(numbers[2]) <= 2 {
+		year = expandYear(year)
+	}
+	if order == OrderDMY {
+		return validDate(year, time.Month(atoi(numbers[1])), atoi(numbers[0]))
+	}
+	return validDate(year, time.Month(atoi(numbers[0])), atoi(numbers[1]))
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// expandYear maps a two-digit year the way time.Parse does for "06".
+func expandYear(year int) int {
+	if year >= 69 {
+		return 1900 + year
+	}
+	return 2000 + year
+}
+
+// validDate rejects out-of-range parts instead of letting time.Date
+// normalize them (month 13, February 30).
+func validDate(year int, month time.Month, day int) (time.Time, bool) {
+	if year < 1 || month < 1 || month > 12 || day < 1 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// DateInference is the outcome of InferDateOrder.
+type DateInference struct {
+	Order DateOrder
+	// Ambiguous is set when numeric dates read validly as both DD/MM and MM/DD
+	// and nothing in the file tells them apart. Order is then the hint.
+	Ambiguous bool
+	Example   string // An ambiguous value, for warnings
+	Parsed    int    // How many values parse in the chosen order
+}
+
+// InferDateOrder decides between DD/MM and MM/DD for a column of dates.
+// Unambiguous values (a day above 12) decide outright; otherwise the order
+// that keeps the file chronologically sorted wins, as statements are listed
+// by date, then the one covering a far shorter period. When nothing settles
+// it, hint is used and the result is marked ambiguous so the caller can warn
+// instead of guessing silently.
+func InferDateOrder(values []string, hint DateOrder) DateInference {
+	orders := []DateOrder{OrderMDY, OrderDMY}
+	parsed := make(map[DateOrder][]time.Time)
+	differing := 0
+	example := ""
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		mdy, okMDY := ParseDateWithOrder(v, OrderMDY)
+		dmy, okDMY := ParseDateWithOrder(v, OrderDMY)
+		if okMDY {
+			parsed[OrderMDY] = append(parsed[OrderMDY], mdy)
+		}
+		if okDMY {
+			parsed[OrderDMY] = append(parsed[OrderDMY], dmy)
+		}
+		if okMDY && okDMY && !mdy.Equal(dmy) {
+			differing++
+			if example == "" {
+				example = strings.TrimSpace(v)
+			}
+		}
+	}
+
+	if hint == OrderYMD {
+		hint = OrderMDY
+	}
+	result := DateInference{Order: hint, Parsed: len(parsed[hint])}
+
+	// A day above 12 rules an order out
+	if len(parsed[OrderMDY]) != len(parsed[OrderDMY]) {
+		best := OrderMDY
+		if len(parsed[OrderDMY]) > len(parsed[OrderMDY]) {
+			best = OrderDMY
+		}
+		return DateInference{Order: best, Parsed: len(parsed[best])}
+	}
+	// ISO dates and month names read the same either way
+	if differing == 0 {
+		return result
+	}
+
+	scores := make(map[DateOrder]int)
+	for _, order := range orders {
+		scores[order] = sortedPairs(parsed[order])
+	}
+	switch {
+	case scores[OrderMDY] > scores[OrderDMY]:
+		return DateInference{Order: OrderMDY, Parsed: len(parsed[OrderMDY])}
+	case scores[OrderDMY] > scores[OrderMDY]:
+		return DateInference{Order: OrderDMY, Parsed: len(parsed[OrderDMY])}
+	}
+
+	// Both sorted: a statement covers weeks, so days read as months stretch
+	// the file over a far longer period
+	spanMDY, spanDMY := dateSpan(parsed[OrderMDY]), dateSpan(parsed[OrderDMY])
+	switch {
+	case spanDMY >= spanRatio*spanMDY && spanDMY > 0:
+		return DateInference{Order: OrderMDY, Parsed: len(parsed[OrderMDY])}
+	case spanMDY >= spanRatio*spanDMY && spanMDY > 0:
+		return DateInference{Order: OrderDMY, Parsed: len(parsed[OrderDMY])}
+	}
+
+	result.Ambiguous = true
+	result.Example = example
+	return result
+}
+
+// spanRatio is how many times longer one reading must stretch the file for
+// the shorter one to win.
+const spanRatio = 3
+
+func dateSpan(dates []time.Time) time.Duration {
+	if len(dates) == 0 {
+		return 0
+	}
+	first, last := dates[0], dates[0]
+	for _, d := range dates[1:] {
+		if d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+	return last.Sub(first)
+}
+
+// sortedPairs counts adjacent dates in order, ascending or descending,
+// whichever direction the file is sorted in.
+func sortedPairs(dates []time.Time) int {
+	asc, desc := 0, 0
+	for i := 1; i < len(dates); i++ {
+		if !dates[i].Before(dates[i-1]) {
+			asc++
+		}
+		if !dates[i].After(dates[i-1]) {
+			desc++
+		}
+	}
+	return max(asc, desc)
+}
+
+// LayoutOrder reads the day/month order of a Go time layout such as the
+// schema's DateFormat ("02/01/2006" is DD/MM). Defaults to MM/DD.
+func LayoutOrder(layout string) DateOrder {
+	for _, token := range strings.FieldsFunc(layout, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		switch token {
+		case "2006", "06":
+			return OrderYMD
+		case "01", "1", "Jan", "January":
+			return OrderMDY
+		case "02", "2", "_2":
+			return OrderDMY
+		}
+	}
+	return OrderMDY
+}
@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"testing"
+)
+
+func TestParseDateWithOrder(t *testing.T) {
+	tests := []struct {
+		value string
+		order DateOrder
+		want  string
+	}{
+		{"03/04/2025", OrderMDY, "2025-03-04"},
+		{"03/04/2025", OrderDMY, "2025-04-03"},
+		{"3.4.25", OrderDMY, "2025-04-03"},
+		{"2025-03-04", OrderDMY, "2025-03-04"},
+		{"2025-03-04T10:15:00Z", OrderMDY, "2025-03-04"},
+		{"03/04/2025 2:05 PM", OrderMDY, "2025-03-04"},
+		{"Mar 4, 2025", OrderDMY, "2025-03-04"},
+		{"04-Mar-25", OrderMDY, "2025-03-04"},
+		{"4 ene 2025", OrderMDY, "2025-01-04"},
+		{"4 de março de 2025", OrderMDY, "2025-03-04"},
+		{"04/dez/2025", OrderMDY, "2025-12-04"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDateWithOrder(tt.value, tt.order)
+		if !ok {
+			t.Errorf("ParseDateWithOrder(%q, %s) failed", tt.value, tt.order)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("ParseDateWithOrder(%q, %s) = %s; expected %s", tt.value, tt.order, got.Format("2006-01-02"), tt.want)
+		}
+	}
+
+	for _, value := range []string{"13/02/2025", "02/30/2025", "Total", "4 foo 2025", ""} {
+		if got, ok := ParseDateWithOrder(value, OrderMDY); ok {
+			t.Errorf("ParseDateWithOrder(%q, MDY) should fail, got %s", value, got.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestInferDateOrder_UnambiguousDayDecides(t *testing.T) {
+	values := []string{"03/04/2025", "05/04/2025", "13/04/2025"}
+	got := InferDateOrder(values, OrderMDY)
+	if got.Order != OrderDMY || got.Ambiguous {
+		t.Errorf("expected DD/MM, got %s (ambiguous=%v)", got.Order, got.Ambiguous)
+	}
+}
+
+func TestInferDateOrder_SortedOrderDecides(t *testing.T) {
+	// Read as MM/DD these jump Jan 2 -> Feb 3 -> Mar 1; as DD/MM they run
+	// 1 Feb, 3 Feb, 2 Mar... both sorted. Add a row that only sorts one way.
+	values := []string{"01/02/2025", "03/02/2025", "10/02/2025", "02/03/2025"}
+	got := InferDateOrder(values, OrderMDY)
+	if got.Order != OrderDMY || got.Ambiguous {
+		t.Errorf("expected DD/MM from date ordering, got %s (ambiguous=%v)", got.Order, got.Ambiguous)
+	}
+}
+
+func TestInferDateOrder_AmbiguousKeepsHint(t *testing.T) {
+	values := []string{"01/02/2025", "02/03/2025"}
+	got := InferDateOrder(values, OrderDMY)
+	if !got.Ambiguous || got.Order != OrderDMY || got.Example != "01/02/2025" {
+		t.Errorf("expected an ambiguous result using the DD/MM hint, got %+v", got)
+	}
+
+	// ISO dates and month names are never ambiguous
+	if got := InferDateOrder([]string{"2025-01-02", "Jan 3, 2025"}, OrderMDY); got.Ambiguous {
+		t.Errorf("ISO and named-month dates should not be ambiguous")
+	}
+}
+
+func TestLayoutOrder(t *testing.T) {
+	tests := map[string]DateOrder{
+		"01/02/2006":  OrderMDY,
+		"1/2/2006":    OrderMDY,
+		"02/01/2006":  OrderDMY,
+		"_2 Jan 2006": OrderDMY,
+		"2006-01-02":  OrderYMD,
+		"":            OrderMDY,
+	}
+	for layout, want := range tests {
+		if got := LayoutOrder(layout); got != want {
+			t.Errorf("LayoutOrder(%q) = %s; expected %s", layout, got, want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// SniffSize is how many leading bytes of a file SniffDialect needs.
+const SniffSize = 64 * 1024
+
+// sniffLines caps how many lines are parsed when scoring delimiters.
+const sniffLines = 30
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+
+	candidateDelimiters = []string{",", ";", "\t", "|"}
+
+	// Quotes opening or closing a field, as opposed to apostrophes inside words
+	doubleQuotedField = regexp.MustCompile(`(?m)(^|[,;\t|])"|"([,;\t|]|\r?$)`)
+	singleQuotedField = regexp.MustCompile(`(?m)(^|[,;\t|])'|'([,;\t|]|\r?$)`)
+)
+
+// SniffDialect detects the encoding, byte order mark, delimiter and quote
+// character of a CSV file from its first SniffSize bytes.
+func SniffDialect(sample []byte) models.CSVDialect {
+	var dialect models.CSVDialect
+
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		dialect.Encoding, dialect.HasBOM = "utf-8", true
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		dialect.Encoding, dialect.HasBOM = "utf-16le", true
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		dialect.Encoding, dialect.HasBOM = "utf-16be", true
+	default:
+		dialect.Encoding = sniffEncoding(sample)
+	}
+
+	if dialect.Encoding == "utf-16le" || dialect.Encoding == "utf-16be" {
+		// Never split a code unit when decoding the sample
+		sample = sample[:len(sample)&^1]
+	}
+	decoded, err := io.ReadAll(decodeReader(bytes.NewReader(sample), dialect.Encoding))
+	if err != nil {
+		return dialect
+	}
+	text := string(decoded)
+
+	if len(singleQuotedField.FindAllString(text, -1)) > len(doubleQuotedField.FindAllString(text, -1)) {
+		dialect.Quote = "'"
+	}
+	dialect.Delimiter = sniffDelimiter(text, dialect.Quote)
+
+	return dialect
+}
+
+// sniffEncoding guesses the encoding of a file without a byte order mark.
+func sniffEncoding(sample []byte) string {
+	// UTF-16 text that is mostly ASCII has a zero byte in every code unit
+	head := sample
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	var evenZeros, oddZeros int
+	for i, b := range head {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	if pairs := len(head) / 2; pairs > 0 {
+		if oddZeros*10 > pairs*3 {
+			return "utf-16le"
+		}
+		if evenZeros*10 > pairs*3 {
+			return "utf-16be"
+		}
+	}
+
+	// The sample may end mid-rune; only the complete prefix has to be valid
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return "utf-8"
+	}
+	// Windows-1252 is a superset of the printable Latin-1 range
+	return "windows-1252"
+}
+
+// sniffDelimiter picks the candidate that splits the most lines into the same
+// number of fields (at least two). Ties go to the earlier candidate.
+func sniffDelimiter(text string, quote string) string {
+	lines := strings.Split(text, "\n")
+	// The last line of a sample is usually cut short
+	if len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	var sample []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			sample = append(sample, line)
+		}
+		if len(sample) >= sniffLines {
+			break
+		}
+	}
+
+	best, bestScore, bestFields := ",", 0, 0
+	for _, delim := range candidateDelimiters {
+		dialect := models.CSVDialect{Delimiter: delim, Quote: quote}
+		counts := make(map[int]int)
+		for _, line := range sample {
+			record, err := NewCSVReader(strings.NewReader(line), dialect).Read()
+			if err != nil {
+				continue
+			}
+			counts[len(record)]++
+		}
+		for fields, score := range counts {
+			if fields < 2 {
+				continue
+			}
+			if score > bestScore || (score == bestScore && fields > bestFields && delim == best) {
+				best, bestScore, bestFields = delim, score, fields
+			}
+		}
+	}
+	return best
+}
+
+// decodeReader converts a file in the given encoding to UTF-8, dropping any
+// byte order mark. Unknown encodings are read as UTF-8.
+func decodeReader(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(encoding) {
+	case "utf-16le":
+		return transform.NewReader(r, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder())
+	case "utf-16be":
+		return transform.NewReader(r, unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder())
+	case "windows-1252", "cp1252":
+		return transform.NewReader(r, charmap.Windows1252.NewDecoder())
+	case "iso-8859-1", "latin-1", "latin1":
+		return transform.NewReader(r, charmap.ISO8859_1.NewDecoder())
+	default:
+		return transform.NewReader(r, unicode.UTF8BOM.NewDecoder())
+	}
+}
+
+// CSVReader reads records from a CSV file in a given dialect.
+type CSVReader struct {
+	reader    *csv.Reader
+	swapQuote bool
+}
+
+// NewCSVReader decodes r to UTF-8 and reads it with the dialect's delimiter
+// and quote character. Rows may have differing field counts.
+func NewCSVReader(r io.Reader, dialect models.CSVDialect) *CSVReader {
+	decoded := decodeReader(r, dialect.Encoding)
+
+	// encoding/csv only understands double quotes. Swapping ' and " is a
+	// bijection, so single-quoted files are read exactly and swapped back.
+	swapQuote := dialect.Quote == "'"
+	if swapQuote {
+		decoded = transform.NewReader(decoded, quoteSwapper{})
+	}
+
+	reader := csv.NewReader(decoded)
+	if d, _ := utf8.DecodeRuneInString(dialect.Delimiter); d != utf8.RuneError && dialect.Delimiter != "" {
+		reader.Comma = d
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	return &CSVReader{reader: reader, swapQuote: swapQuote}
+}
+
+// Read returns the next record, or io.EOF at the end of the file.
+func (c *CSVReader) Read() ([]string, error) {
+	record, err := c.reader.Read()
+	if err != nil || !c.swapQuote {
+		return record, err
+	}
+	for i, field := range record {
+		record[i] = swapQuotes(field)
+	}
+	return record, nil
+}
+
+// quoteSwapper is a transformer that exchanges ' and " byte for byte.
+type quoteSwapper struct{ transform.NopResetter }
+
+func (quoteSwapper) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	n := copy(dst, src)
+	for i := 0; i < n; i++ {
+		switch dst[i] {
+		case '\'':
+			dst[i] = '"'
+		case '"':
+			dst[i] = '\''
+		}
+	}
+	if n < len(src) {
+		return n, n, transform.ErrShortDst
+	}
+	return n, n, nil
+}
+
+func swapQuotes(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\'':
+			return '"'
+		case '"':
+			return '\''
+		}
+		return r
+	}, s)
+}
+
+// FormatRecord encodes a record as a comma-separated CSV line, quoting fields
+// as needed, so rows can be shown or passed on independent of the dialect.
+func FormatRecord(record []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(record)
+	w.Flush()
+	return strings.TrimRight(b.String(), "\r\n")
+}
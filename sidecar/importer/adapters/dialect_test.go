@@ -0,0 +1,134 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"retrospend-sidecar/importer/models"
+	"retrospend-sidecar/importer/processor"
+)
+
+func readAll(t *testing.T, data []byte, dialect models.CSVDialect) [][]string {
+	t.Helper()
+	r := NewCSVReader(bytes.NewReader(data), dialect)
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func encodeUTF16LE(s string, bom bool) []byte {
+	var buf bytes.Buffer
+	if bom {
+		buf.Write([]byte{0xFF, 0xFE})
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		binary.Write(&buf, binary.LittleEndian, u)
+	}
+	return buf.Bytes()
+}
+
+func TestSniffDialect_Delimiters(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"comma", "Date,Description,Amount\n01/02/2025,COFFEE,-4.50\n01/03/2025,BAKERY,-3.00\n", ","},
+		{"semicolon with decimal commas", "Datum;Omschrijving;Bedrag\n01-02-2025;Koffie;-4,50\n03-02-2025;Bakker;-3,00\n", ";"},
+		{"tab", "Date\tDescription\tAmount\n2025-01-02\tCOFFEE, DOWNTOWN\t-4.50\n2025-01-03\tBAKERY\t-3.00\n", "\t"},
+		{"pipe", "Date|Description|Amount\n2025-01-02|COFFEE|-4.50\n", "|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := SniffDialect([]byte(tt.input))
+			if d.Delimiter != tt.want {
+				t.Errorf("delimiter = %q; expected %q", d.Delimiter, tt.want)
+			}
+			if d.Encoding != "utf-8" || d.HasBOM {
+				t.Errorf("expected plain utf-8, got %+v", d)
+			}
+		})
+	}
+}
+
+func TestSniffDialect_UTF8BOMKeepsHeaderClean(t *testing.T) {
+	data := append([]byte{0xEF, 0xBB, 0xBF}, "Date,Description,Amount\n01/02/2025,COFFEE,-4.50\n"...)
+
+	d := SniffDialect(data)
+	if !d.HasBOM || d.Encoding != "utf-8" {
+		t.Fatalf("expected utf-8 with BOM, got %+v", d)
+	}
+	if records := readAll(t, data, d); records[0][0] != "Date" {
+		t.Errorf("BOM leaked into the header: %q", records[0][0])
+	}
+}
+
+func TestSniffDialect_UTF16(t *testing.T) {
+	text := "Fecha\tDescripción\tImporte\n02/01/2025\tCAFÉ\t-4,50\n"
+	for _, bom := range []bool{true, false} {
+		data := encodeUTF16LE(text, bom)
+		d := SniffDialect(data)
+		if d.Encoding != "utf-16le" || d.HasBOM != bom || d.Delimiter != "\t" {
+			t.Errorf("bom=%t: got %+v", bom, d)
+			continue
+		}
+		records := readAll(t, data, d)
+		if records[0][1] != "Descripción" || records[1][1] != "CAFÉ" {
+			t.Errorf("bom=%t: decoded records %q", bom, records)
+		}
+	}
+}
+
+func TestSniffDialect_Latin1(t *testing.T) {
+	// "CAFÉ SÃO PAULO" in Latin-1 / Windows-1252
+	data := []byte("Data;Descricao;Valor\n02/01/2025;CAF\xC9 S\xC3O PAULO;-4,50\n")
+
+	d := SniffDialect(data)
+	if d.Encoding != "windows-1252" {
+		t.Fatalf("expected windows-1252, got %q", d.Encoding)
+	}
+	if records := readAll(t, data, d); records[1][1] != "CAFÉ SÃO PAULO" {
+		t.Errorf("accents were corrupted: %q", records[1][1])
+	}
+}
+
+func TestSniffDialect_SingleQuotes(t *testing.T) {
+	data := []byte("'Date','Description','Amount'\n'01/02/2025','COFFEE, DOWNTOWN','-4.50'\n'01/03/2025','MCDONALD\"S','-8.00'\n")
+
+	d := SniffDialect(data)
+	if d.Quote != "'" || d.Delimiter != "," {
+		t.Fatalf("expected single-quoted comma dialect, got %+v", d)
+	}
+	records := readAll(t, data, d)
+	if records[1][1] != "COFFEE, DOWNTOWN" || records[2][1] != `MCDONALD"S` {
+		t.Errorf("unexpected records: %q", records)
+	}
+}
+
+func TestParseDynamicCSV_UsesSchemaDialect(t *testing.T) {
+	input := "Datum;Omschrijving;Bedrag\n01/02/2025;KOFFIE, CENTRUM;-4.50\n"
+	schema := models.CSVSchema{
+		DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006",
+		Dialect: models.CSVDialect{Delimiter: ";"},
+	}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Title != "KOFFIE, CENTRUM" || !txs[0].Amount.Equal(dec("4.50")) {
+		t.Errorf("unexpected transactions: %+v", txs)
+	}
+}
@@ -1,19 +1,25 @@
 package adapters
 
 import (
-	"encoding/csv"
 	"fmt"
+	"retrospend-sidecar/importer/llm"
 	"retrospend-sidecar/importer/models"
+	"retrospend-sidecar/importer/processor"
 	"io"
 	"log"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // DynamicAdapter implements the BankAdapter interface using a discovered schema.
 type DynamicAdapter struct {
-	Schema models.CSVSchema
+	Schema  models.CSVSchema
+	Profile string // Name of the matched bank profile; empty for discovered schemas
+	// Currency settles symbols such as "$" written in the amount cells
+	Currency processor.CurrencyContext
 }
 
 // NewDynamicAdapter creates a new DynamicAdapter with the given schema.
@@ -22,12 +28,16 @@ func NewDynamicAdapter(schema models.CSVSchema) *DynamicAdapter {
 }
 
 // Parse implements the BankAdapter interface.
-func (a *DynamicAdapter) Parse(reader io.Reader) ([]models.NormalizedTransaction, error) {
-	return ParseDynamicCSV(reader, a.Schema)
+func (a *DynamicAdapter) Parse(reader io.Reader, metadata *models.ImportMetadata) ([]models.NormalizedTransaction, error) {
+	return ParseDynamicCSV(reader, a.Schema, a.Currency, metadata)
 }
 
 // ParseDynamicCSV parses a CSV file using the provided schema mapping.
-func ParseDynamicCSV(reader io.Reader, schema models.CSVSchema) ([]models.NormalizedTransaction, error) {
+// Without a currency column, a symbol or code in the amount ("€12.50",
+// "12,50 EUR") sets the currency, read in the given context.
+// Rows whose date or amount cannot be parsed are skipped and reported as
+// warnings on metadata, which may be nil.
+func ParseDynamicCSV(reader io.Reader, schema models.CSVSchema, currency processor.CurrencyContext, metadata *models.ImportMetadata) ([]models.NormalizedTransaction, error) {
 	// Validate required schema fields
 	if schema.DateColIdx < 0 {
 		return nil, fmt.Errorf("schema validation failed: DateColIdx is not set")
@@ -39,140 +49,324 @@ func ParseDynamicCSV(reader io.Reader, schema models.CSVSchema) ([]models.Normal
 		return nil, fmt.Errorf("schema validation failed: no amount column specified")
 	}
 
-	csvReader := csv.NewReader(reader)
-
-	// Read and skip the header row.
-	_, err := csvReader.Read()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read header in dynamic parser: %w", err)
-	}
-
-	var transactions []models.NormalizedTransaction
-	rowNum := 1 // Header was row 0
+	csvReader := NewCSVReader(reader, schema.Dialect)
 
+	// Read every record up front so number formats can be inferred from the
+	// whole file and footers can be trimmed from its end
+	var records [][]string
 	for {
 		record, err := csvReader.Read()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("failed to read record at row %d: %w", rowNum, err)
+			return nil, fmt.Errorf("failed to read record at row %d: %w", len(records)+1, err)
 		}
-		rowNum++
+		records = append(records, record)
+	}
 
-		// Basic validation: ensure indices are within bounds
-		maxIdx := schema.DateColIdx
-		if schema.MerchantColIdx > maxIdx {
-			maxIdx = schema.MerchantColIdx
+	// Skip any preamble and the header row itself
+	if schema.HeaderRowIdx < 0 || schema.HeaderRowIdx >= len(records) {
+		return nil, fmt.Errorf("failed to read header in dynamic parser: no record at index %d", schema.HeaderRowIdx)
+	}
+	firstRow := schema.HeaderRowIdx + 2 // 1-based row number of the first data record
+	header := records[schema.HeaderRowIdx]
+	records = records[schema.HeaderRowIdx+1:]
+
+	// Basic validation: ensure indices are within bounds
+	maxIdx := schema.DateColIdx
+	if schema.MerchantColIdx > maxIdx {
+		maxIdx = schema.MerchantColIdx
+	}
+	amountCols := []*int{schema.AmountColIdx, schema.DebitColIdx, schema.CreditColIdx}
+	for _, col := range amountCols {
+		if col != nil && *col > maxIdx {
+			maxIdx = *col
 		}
-		if schema.AmountColIdx != nil && *schema.AmountColIdx > maxIdx {
-			maxIdx = *schema.AmountColIdx
+	}
+
+	// Ignore totals and disclaimers after the last transaction; rows that are
+	// not labelled as totals are reported below in case they were transactions
+	footer := records
+	records, shortRows := trimFooter(records, schema.SkipFooterRows, maxIdx+1, schema.DateColIdx)
+	if len(records) < len(footer) {
+		log.Printf("Ignoring %d footer row(s)", len(footer)-len(records))
+	}
+
+	if schema.DecimalSeparator == "" || schema.NegativeStyle == "" {
+		var amountCells []string
+		for _, record := range records {
+			for _, col := range amountCols {
+				if col != nil && *col < len(record) && strings.TrimSpace(record[*col]) != "" {
+					amountCells = append(amountCells, record[*col])
+				}
+			}
+		}
+		if schema.DecimalSeparator == "" {
+			schema.DecimalSeparator, schema.ThousandsSeparator = DetectNumberFormat(amountCells)
 		}
-		if schema.DebitColIdx != nil && *schema.DebitColIdx > maxIdx {
-			maxIdx = *schema.DebitColIdx
+		if schema.NegativeStyle == "" {
+			schema.NegativeStyle = DetectNegativeStyle(amountCells)
 		}
-		if schema.CreditColIdx != nil && *schema.CreditColIdx > maxIdx {
-			maxIdx = *schema.CreditColIdx
+		log.Printf("Inferred number format: decimal=%q thousands=%q negatives=%q", schema.DecimalSeparator, schema.ThousandsSeparator, schema.NegativeStyle)
+	}
+
+	// Infer DD/MM vs MM/DD from every date in the file, not the first few rows
+	dateCells := make([]string, 0, len(records))
+	for _, record := range records {
+		dateCells = append(dateCells, cell(record, schema.DateColIdx))
+	}
+	hint := LayoutOrder(schema.DateFormat)
+	dates := InferDateOrder(dateCells, hint)
+	if dates.Ambiguous {
+		warning := fmt.Sprintf("Dates such as %q could be day-first or month-first and nothing in the file tells them apart; they were read as %s. Check the dates before importing.", dates.Example, dates.Order)
+		log.Printf("Warning: %s", warning)
+		if metadata != nil {
+			metadata.Warnings = append(metadata.Warnings, warning)
 		}
+	} else if hint != OrderYMD && dates.Order != hint && dates.Parsed > 0 {
+		log.Printf("Date order inferred from the file (%s) overrides schema format %q", dates.Order, schema.DateFormat)
+	}
+
+	var transactions []models.NormalizedTransaction
+	var diagnostics []models.RowDiagnostic
+	reject := func(row int, record []string, reason string, detail string) {
+		log.Printf("Warning: skipping row %d (%s): %s", row, reason, detail)
+		diagnostics = append(diagnostics, models.RowDiagnostic{
+			Row:    row,
+			Reason: reason,
+			Detail: detail,
+			Cells:  llm.MaskRecord(header, record),
+		})
+	}
+
+	for i, record := range records {
+		rowNum := firstRow + i
 
 		if len(record) <= maxIdx {
-			log.Printf("Warning: row %d has insufficient columns for discovered schema (skipping)", rowNum)
+			reject(rowNum, record, models.RowTooFewColumns, fmt.Sprintf("expected at least %d columns, got %d", maxIdx+1, len(record)))
 			continue
 		}
 
 		dateStr := strings.TrimSpace(record[schema.DateColIdx])
 		merchant := strings.TrimSpace(record[schema.MerchantColIdx])
 
-		// 1. Parse Date - be flexible with leading zeros
-		var date time.Time
+		// 1. Parse Date in the order inferred from the whole file, falling back
+		// to the LLM layout for notations ParseDateWithOrder does not know
 		var parseErr error
-
-		// Try the format provided by LLM first
-		date, parseErr = time.Parse(schema.DateFormat, dateStr)
-
-		// Fallback: try common formats if LLM format fails
-		if parseErr != nil {
-			formats := []string{
-				"1/2/2006",   // M/D/YYYY
-				"01/02/2006", // MM/DD/YYYY
-				"2006-01-02", // YYYY-MM-DD
-				"1/2/06",     // M/D/YY
-				"01/02/06",   // MM/DD/YY
-			}
-			for _, f := range formats {
-				if d, err := time.Parse(f, dateStr); err == nil {
-					date = d
-					parseErr = nil
-					break
-				}
-			}
+		date, ok := ParseDateWithOrder(dateStr, dates.Order)
+		if !ok {
+			date, parseErr = time.Parse(schema.DateFormat, dateStr)
 		}
 
 		if parseErr != nil {
-			log.Printf("Warning: failed to parse date '%s' at row %d: %v", dateStr, rowNum, parseErr)
+			reject(rowNum, record, models.RowInvalidDate, fmt.Sprintf("unrecognized date %q", dateStr))
 			continue
 		}
 
 		// 2. Parse Amount
-		var amount float64
+		var amount decimal.Decimal
+		var amountCell string
 		var amountParsed bool
+		var amountErr error
+		parse := func(col int) (decimal.Decimal, bool) {
+			val, err := ParseAmount(record[col], schema.DecimalSeparator, schema.ThousandsSeparator, schema.NegativeStyle)
+			if err != nil {
+				if err != ErrEmptyAmount {
+					amountErr = err
+				}
+				return decimal.Zero, false
+			}
+			amountCell = record[col]
+			return val, true
+		}
 
 		if schema.AmountColIdx != nil {
-			amountStr := strings.TrimSpace(record[*schema.AmountColIdx])
-			if amountStr != "" {
-				cleanAmount := strings.ReplaceAll(amountStr, "$", "")
-				cleanAmount = strings.ReplaceAll(cleanAmount, ",", "")
-				cleanAmount = strings.TrimSpace(cleanAmount)
-				if val, err := strconv.ParseFloat(cleanAmount, 64); err == nil {
-					amountParsed = true
-					// Default: negative means expense (common in CSVs). If 'invert_amounts' is true, positive means expense.
-					// We need expenses to be POSITIVE returning from this function.
-					if schema.InvertAmounts {
-						amount = val
-					} else {
-						amount = -val
-					}
+			if val, ok := parse(*schema.AmountColIdx); ok {
+				amountParsed = true
+				// Default: negative means expense (common in CSVs). If 'invert_amounts' is true, positive means expense.
+				// We need expenses to be POSITIVE returning from this function.
+				if schema.InvertAmounts {
+					amount = val
+				} else {
+					amount = val.Neg()
 				}
 			}
 		} else {
 			// Try Debit
 			if schema.DebitColIdx != nil {
-				debitStr := strings.TrimSpace(record[*schema.DebitColIdx])
-				if debitStr != "" {
-					cleanDebit := strings.ReplaceAll(debitStr, "$", "")
-					cleanDebit = strings.ReplaceAll(cleanDebit, ",", "")
-					if val, err := strconv.ParseFloat(strings.TrimSpace(cleanDebit), 64); err == nil {
-						amount = val // Debits are expenses. Output as positive.
-						amountParsed = true
-					}
+				if val, ok := parse(*schema.DebitColIdx); ok {
+					amount = val.Abs() // Debits are expenses. Output as positive.
+					amountParsed = true
 				}
 			}
 			// Try Credit
 			if !amountParsed && schema.CreditColIdx != nil {
-				creditStr := strings.TrimSpace(record[*schema.CreditColIdx])
-				if creditStr != "" {
-					cleanCredit := strings.ReplaceAll(creditStr, "$", "")
-					cleanCredit = strings.ReplaceAll(cleanCredit, ",", "")
-					if val, err := strconv.ParseFloat(strings.TrimSpace(cleanCredit), 64); err == nil {
-						amount = -val // Credits are income/payments. Output as negative so they get filtered out.
-						amountParsed = true
-					}
+				if val, ok := parse(*schema.CreditColIdx); ok {
+					amount = val.Abs().Neg() // Credits are income/payments. Output as negative so they get filtered out.
+					amountParsed = true
 				}
 			}
 		}
 
 		if !amountParsed {
-			continue // skip rows where amount could not be parsed
+			if amountErr != nil {
+				reject(rowNum, record, models.RowInvalidAmount, amountErr.Error())
+			} else {
+				reject(rowNum, record, models.RowMissingAmount, "no amount in any amount column")
+			}
+			continue
+		}
+
+		// An explicit DR/CR column overrides the sign convention of the amount
+		if schema.TypeColIdx != nil {
+			switch typeSign(cell(record, *schema.TypeColIdx)) {
+			case 1:
+				amount = amount.Abs()
+			case -1:
+				amount = amount.Abs().Neg()
+			}
 		}
 
-		transactions = append(transactions, models.NormalizedTransaction{
+		tx := models.NormalizedTransaction{
 			Date:        date.Format("2006-01-02"),
 			Amount:      amount,
 			Title:       merchant,
 			Description: merchant,
-			Currency:    "USD",
-		})
+			SourceRow:   rowNum,
+			RawText:     FormatRecord(llm.MaskRecord(header, record)),
+		}
+		// Left empty when neither a currency column nor the amount names one;
+		// the default currency is applied later
+		if schema.CurrencyColIdx != nil {
+			tx.Currency = processor.ResolveCurrency(cell(record, *schema.CurrencyColIdx), currency)
+		}
+		if tx.Currency == "" {
+			tx.Currency, _ = processor.DetectCurrency(amountCell, currency)
+		}
+		if description := joinCells(record, schema.DescriptionColIdx); description != "" {
+			tx.Description = description
+		}
+		if schema.CategoryColIdx != nil {
+			tx.Category = cell(record, *schema.CategoryColIdx)
+		}
+		if schema.LocationColIdx != nil {
+			tx.Location = cell(record, *schema.LocationColIdx)
+		}
+		if schema.OriginalAmountColIdx != nil {
+			originalCell := cell(record, *schema.OriginalAmountColIdx)
+			originalCurrency := ""
+			if schema.OriginalCurrencyColIdx != nil {
+				originalCurrency = processor.ResolveCurrency(cell(record, *schema.OriginalCurrencyColIdx), currency)
+			}
+			if originalCurrency == "" {
+				originalCurrency, _ = processor.DetectCurrency(originalCell, currency)
+			}
+			original, err := ParseAmount(originalCell, schema.DecimalSeparator, schema.ThousandsSeparator, schema.NegativeStyle)
+			// A purchase in the account currency has no separate original amount
+			if err == nil && originalCurrency != "" && originalCurrency != tx.Currency {
+				tx.OriginalAmount = original.Abs()
+				tx.OriginalCurrency = originalCurrency
+			}
+		}
+
+		transactions = append(transactions, tx)
+	}
+
+	for _, idx := range shortRows {
+		reject(firstRow+idx, footer[idx], models.RowFooter, "trailing row with too few cells or no date")
+	}
+
+	if metadata != nil {
+		metadata.TotalRows = len(records) + len(shortRows)
+		metadata.RejectedRows = len(diagnostics)
+		metadata.RowDiagnostics = diagnostics
+		metadata.Warnings = append(metadata.Warnings, rejectionWarnings(diagnostics)...)
 	}
 
 	return transactions, nil
 }
+
+// cell returns the trimmed value at idx, or "" when the record is too short.
+func cell(record []string, idx int) string {
+	if idx < 0 || idx >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
+
+// joinCells merges the distinct non-empty cells at cols with " - ", so a
+// description split across "Description" and "Memo" columns reads as one.
+func joinCells(record []string, cols models.ColumnList) string {
+	var parts []string
+	for _, col := range cols {
+		value := cell(record, col)
+		if value == "" || slices.Contains(parts, value) {
+			continue
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, " - ")
+}
+
+// typeSign maps a transaction type cell to the sign of an expense (1) or an
+// income/payment (-1). Returns 0 for unrecognized values.
+func typeSign(value string) int {
+	switch strings.ToLower(strings.TrimRight(strings.TrimSpace(value), ".")) {
+	case "dr", "d", "debit", "débito", "debito", "withdrawal", "purchase", "sale", "charge", "fee":
+		return 1
+	case "cr", "c", "credit", "crédito", "credito", "deposit", "refund", "return", "payment", "reversal":
+		return -1
+	}
+	return 0
+}
+
+// rejectionWarnings summarizes diagnostics as one warning per reason.
+func rejectionWarnings(diagnostics []models.RowDiagnostic) []string {
+	summaries := []struct {
+		reason string
+		text   string
+	}{
+		{models.RowTooFewColumns, "too few columns"},
+		{models.RowInvalidDate, "an unparseable date"},
+		{models.RowInvalidAmount, "an unparseable amount"},
+		{models.RowMissingAmount, "no amount"},
+		{models.RowFooter, "too few cells or no date after the last transaction"},
+	}
+	var warnings []string
+	for _, summary := range summaries {
+		var rows []int
+		for _, d := range diagnostics {
+			if d.Reason == summary.reason {
+				rows = append(rows, d.Row)
+			}
+		}
+		if len(rows) > 0 {
+			warnings = append(warnings, fmt.Sprintf("Skipped %d row(s) with %s: %s", len(rows), summary.text, formatRows(rows)))
+		}
+	}
+	return warnings
+}
+
+// maxListedRows caps how many row numbers a single warning lists.
+const maxListedRows = 10
+
+// formatRows lists row numbers for a warning, e.g. "rows 4, 9 and 3 more".
+func formatRows(rows []int) string {
+	var parts []string
+	for i, r := range rows {
+		if i == maxListedRows {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d", r))
+	}
+	label := "row "
+	if len(rows) > 1 {
+		label = "rows "
+	}
+	list := label + strings.Join(parts, ", ")
+	if extra := len(rows) - maxListedRows; extra > 0 {
+		list += fmt.Sprintf(" and %d more", extra)
+	}
+	return list
+}
@@ -0,0 +1,247 @@
+package adapters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"retrospend-sidecar/importer/models"
+	"retrospend-sidecar/importer/processor"
+)
+
+func intPtr(v int) *int { return &v }
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestParseDynamicCSV_RecordsSourceRowAndRawText(t *testing.T) {
+	input := `Date,Description,Amount
+01/02/2025,"COFFEE SHOP, DOWNTOWN",-4.50
+not a date,SKIPPED,-1.00
+01/03/2025,BAKERY,-3.00
+`
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006"}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	if txs[0].SourceRow != 2 || txs[1].SourceRow != 4 {
+		t.Errorf("expected source rows 2 and 4, got %d and %d", txs[0].SourceRow, txs[1].SourceRow)
+	}
+	if txs[0].RawText != `01/02/2025,"COFFEE SHOP, DOWNTOWN",-4.50` {
+		t.Errorf("raw text should match the original row, got %q", txs[0].RawText)
+	}
+}
+
+func TestParseDynamicCSV_MasksRawText(t *testing.T) {
+	input := `Date,Description,Account Number,Amount
+01/02/2025,TRANSFER 4111111111111111,12345678,-4.50
+`
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(3), DateFormat: "01/02/2006"}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(txs))
+	}
+	if want := `01/02/2025,TRANSFER ****1111,***,-4.50`; txs[0].RawText != want {
+		t.Errorf("expected masked raw text %q, got %q", want, txs[0].RawText)
+	}
+}
+
+func TestParseDynamicCSV_LocaleAmountsAndUnparsedRows(t *testing.T) {
+	input := `Data;Descricao;Valor
+02/01/2025;PADARIA;"-1.030,00"
+03/01/2025;MERCADO;R$ -30,50
+04/01/2025;ESTORNO;(12,00)
+05/01/2025;ILEGIVEL;trinta reais
+`
+	schema := models.CSVSchema{
+		DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "02/01/2006",
+		Dialect: models.CSVDialect{Delimiter: ";"},
+	}
+	meta := &models.ImportMetadata{}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"1030", "30.50", "12"}
+	if len(txs) != len(want) {
+		t.Fatalf("expected %d transactions, got %d: %+v", len(want), len(txs), txs)
+	}
+	for i, w := range want {
+		if !txs[i].Amount.Equal(dec(w)) {
+			t.Errorf("tx %d: amount = %v; expected %v", i, txs[i].Amount, w)
+		}
+	}
+	if len(meta.Warnings) != 1 || !strings.Contains(meta.Warnings[0], "row 5") {
+		t.Errorf("expected a warning naming row 5, got %v", meta.Warnings)
+	}
+}
+
+func TestParseDynamicCSV_OptionalColumns(t *testing.T) {
+	input := `Date,Payee,Memo,Category,City,Type,Amount,Currency,Orig Amount,Orig Currency
+01/02/2025,CAFE CENTRAL,Card 1234,Dining,Lisboa,DR,4.50,eur,4.50,EUR
+01/03/2025,AMAZON,AMAZON,Shopping,,CR,25.00,EUR,,
+01/04/2025,LOJA,,Shopping,Sao Paulo,debit,10.00,EUR,59.90,BRL
+`
+	schema := models.CSVSchema{
+		DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(6), DateFormat: "01/02/2006",
+		DescriptionColIdx:      models.ColumnList{1, 2},
+		CategoryColIdx:         intPtr(3),
+		LocationColIdx:         intPtr(4),
+		TypeColIdx:             intPtr(5),
+		CurrencyColIdx:         intPtr(7),
+		OriginalAmountColIdx:   intPtr(8),
+		OriginalCurrencyColIdx: intPtr(9),
+		DecimalSeparator:       ".",
+	}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(txs))
+	}
+
+	cafe := txs[0]
+	if !cafe.Amount.Equal(dec("4.50")) || cafe.Currency != "EUR" {
+		t.Errorf("DR row should be a EUR expense of 4.50, got %s %s", cafe.Amount, cafe.Currency)
+	}
+	if cafe.Description != "CAFE CENTRAL - Card 1234" || cafe.Category != "Dining" || cafe.Location != "Lisboa" {
+		t.Errorf("unexpected optional fields: %+v", cafe)
+	}
+	if cafe.OriginalCurrency != "" || !cafe.OriginalAmount.Equal(dec("0")) {
+		t.Errorf("an original amount in the account currency should be ignored, got %s %s", cafe.OriginalAmount, cafe.OriginalCurrency)
+	}
+
+	if !txs[1].Amount.Equal(dec("-25.00")) {
+		t.Errorf("CR row should be negative, got %s", txs[1].Amount)
+	}
+	if txs[1].Description != "AMAZON" {
+		t.Errorf("repeated description cells should be merged once, got %q", txs[1].Description)
+	}
+
+	if !txs[2].Amount.Equal(dec("10.00")) || !txs[2].OriginalAmount.Equal(dec("59.90")) || txs[2].OriginalCurrency != "BRL" {
+		t.Errorf("expected 10.00 EUR from 59.90 BRL, got %s from %s %s", txs[2].Amount, txs[2].OriginalAmount, txs[2].OriginalCurrency)
+	}
+}
+
+func TestParseDynamicCSV_NoCurrencyColumnLeavesCurrencyEmpty(t *testing.T) {
+	input := "Date,Description,Amount\n01/02/2025,COFFEE,-4.50\n"
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006"}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Currency != "" {
+		t.Errorf("expected one transaction without a currency, got %+v", txs)
+	}
+}
+
+func TestParseDynamicCSV_CurrencyFromAmountSymbols(t *testing.T) {
+	input := "Date,Description,Amount,Foreign\n01/02/2025,TAXI,-$4.50,\n01/03/2025,HOTEL,-$120.00,€110.00\n01/04/2025,BOOKS,-12.00 GBP,\n"
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), OriginalAmountColIdx: intPtr(3), DateFormat: "01/02/2006"}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{Account: "CAD"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(txs))
+	}
+	if txs[0].Currency != "CAD" {
+		t.Errorf("expected $ on a CAD account to be CAD, got %q", txs[0].Currency)
+	}
+	if txs[1].OriginalCurrency != "EUR" || !txs[1].OriginalAmount.Equal(dec("110")) {
+		t.Errorf("expected an original amount of 110 EUR, got %s %q", txs[1].OriginalAmount, txs[1].OriginalCurrency)
+	}
+	if txs[2].Currency != "GBP" || !txs[2].Amount.Equal(dec("12")) {
+		t.Errorf("expected 12 GBP, got %s %q", txs[2].Amount, txs[2].Currency)
+	}
+}
+
+func TestParseDynamicCSV_InfersDayFirstDates(t *testing.T) {
+	// The LLM guessed a US layout, but row 3 can only be day-first
+	input := "Date,Description,Amount\n03/04/2025,PADARIA,-4.50\n13/04/2025,MERCADO,-30.00\n"
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006"}
+	meta := &models.ImportMetadata{}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 2 || txs[0].Date != "2025-04-03" || txs[1].Date != "2025-04-13" {
+		t.Errorf("expected 3 and 13 April, got %+v", txs)
+	}
+	if len(meta.Warnings) != 0 {
+		t.Errorf("unambiguous dates should not warn, got %v", meta.Warnings)
+	}
+}
+
+func TestParseDynamicCSV_WarnsOnAmbiguousDates(t *testing.T) {
+	input := "Date,Description,Amount\n03/04/2025,PADARIA,-4.50\n"
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006"}
+	meta := &models.ImportMetadata{}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Date != "2025-03-04" {
+		t.Errorf("ambiguous dates should follow the schema layout, got %+v", txs)
+	}
+	if len(meta.Warnings) != 1 || !strings.Contains(meta.Warnings[0], "03/04/2025") {
+		t.Errorf("expected a warning naming the ambiguous date, got %v", meta.Warnings)
+	}
+}
+
+func TestParseDynamicCSV_RowDiagnostics(t *testing.T) {
+	input := `Date,Description,Amount,Card No.
+01/02/2025,COFFEE,-4.50,4111111111111111
+01/03/2025,SHORT ROW
+someday,BAKERY,-3.00,4111111111111111
+01/04/2025,ACCOUNT 123456789012,abc,4111111111111111
+01/05/2025,PENDING,,4111111111111111
+`
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006"}
+	meta := &models.ImportMetadata{}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(txs))
+	}
+	if meta.TotalRows != 5 || meta.RejectedRows != 4 {
+		t.Errorf("expected 4 of 5 rows rejected, got %d of %d", meta.RejectedRows, meta.TotalRows)
+	}
+
+	wantReasons := []string{models.RowTooFewColumns, models.RowInvalidDate, models.RowInvalidAmount, models.RowMissingAmount}
+	if len(meta.RowDiagnostics) != len(wantReasons) {
+		t.Fatalf("expected %d diagnostics, got %+v", len(wantReasons), meta.RowDiagnostics)
+	}
+	for i, want := range wantReasons {
+		if d := meta.RowDiagnostics[i]; d.Reason != want || d.Row != i+3 {
+			t.Errorf("diagnostic %d: got row %d %q; expected row %d %q", i, d.Row, d.Reason, i+3, want)
+		}
+	}
+
+	invalid := meta.RowDiagnostics[2]
+	if invalid.Cells[3] != "***" || invalid.Cells[1] != "ACCOUNT ****9012" {
+		t.Errorf("sensitive cells should be masked, got %q", invalid.Cells)
+	}
+	if len(meta.Warnings) != 4 {
+		t.Errorf("expected one warning per reason, got %v", meta.Warnings)
+	}
+}
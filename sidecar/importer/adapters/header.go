@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// HeaderScanRows is how many leading records DetectHeaderRow considers.
+const HeaderScanRows = 20
+
+// headerLookahead is how many records after a candidate header must match its width.
+const headerLookahead = 5
+
+var (
+	dateLike = regexp.MustCompile(`\d{1,4}[/.\-]\d{1,2}([/.\-]\d{1,4})?`)
+
+	// footerLabel matches a whole cell labelling a summary row, but not
+	// merchants that merely start with such a word ("TOTAL WINE & MORE")
+	footerLabel = regexp.MustCompile(`(?i)^\s*((sub)?totals?(\s+(debits?|credits?|charges|payments|amount|spent|geral))?|(opening|closing|ending|final|available)\s+balance|saldo(\s+(final|anterior|atual))?|balance|summary|resumen)\s*:?\s*$`)
+)
+
+// DetectHeaderRow returns the index of the most table-like header among the
+// first HeaderScanRows records. A header is a row of labels (text, not amounts
+// or dates) that the records after it match in width, so account preambles
+// ("Account: ****1234", "Date range: ...") are skipped. Returns 0 when no row
+// qualifies.
+func DetectHeaderRow(records [][]string) int {
+	best, bestScore := 0, 0.0
+	for i := 0; i < len(records) && i < HeaderScanRows; i++ {
+		row := records[i]
+		filled, labels := 0, 0
+		for _, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			filled++
+			if isLabel(cell) {
+				labels++
+			}
+		}
+		if filled < 2 || labels*2 <= filled {
+			continue // Too sparse, or mostly values: a data row, not a header
+		}
+
+		following := records[i+1 : min(len(records), i+1+headerLookahead)]
+		if len(following) == 0 {
+			continue
+		}
+		matching := 0
+		for _, r := range following {
+			if len(r) == len(row) && countFilled(r) >= 2 {
+				matching++
+			}
+		}
+
+		score := float64(filled) * float64(matching) / float64(len(following))
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// isLabel reports whether a cell reads as a column name rather than a value.
+func isLabel(cell string) bool {
+	if dateLike.MatchString(cell) {
+		return false
+	}
+	if _, err := ParseAmount(cell, ".", ",", ""); err == nil {
+		return false
+	}
+	letters, digits := 0, 0
+	for _, r := range cell {
+		if unicode.IsLetter(r) {
+			letters++
+		} else if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	// "Jan 02, 2025" is a value; "Check or Slip #" is a label
+	return letters > digits
+}
+
+func countFilled(record []string) int {
+	n := 0
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// isFooterLabel reports whether a record is a totals or balance row.
+func isFooterLabel(record []string) bool {
+	for _, cell := range record {
+		if footerLabel.MatchString(cell) {
+			return true
+		}
+	}
+	return false
+}
+
+// isShortRow reports whether a record cannot be a transaction: too few cells
+// for the table, or nothing resembling a date in the date column.
+func isShortRow(record []string, width int, dateCol int) bool {
+	if countFilled(record) < 2 || len(record) < width {
+		return true
+	}
+	return !strings.ContainsAny(record[dateCol], "0123456789")
+}
+
+// trimFooter drops the last skip records, then any trailing footer rows.
+// Totals and balance rows go silently; the indices of the other trailing rows
+// (disclaimers, but also a truncated last transaction) are returned in file
+// order so the caller can report them.
+func trimFooter(records [][]string, skip int, width int, dateCol int) ([][]string, []int) {
+	if skip > 0 {
+		records = records[:max(0, len(records)-skip)]
+	}
+	var short []int
+	for len(records) > 0 {
+		last := len(records) - 1
+		if !isFooterLabel(records[last]) {
+			if !isShortRow(records[last], width, dateCol) {
+				break
+			}
+			short = append([]int{last}, short...)
+		}
+		records = records[:last]
+	}
+	return records, short
+}
@@ -0,0 +1,125 @@
+package adapters
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"retrospend-sidecar/importer/models"
+	"retrospend-sidecar/importer/processor"
+)
+
+const preambleCSV = `Account:,****1234
+Date range:,01/01/2025 - 01/31/2025
+Currency:,USD
+
+Date,Description,Amount,Balance
+01/02/2025,COFFEE SHOP,-4.50,995.50
+01/03/2025,TOTAL WINE & MORE,-30.00,965.50
+01/04/2025,BAKERY,-3.00,962.50
+,,Total,-37.50
+Closing balance,,,962.50
+`
+
+func readRecords(t *testing.T, input string) [][]string {
+	t.Helper()
+	r := NewCSVReader(strings.NewReader(input), models.CSVDialect{})
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestDetectHeaderRow_SkipsPreamble(t *testing.T) {
+	records := readRecords(t, preambleCSV)
+	if idx := DetectHeaderRow(records); idx != 3 || records[idx][0] != "Date" {
+		t.Errorf("expected the header at record 3, got %d (%q)", idx, records[idx])
+	}
+}
+
+func TestDetectHeaderRow_PlainFile(t *testing.T) {
+	records := readRecords(t, "Date,Description,Amount\n01/02/2025,COFFEE,-4.50\n01/03/2025,BAKERY,-3.00\n")
+	if idx := DetectHeaderRow(records); idx != 0 {
+		t.Errorf("expected the header at record 0, got %d", idx)
+	}
+}
+
+func TestDetectHeaderRow_NamedMonthDates(t *testing.T) {
+	records := readRecords(t, "Posted,Payee,Amount\nJan 02 2025,COFFEE,-4.50\nJan 03 2025,BAKERY,-3.00\n")
+	if idx := DetectHeaderRow(records); idx != 0 {
+		t.Errorf("data rows with named months must not be taken as the header, got %d", idx)
+	}
+}
+
+func TestParseDynamicCSV_PreambleAndFooter(t *testing.T) {
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006", HeaderRowIdx: 3}
+	meta := &models.ImportMetadata{}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(preambleCSV), schema, processor.CurrencyContext{}, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 3 {
+		t.Fatalf("expected 3 transactions, got %d: %+v", len(txs), txs)
+	}
+	if txs[1].Title != "TOTAL WINE & MORE" {
+		t.Errorf("merchants starting with 'Total' must not be treated as footers, got %q", txs[1].Title)
+	}
+	if txs[0].SourceRow != 5 {
+		t.Errorf("expected the first transaction at record 5, got %d", txs[0].SourceRow)
+	}
+	if len(meta.Warnings) != 0 {
+		t.Errorf("footers should be ignored silently, got %v", meta.Warnings)
+	}
+}
+
+func TestParseDynamicCSV_SkipFooterRows(t *testing.T) {
+	input := "Date,Description,Amount\n01/02/2025,COFFEE,-4.50\n01/03/2025,BAKERY,-3.00\n01/31/2025,Generated by Online Banking,0.00\n"
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006", SkipFooterRows: 1}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Errorf("expected the configured footer row to be skipped, got %d transactions", len(txs))
+	}
+}
+
+func TestParseDynamicCSV_ReportsShortTrailingRows(t *testing.T) {
+	input := "Date,Description,Amount\n01/22/2025,COFFEE,-4.50\n01/23/2025,BAKERY\nPending transactions are not included,,\nTotal,,-7.50\n"
+	schema := models.CSVSchema{DateColIdx: 0, MerchantColIdx: 1, AmountColIdx: intPtr(2), DateFormat: "01/02/2006"}
+	meta := &models.ImportMetadata{}
+
+	txs, err := ParseDynamicCSV(strings.NewReader(input), schema, processor.CurrencyContext{}, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(txs))
+	}
+	// The truncated row and the disclaimer are reported; the totals row is not
+	if meta.TotalRows != 3 || meta.RejectedRows != 2 {
+		t.Errorf("expected 2 of 3 rows rejected, got %d of %d", meta.RejectedRows, meta.TotalRows)
+	}
+	for i, d := range meta.RowDiagnostics {
+		if d.Reason != models.RowFooter || d.Row != i+3 {
+			t.Errorf("diagnostic %d: got row %d %q; expected row %d %q", i, d.Row, d.Reason, i+3, models.RowFooter)
+		}
+	}
+	if len(meta.Warnings) != 1 {
+		t.Errorf("expected one warning for the short rows, got %v", meta.Warnings)
+	}
+
+	policy := RejectionPolicy{Strict: true, MaxRejectedPercent: 50}
+	if err := policy.Check(meta); err == nil {
+		t.Error("expected the short rows to count toward the strict rejection limit")
+	}
+}
@@ -0,0 +1,294 @@
+package adapters
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// Built-in bank profiles. More can be added without a rebuild by mounting
+// JSON files in the directory named by BANK_PROFILES_DIR.
+//
+//go:embed profiles/*.json
+var embeddedProfiles embed.FS
+
+// Sign conventions for BankProfile.SignConvention.
+const (
+	ExpensesNegative = "expenses_negative" // -45.00 is a purchase (the default)
+	ExpensesPositive = "expenses_positive" // 45.00 is a purchase
+)
+
+// BankProfile describes a known bank's CSV export: the headers that identify
+// it and how its columns map onto a CSVSchema.
+type BankProfile struct {
+	Name      string           `json:"name"`
+	Signature ProfileSignature `json:"signature"`
+	Columns   ProfileColumns   `json:"columns"`
+
+	DateFormat         string            `json:"date_format"`
+	SignConvention     string            `json:"sign_convention,omitempty"`
+	DecimalSeparator   string            `json:"decimal_separator,omitempty"`
+	ThousandsSeparator string            `json:"thousands_separator,omitempty"`
+	Dialect            models.CSVDialect `json:"dialect"`
+}
+
+// ProfileSignature lists the header names that identify a bank's export.
+// Names are matched case-insensitively against whole header cells.
+type ProfileSignature struct {
+	Required []string `json:"required"`           // All must be present
+	Any      []string `json:"any,omitempty"`      // At least one must be present
+	Optional []string `json:"optional,omitempty"` // Raise the score when present
+}
+
+// ProfileColumns maps each schema field to candidate header names, in order
+// of preference. Description uses every candidate present, merged in order.
+type ProfileColumns struct {
+	Date             []string `json:"date"`
+	Merchant         []string `json:"merchant"`
+	Amount           []string `json:"amount,omitempty"`
+	Debit            []string `json:"debit,omitempty"`
+	Credit           []string `json:"credit,omitempty"`
+	Currency         []string `json:"currency,omitempty"`
+	Description      []string `json:"description,omitempty"`
+	Category         []string `json:"category,omitempty"`
+	Location         []string `json:"location,omitempty"`
+	Type             []string `json:"type,omitempty"`
+	OriginalAmount   []string `json:"original_amount,omitempty"`
+	OriginalCurrency []string `json:"original_currency,omitempty"`
+}
+
+var (
+	profiles     []BankProfile
+	profilesOnce sync.Once
+)
+
+func getProfilesDir() string {
+	return os.Getenv("BANK_PROFILES_DIR")
+}
+
+func initProfiles() {
+	embedded, err := loadProfiles(embeddedProfiles, "profiles")
+	if err != nil {
+		log.Printf("Warning: failed to load built-in bank profiles: %v", err)
+	}
+	profiles = embedded
+
+	dir := getProfilesDir()
+	if dir == "" {
+		return
+	}
+	custom, err := loadProfiles(os.DirFS(dir), ".")
+	if err != nil {
+		log.Printf("Warning: failed to load bank profiles from %s: %v", dir, err)
+	}
+	// A mounted profile replaces a built-in one with the same name
+	for _, p := range custom {
+		replaced := false
+		for i := range profiles {
+			if strings.EqualFold(profiles[i].Name, p.Name) {
+				profiles[i], replaced = p, true
+			}
+		}
+		if !replaced {
+			profiles = append(profiles, p)
+		}
+	}
+	log.Printf("Loaded %d bank profile(s) from %s", len(custom), dir)
+}
+
+// loadProfiles reads every *.json file in dir. Invalid files are logged and
+// skipped so one bad profile does not disable the rest.
+func loadProfiles(fsys fs.FS, dir string) ([]BankProfile, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []BankProfile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, filepath.ToSlash(filepath.Join(dir, entry.Name())))
+		if err != nil {
+			log.Printf("Warning: failed to read bank profile %s: %v", entry.Name(), err)
+			continue
+		}
+		var p BankProfile
+		if err := json.Unmarshal(data, &p); err != nil {
+			log.Printf("Warning: failed to parse bank profile %s: %v", entry.Name(), err)
+			continue
+		}
+		if err := p.validate(); err != nil {
+			log.Printf("Warning: invalid bank profile %s: %v", entry.Name(), err)
+			continue
+		}
+		loaded = append(loaded, p)
+	}
+	return loaded, nil
+}
+
+func (p BankProfile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if len(p.Signature.Required) == 0 {
+		return fmt.Errorf("signature needs at least one required header")
+	}
+	if len(p.Columns.Date) == 0 || len(p.Columns.Merchant) == 0 {
+		return fmt.Errorf("date and merchant columns are required")
+	}
+	if len(p.Columns.Amount) == 0 && len(p.Columns.Debit) == 0 && len(p.Columns.Credit) == 0 {
+		return fmt.Errorf("no amount, debit or credit column")
+	}
+	switch p.SignConvention {
+	case "", ExpensesNegative, ExpensesPositive:
+	default:
+		return fmt.Errorf("unknown sign_convention %q", p.SignConvention)
+	}
+	return nil
+}
+
+// normalizeHeader makes header cells comparable across exports.
+func normalizeHeader(h string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(h), "\ufeff\"'"))
+}
+
+// score rates how well headers match the profile's signature: -1 when a
+// required header is missing, otherwise the number of signature headers
+// present, so a more specific profile beats a looser one.
+func (p BankProfile) score(headers map[string]int) int {
+	score := 0
+	for _, h := range p.Signature.Required {
+		if _, ok := headers[normalizeHeader(h)]; !ok {
+			return -1
+		}
+		score++
+	}
+	if len(p.Signature.Any) > 0 {
+		found := 0
+		for _, h := range p.Signature.Any {
+			if _, ok := headers[normalizeHeader(h)]; ok {
+				found++
+			}
+		}
+		if found == 0 {
+			return -1
+		}
+		score += found
+	}
+	for _, h := range p.Signature.Optional {
+		if _, ok := headers[normalizeHeader(h)]; ok {
+			score++
+		}
+	}
+	return score
+}
+
+// schema maps the profile's columns onto a header row. It fails when the
+// date, merchant or amount columns are not all present.
+func (p BankProfile) schema(headers map[string]int) (models.CSVSchema, error) {
+	find := func(names []string) *int {
+		for _, name := range names {
+			if idx, ok := headers[normalizeHeader(name)]; ok {
+				return &idx
+			}
+		}
+		return nil
+	}
+
+	schema := models.CSVSchema{
+		DateColIdx:             -1,
+		MerchantColIdx:         -1,
+		DateFormat:             p.DateFormat,
+		InvertAmounts:          p.SignConvention == ExpensesPositive,
+		AmountColIdx:           find(p.Columns.Amount),
+		DebitColIdx:            find(p.Columns.Debit),
+		CreditColIdx:           find(p.Columns.Credit),
+		CurrencyColIdx:         find(p.Columns.Currency),
+		CategoryColIdx:         find(p.Columns.Category),
+		LocationColIdx:         find(p.Columns.Location),
+		TypeColIdx:             find(p.Columns.Type),
+		OriginalAmountColIdx:   find(p.Columns.OriginalAmount),
+		OriginalCurrencyColIdx: find(p.Columns.OriginalCurrency),
+		DecimalSeparator:       p.DecimalSeparator,
+		ThousandsSeparator:     p.ThousandsSeparator,
+		Dialect:                p.Dialect,
+	}
+	if idx := find(p.Columns.Date); idx != nil {
+		schema.DateColIdx = *idx
+	}
+	if idx := find(p.Columns.Merchant); idx != nil {
+		schema.MerchantColIdx = *idx
+	}
+	for _, name := range p.Columns.Description {
+		if idx, ok := headers[normalizeHeader(name)]; ok && !slices.Contains(schema.DescriptionColIdx, idx) {
+			schema.DescriptionColIdx = append(schema.DescriptionColIdx, idx)
+		}
+	}
+	// A lone merchant column adds nothing to the default description
+	if len(schema.DescriptionColIdx) == 1 && schema.DescriptionColIdx[0] == schema.MerchantColIdx {
+		schema.DescriptionColIdx = nil
+	}
+
+	if schema.DateColIdx == -1 || schema.MerchantColIdx == -1 {
+		return schema, fmt.Errorf("profile %q: date or merchant column not found", p.Name)
+	}
+	if schema.AmountColIdx == nil && schema.DebitColIdx == nil && schema.CreditColIdx == nil {
+		return schema, fmt.Errorf("profile %q: no amount column found", p.Name)
+	}
+	return schema, nil
+}
+
+// MatchProfile returns the best-scoring bank profile for a header row and the
+// schema it maps to. ok is false when no profile matches.
+func MatchProfile(headerRow []string) (profile BankProfile, schema models.CSVSchema, ok bool) {
+	profilesOnce.Do(initProfiles)
+	return matchProfile(profiles, headerRow)
+}
+
+func matchProfile(candidates []BankProfile, headerRow []string) (BankProfile, models.CSVSchema, bool) {
+	headers := make(map[string]int, len(headerRow))
+	for i, h := range headerRow {
+		if _, dup := headers[normalizeHeader(h)]; !dup {
+			headers[normalizeHeader(h)] = i
+		}
+	}
+
+	type match struct {
+		profile BankProfile
+		score   int
+	}
+	var matches []match
+	for _, p := range candidates {
+		if s := p.score(headers); s >= 0 {
+			matches = append(matches, match{p, s})
+		}
+	}
+	// Highest score first; ties go to the profile with more required headers
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(matches[i].profile.Signature.Required) > len(matches[j].profile.Signature.Required)
+	})
+
+	for _, m := range matches {
+		schema, err := m.profile.schema(headers)
+		if err != nil {
+			log.Printf("Skipping bank profile: %v", err)
+			continue
+		}
+		return m.profile, schema, true
+	}
+	return BankProfile{}, models.CSVSchema{}, false
+}
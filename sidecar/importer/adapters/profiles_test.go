@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchProfile_BuiltInBanks(t *testing.T) {
+	tests := []struct {
+		headers []string
+		want    string
+	}{
+		{[]string{"Details", "Posting Date", "Description", "Amount", "Type", "Balance", "Check or Slip #"}, "Chase (checking)"},
+		{[]string{"Transaction Date", "Post Date", "Description", "Category", "Type", "Amount", "Memo"}, "Chase (credit card)"},
+		{[]string{"Transaction Date", "Name", "Memo", "Amount"}, "Fidelity"},
+		{[]string{"Posted Date", "Reference Number", "Payee", "Address", "Amount"}, "Bank of America"},
+		{[]string{"Transaction Date", "Posted Date", "Card No.", "Description", "Category", "Debit", "Credit"}, "Capital One"},
+		{[]string{"Account", "Post Date", "Check", "Description", "Debit", "Credit", "Status", "Balance"}, "Lighthouse / BNH"},
+	}
+	for _, tt := range tests {
+		profile, _, ok := MatchProfile(tt.headers)
+		if !ok || profile.Name != tt.want {
+			t.Errorf("MatchProfile(%v) = %q (ok=%v); expected %q", tt.headers, profile.Name, ok, tt.want)
+		}
+	}
+
+	if _, _, ok := MatchProfile([]string{"foo", "bar", "baz"}); ok {
+		t.Errorf("unknown headers should not match a profile")
+	}
+}
+
+func TestMatchProfile_MoreSpecificProfileWins(t *testing.T) {
+	// A Chase credit export with a Credit column also satisfies the loose
+	// Lighthouse signature; the Chase profile matches more headers
+	headers := []string{"Transaction Date", "Post Date", "Description", "Category", "Type", "Amount", "Memo", "Credit"}
+	profile, schema, ok := MatchProfile(headers)
+	if !ok || profile.Name != "Chase (credit card)" {
+		t.Fatalf("expected the Chase profile, got %q", profile.Name)
+	}
+	if schema.DateColIdx != 1 || schema.MerchantColIdx != 2 || schema.AmountColIdx == nil || *schema.AmountColIdx != 5 {
+		t.Errorf("unexpected column mapping: %+v", schema)
+	}
+	if schema.TypeColIdx == nil || *schema.TypeColIdx != 4 || len(schema.DescriptionColIdx) != 2 {
+		t.Errorf("expected Type and a Description+Memo description, got %+v", schema)
+	}
+}
+
+func TestLoadProfiles_MountedDirectory(t *testing.T) {
+	fsys := fstest.MapFS{
+		"nubank.json": {Data: []byte(`{
+			"name": "Nubank",
+			"signature": {"required": ["Data", "Descrição", "Valor"]},
+			"columns": {"date": ["Data"], "merchant": ["Descrição"], "amount": ["Valor"]},
+			"date_format": "02/01/2006",
+			"sign_convention": "expenses_positive",
+			"decimal_separator": ",",
+			"dialect": {"delimiter": ";"}
+		}`)},
+		"broken.json": {Data: []byte(`{"name": "Broken"`)},
+		"no_amount.json": {Data: []byte(`{"name": "NoAmount", "signature": {"required": ["Date"]},
+			"columns": {"date": ["Date"], "merchant": ["Payee"]}}`)},
+		"README.md": {Data: []byte("not a profile")},
+	}
+
+	loaded, err := loadProfiles(fsys, ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Name != "Nubank" {
+		t.Fatalf("expected only the valid profile to load, got %+v", loaded)
+	}
+
+	profile, schema, ok := matchProfile(loaded, []string{"Data", "DESCRIÇÃO", "Valor"})
+	if !ok || profile.Name != "Nubank" {
+		t.Fatalf("expected a case-insensitive match on Nubank headers")
+	}
+	if !schema.InvertAmounts || schema.DecimalSeparator != "," || schema.Dialect.Delimiter != ";" {
+		t.Errorf("profile settings should carry into the schema, got %+v", schema)
+	}
+}
@@ -0,0 +1,28 @@
+package adapters
+
+import (
+	"fmt"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// RejectionPolicy decides whether a CSV import with rejected rows may go on.
+type RejectionPolicy struct {
+	Strict             bool // Fail the import when too many rows are rejected
+	MaxRejectedPercent int  // Share of rows that may be rejected in strict mode
+}
+
+// Check fails loudly when strict mode is on and more than MaxRejectedPercent
+// of the rows in metadata were rejected, as EnrichTransactions does for
+// failed batches. Outside strict mode rejected rows are only reported.
+func (p RejectionPolicy) Check(metadata *models.ImportMetadata) error {
+	if !p.Strict || metadata == nil || metadata.TotalRows == 0 {
+		return nil
+	}
+	rejectedRate := float64(metadata.RejectedRows) / float64(metadata.TotalRows)
+	if rejectedRate*100 > float64(p.MaxRejectedPercent) {
+		return fmt.Errorf("CRITICAL: %.0f%% of CSV rows were rejected (%d/%d rejected, limit %d%%). The file layout does not match the detected schema; see the row diagnostics",
+			rejectedRate*100, metadata.RejectedRows, metadata.TotalRows, p.MaxRejectedPercent)
+	}
+	return nil
+}
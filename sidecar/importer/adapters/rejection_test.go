@@ -0,0 +1,26 @@
+package adapters
+
+import (
+	"strings"
+	"testing"
+
+	"retrospend-sidecar/importer/models"
+)
+
+func TestRejectionPolicy_Check(t *testing.T) {
+	meta := &models.ImportMetadata{TotalRows: 10, RejectedRows: 3}
+
+	if err := (RejectionPolicy{MaxRejectedPercent: 20}).Check(meta); err != nil {
+		t.Errorf("rejected rows must only be reported outside strict mode, got %v", err)
+	}
+
+	err := RejectionPolicy{Strict: true, MaxRejectedPercent: 20}.Check(meta)
+	if err == nil || !strings.Contains(err.Error(), "3/10") {
+		t.Errorf("expected strict mode to fail with 30%% rejected, got %v", err)
+	}
+
+	meta.RejectedRows = 2
+	if err := (RejectionPolicy{Strict: true, MaxRejectedPercent: 20}).Check(meta); err != nil {
+		t.Errorf("exactly 20%% rejected should pass, got %v", err)
+	}
+}
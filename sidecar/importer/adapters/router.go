@@ -8,117 +8,43 @@ import (
 	"strings"
 )
 
-func DetectAdapter(provider llm.Provider, model string, headerRow []string, sampleRows []string) (BankAdapter, int, error) {
-	headerStr := strings.Join(headerRow, ",")
-
-	// Check if common header-based banks (e.g. Lighthouse, BNH)
-	if strings.Contains(headerStr, "Post Date") && strings.Contains(headerStr, "Description") &&
-		(strings.Contains(headerStr, "Debit") || strings.Contains(headerStr, "Credit")) {
-		schema := models.CSVSchema{DateFormat: "1/2/2006", InvertAmounts: false, DateColIdx: -1, MerchantColIdx: -1}
-		for i, h := range headerRow {
-			hStr := strings.TrimSpace(h)
-			if hStr == "Post Date" || hStr == "Posting Date" || hStr == "Date" {
-				schema.DateColIdx = i
-			} else if hStr == "Description" || hStr == "Payee" || hStr == "Merchant" {
-				schema.MerchantColIdx = i
-			} else if hStr == "Debit" {
-				idx := i
-				schema.DebitColIdx = &idx
-			} else if hStr == "Credit" {
-				idx := i
-				schema.CreditColIdx = &idx
-			} else if hStr == "Amount" {
-				idx := i
-				schema.AmountColIdx = &idx
-			}
-		}
-		// If both date and merchant were found, we can use this schema
-		if schema.DateColIdx != -1 && schema.MerchantColIdx != -1 && (schema.AmountColIdx != nil || schema.DebitColIdx != nil) {
-			return NewDynamicAdapter(schema), 0, nil
-		}
-	}
+// CSVSample is what DetectAdapter sees of a file: the header and first rows
+// of its table, plus the layout detected while reading them.
+type CSVSample struct {
+	Header       []string
+	Rows         []string // CSV-encoded data rows following the header
+	Dialect      models.CSVDialect
+	HeaderRowIdx int // Record index of the header; preamble rows come before it
+}
 
-	// Check if Capital One (Debit/Credit separate)
-	if strings.Contains(headerStr, "Debit") && strings.Contains(headerStr, "Credit") && strings.Contains(headerStr, "Card No.") {
-		schema := models.CSVSchema{DateFormat: "2006-01-02", InvertAmounts: false, DateColIdx: -1, MerchantColIdx: -1}
-		for i, h := range headerRow {
-			hStr := strings.TrimSpace(h)
-			if hStr == "Transaction Date" {
-				schema.DateColIdx = i
-			} else if hStr == "Description" {
-				schema.MerchantColIdx = i
-			} else if hStr == "Debit" {
-				idx := i
-				schema.DebitColIdx = &idx
-			} else if hStr == "Credit" {
-				idx := i
-				schema.CreditColIdx = &idx
-			}
-		}
-		return NewDynamicAdapter(schema), 0, nil
+// DetectAdapter picks a schema for a CSV file from its header and sample rows.
+// The sniffed dialect is attached to the schema so the file is parsed the same
+// way it was read; cached schemas replay the dialect they were saved with.
+// The header position is always taken from the file, since preambles vary
+// between exports of the same bank.
+func DetectAdapter(provider llm.Provider, model string, sample CSVSample) (BankAdapter, int, error) {
+	adapter, tokens, err := detectAdapter(provider, model, sample.Header, sample.Rows, sample.Dialect)
+	if err != nil {
+		return nil, tokens, err
 	}
-
-	// Check if Chase
-	if (strings.Contains(headerStr, "Posting Date") && strings.Contains(headerStr, "Details")) ||
-		(strings.Contains(headerStr, "Post Date") && strings.Contains(headerStr, "Description") && strings.Contains(headerStr, "Category")) {
-		schema := models.CSVSchema{DateFormat: "01/02/2006", InvertAmounts: false, DateColIdx: -1, MerchantColIdx: -1}
-		for i, h := range headerRow {
-			hStr := strings.TrimSpace(h)
-			if hStr == "Posting Date" || hStr == "Post Date" || hStr == "Transaction Date" {
-				schema.DateColIdx = i
-			} else if hStr == "Description" {
-				schema.MerchantColIdx = i
-			} else if hStr == "Amount" {
-				idx := i
-				schema.AmountColIdx = &idx
-			}
-		}
-		// Fallbacks for Chase if indices weren't found precisely match legacy
-		if schema.DateColIdx == -1 && schema.MerchantColIdx == -1 {
-			schema.DateColIdx = 1
-			schema.MerchantColIdx = 2
-			idx := 3
-			schema.AmountColIdx = &idx
+	if a, ok := adapter.(*DynamicAdapter); ok {
+		if a.Schema.Dialect == (models.CSVDialect{}) {
+			a.Schema.Dialect = sample.Dialect
 		}
-		return NewDynamicAdapter(schema), 0, nil
+		a.Schema.HeaderRowIdx = sample.HeaderRowIdx
 	}
+	return adapter, tokens, nil
+}
 
-	// Check if BoA
-	if strings.Contains(headerStr, "Payee") && strings.Contains(headerStr, "Posted Date") {
-		schema := models.CSVSchema{DateFormat: "01/02/2006", InvertAmounts: false, DateColIdx: -1, MerchantColIdx: -1}
-		for i, h := range headerRow {
-			hStr := strings.TrimSpace(h)
-			if hStr == "Posted Date" {
-				schema.DateColIdx = i
-			} else if hStr == "Payee" {
-				schema.MerchantColIdx = i
-			} else if hStr == "Amount" {
-				idx := i
-				schema.AmountColIdx = &idx
-			}
-		}
-		return NewDynamicAdapter(schema), 0, nil
-	}
+func detectAdapter(provider llm.Provider, model string, headerRow []string, sampleRows []string, dialect models.CSVDialect) (BankAdapter, int, error) {
+	headerStr := strings.Join(headerRow, ",")
 
-	// Check if Fidelity
-	if strings.Contains(headerStr, "Transaction Date") && strings.Contains(headerStr, "Memo") {
-		schema := models.CSVSchema{DateFormat: "2006-01-02", InvertAmounts: false, DateColIdx: -1, MerchantColIdx: -1}
-		for i, h := range headerRow {
-			hStr := strings.TrimSpace(h)
-			if hStr == "Transaction Date" {
-				schema.DateColIdx = i
-			} else if hStr == "Name" || hStr == "Description" {
-				schema.MerchantColIdx = i
-			} else if hStr == "Amount" {
-				idx := i
-				schema.AmountColIdx = &idx
-			}
-		}
-		// Fidelity fallback
-		if schema.MerchantColIdx == -1 {
-			schema.MerchantColIdx = 1
-		}
-		return NewDynamicAdapter(schema), 0, nil
+	// Known banks are matched by header signature, see profiles/
+	if profile, schema, ok := MatchProfile(headerRow); ok {
+		log.Printf("Matched bank profile %q", profile.Name)
+		adapter := NewDynamicAdapter(schema)
+		adapter.Profile = profile.Name
+		return adapter, 0, nil
 	}
 
 	// Fallback: Use LLM to discover schema
@@ -136,7 +62,8 @@ func DetectAdapter(provider llm.Provider, model string, headerRow []string, samp
 	}
 	log.Println("Schema discovery complete")
 
-	// Save to cache for future use
+	// Save to cache for future use, with the dialect the file was read in
+	schema.Dialect = dialect
 	llm.SaveSchemaToCache(headerStr, schema)
 
 	return NewDynamicAdapter(schema), tokens, nil
@@ -15,7 +15,7 @@ func TestDetectAdapter(t *testing.T) {
 	junkHeaders := []string{"foo", "bar", "baz"}
 
 	// Test Chase
-	adapter, _, err := DetectAdapter(provider, model, chaseHeaders, nil)
+	adapter, _, err := DetectAdapter(provider, model, CSVSample{Header: chaseHeaders})
 	if err != nil {
 		t.Fatalf("Expected nil error for Chase headers, got: %v", err)
 	}
@@ -24,7 +24,7 @@ func TestDetectAdapter(t *testing.T) {
 	}
 
 	// Test Fidelity
-	adapter, _, err = DetectAdapter(provider, model, fidelityHeaders, nil)
+	adapter, _, err = DetectAdapter(provider, model, CSVSample{Header: fidelityHeaders})
 	if err != nil {
 		t.Fatalf("Expected nil error for Fidelity headers, got: %v", err)
 	}
@@ -34,7 +34,7 @@ func TestDetectAdapter(t *testing.T) {
 
 	// Test BoA
 	boaHeaders := []string{"Posted Date", "Reference Number", "Payee", "Address", "Amount"}
-	adapter, _, err = DetectAdapter(provider, model, boaHeaders, nil)
+	adapter, _, err = DetectAdapter(provider, model, CSVSample{Header: boaHeaders})
 	if err != nil {
 		t.Fatalf("Expected nil error for BoA headers, got: %v", err)
 	}
@@ -44,7 +44,7 @@ func TestDetectAdapter(t *testing.T) {
 
 	// Test Capital One
 	capOneHeaders := []string{"Transaction Date", "Posted Date", "Card No.", "Description", "Category", "Debit", "Credit"}
-	adapter, _, err = DetectAdapter(provider, model, capOneHeaders, nil)
+	adapter, _, err = DetectAdapter(provider, model, CSVSample{Header: capOneHeaders})
 	if err != nil {
 		t.Fatalf("Expected nil error for Capital One headers, got: %v", err)
 	}
@@ -55,7 +55,7 @@ func TestDetectAdapter(t *testing.T) {
 	// Test Junk (will attempt LLM discovery)
 	// Note: If Ollama is running, this may succeed with a discovered schema
 	// If Ollama is not running, this will fail with a connection error
-	_, _, err = DetectAdapter(provider, model, junkHeaders, nil)
+	_, _, err = DetectAdapter(provider, model, CSVSample{Header: junkHeaders})
 	// We don't assert on the error since it depends on whether Ollama is available
 	// The important thing is that the fallback mechanism works
 	_ = err
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"retrospend-sidecar/importer/models"
+	"retrospend-sidecar/importer/processor"
 	"os"
 )
 
@@ -27,10 +28,10 @@ func ExportToCSV(transactions []models.NormalizedTransaction, filename string) e
 	for _, tx := range transactions {
 		row := []string{
 			tx.Title,
-			fmt.Sprintf("%.2f", tx.Amount),
+			tx.Amount.StringFixed(int32(processor.DecimalDigits(tx.Currency))),
 			tx.Currency,
-			fmt.Sprintf("%.4f", tx.ExchangeRate),
-			fmt.Sprintf("%.2f", tx.AmountInUSD),
+			tx.ExchangeRate.String(),
+			tx.AmountInUSD.StringFixed(int32(processor.DecimalDigits("USD"))),
 			tx.Date,
 			tx.Location,
 			tx.Description,
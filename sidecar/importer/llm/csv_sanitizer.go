@@ -1,6 +1,8 @@
 package llm
 
 import (
+	"encoding/csv"
+	"regexp"
 	"strings"
 )
 
@@ -22,39 +24,87 @@ var maskedColumnNames = map[string]bool{
 	"transaction type":   true,
 	"category":           true,
 	"appears on your statement as": true,
+	"account number":     true,
+	"account no.":        true,
+	"account":            true,
 }
 
 // MaskCSVSampleRows replaces cell values in non-essential columns with "***".
 // The header string is used to determine which columns to mask.
-// sampleRows are comma-separated strings matching the header layout.
+// sampleRows are CSV-encoded lines matching the header layout; they are
+// parsed as CSV so quoted cells containing commas keep their column index.
 func MaskCSVSampleRows(header string, sampleRows []string) []string {
-	headers := strings.Split(header, ",")
-
-	// Determine which column indices to mask
-	maskIndices := make(map[int]bool)
-	for i, h := range headers {
-		normalized := strings.ToLower(strings.TrimSpace(h))
-		// Strip surrounding quotes
-		normalized = strings.Trim(normalized, "\"'")
-		if maskedColumnNames[normalized] {
-			maskIndices[i] = true
-		}
-	}
-
+	maskIndices := maskedColumns(splitCSVLine(header))
 	if len(maskIndices) == 0 {
 		return sampleRows
 	}
 
 	masked := make([]string, len(sampleRows))
 	for i, row := range sampleRows {
-		cells := strings.Split(row, ",")
+		cells := splitCSVLine(row)
 		for j := range cells {
 			if maskIndices[j] {
 				cells[j] = "***"
 			}
 		}
-		masked[i] = strings.Join(cells, ",")
+		masked[i] = joinCSVLine(cells)
 	}
 
 	return masked
 }
+
+// MaskRecord masks a parsed record for display, e.g. in row diagnostics:
+// non-essential columns become "***" as in MaskCSVSampleRows, and long digit
+// runs in the remaining cells (card or account numbers) keep only their last
+// four digits.
+func MaskRecord(headers []string, record []string) []string {
+	maskIndices := maskedColumns(headers)
+	masked := make([]string, len(record))
+	for i, cell := range record {
+		if maskIndices[i] {
+			masked[i] = "***"
+			continue
+		}
+		masked[i] = accountNumber.ReplaceAllStringFunc(cell, func(digits string) string {
+			return "****" + digits[len(digits)-4:]
+		})
+	}
+	return masked
+}
+
+// accountNumber matches digit runs long enough to be a card or account number.
+var accountNumber = regexp.MustCompile(`\d{9,}`)
+
+// maskedColumns returns the indices of headers listed in maskedColumnNames.
+func maskedColumns(headers []string) map[int]bool {
+	maskIndices := make(map[int]bool)
+	for i, h := range headers {
+		normalized := strings.ToLower(strings.TrimSpace(h))
+		// Strip surrounding quotes
+		normalized = strings.Trim(normalized, "\"'")
+		if maskedColumnNames[normalized] {
+			maskIndices[i] = true
+		}
+	}
+	return maskIndices
+}
+
+// splitCSVLine parses one CSV line, falling back to a plain comma split for
+// lines encoding/csv rejects.
+func splitCSVLine(line string) []string {
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if cells, err := r.Read(); err == nil {
+		return cells
+	}
+	return strings.Split(line, ",")
+}
+
+func joinCSVLine(cells []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(cells)
+	w.Flush()
+	return strings.TrimRight(b.String(), "\r\n")
+}
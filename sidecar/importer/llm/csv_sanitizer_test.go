@@ -120,3 +120,15 @@ func TestMaskCSVSampleRows_QuotedHeaders(t *testing.T) {
 		t.Errorf("Quoted Card No. header not matched: %s", cells[1])
 	}
 }
+
+func TestMaskCSVSampleRows_QuotedCommas(t *testing.T) {
+	header := "Date,Description,Amount,Balance"
+	sampleRows := []string{`01/11/2025,"CAFE, CENTRO","4,50","1.234,56"`}
+
+	masked := MaskCSVSampleRows(header, sampleRows)
+
+	want := `01/11/2025,"CAFE, CENTRO","4,50",***`
+	if masked[0] != want {
+		t.Errorf("expected %q, got %q", want, masked[0])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"retrospend-sidecar/importer/models"
+	"retrospend-sidecar/importer/processor"
 	"log"
 	"strings"
 	"sync"
@@ -11,8 +12,9 @@ import (
 
 // EnrichInput represents the data sent to the LLM for enrichment.
 type EnrichInput struct {
-	Index   int    `json:"index"`
-	RawText string `json:"raw_text"`
+	Index        int    `json:"index"`
+	RawText      string `json:"raw_text"`
+	BankCategory string `json:"bank_category,omitempty"` // Category column from the statement, if any
 }
 
 // EnrichOutput represents the enriched data returned by the LLM.
@@ -45,6 +47,31 @@ var enrichSchema = map[string]interface{}{
 	"required": []string{"enriched"},
 }
 
+// MatchCategories replaces each transaction's category with its spelling in
+// categories, ignoring case, or clears it when there is no such category, so
+// only valid categories reach the app. A statement's own categories
+// ("Restaurants & Dining") are only hints for enrichment.
+func MatchCategories(transactions []models.NormalizedTransaction, categories []string) {
+	for i := range transactions {
+		transactions[i].Category = matchCategory(transactions[i].Category, categories)
+	}
+}
+
+// matchCategory returns the category of categories equal to category,
+// ignoring case and surrounding spaces, or "" when there is none.
+func matchCategory(category string, categories []string) string {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return ""
+	}
+	for _, valid := range categories {
+		if strings.EqualFold(category, strings.TrimSpace(valid)) {
+			return valid
+		}
+	}
+	return ""
+}
+
 // EnrichTransactions enhances transaction data with better titles and categories using an LLM.
 // Returns enriched transactions and metadata about the enrichment process.
 func EnrichTransactions(provider Provider, model string, transactions []models.NormalizedTransaction, categories []string, batchSize int, maxConcurrency int, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, int, error) {
@@ -58,15 +85,28 @@ func EnrichTransactions(provider Provider, model string, transactions []models.N
 
 	// 1. Group transactions by unique raw text (cleaned for better deduplication)
 	uniqueRawToIndices := make(map[string][]int)
+	rawToBankCategory := make(map[string]string)
 	for i, t := range transactions {
 		rawText := strings.TrimSpace(t.Title + " " + t.Location)
 		if rawText == "" {
 			continue
 		}
-		// Clean noise from merchant text before deduplication
+		// Drop charged amounts ("UBER TRIP R$49,96") and noise from merchant
+		// text before deduplication; their currency was already recorded by
+		// processor.DetectChargedCurrencies
+		rawText, _ = processor.StripCurrencyAmounts(rawText, processor.CurrencyContext{Account: t.Currency})
 		rawText = CleanMerchantText(rawText)
+		if rawText == "" {
+			continue
+		}
 		uniqueRawToIndices[rawText] = append(uniqueRawToIndices[rawText], i)
+		if t.Category != "" && rawToBankCategory[rawText] == "" {
+			rawToBankCategory[rawText] = t.Category
+		}
 	}
+	// The bank's categories are kept as hints above; the transactions keep
+	// them only where they name a valid category
+	MatchCategories(transactions, categories)
 
 	if len(uniqueRawToIndices) == 0 {
 		return transactions, metadata, totalTokens, nil
@@ -118,7 +158,8 @@ func EnrichTransactions(provider Provider, model string, transactions []models.N
 		"3. 'category': Strictly choose the best fit from the valid list.\n" +
 		"   - Use 'Transfer' for all internal transfers or money movements between accounts.\n" +
 		"   - 'Groceries': Markets, supermarkets, convenience stores.\n" +
-		"   - 'Dining Out': Restaurants, fast food.\n\n" +
+		"   - 'Dining Out': Restaurants, fast food.\n" +
+		"   - If a 'bank_category' is given, it is the bank's own category for the transaction. Use it as a strong hint and pick the closest valid category.\n\n" +
 		"EXAMPLES:\n" +
 		"- Raw: 'DLO*RAPPI 7523CAP.FEDERAL' -> Title: 'Rappi', Location: 'Capital Federal', Category: 'Food Delivery'\n" +
 		"- Raw: 'ETHAN GIROUARD Funds Tran ETHAN GIROUARD' -> Title: 'Transfer', Location: '', Category: 'Transfer'\n" +
@@ -145,8 +186,9 @@ func EnrichTransactions(provider Provider, model string, transactions []models.N
 		var chunk []EnrichInput
 		for localIdx, globalIdx := 0, i; globalIdx < end; localIdx, globalIdx = localIdx+1, globalIdx+1 {
 			chunk = append(chunk, EnrichInput{
-				Index:   localIdx,
-				RawText: uniqueRawTexts[globalIdx],
+				Index:        localIdx,
+				RawText:      uniqueRawTexts[globalIdx],
+				BankCategory: rawToBankCategory[uniqueRawTexts[globalIdx]],
 			})
 		}
 
@@ -289,7 +331,10 @@ func EnrichTransactions(provider Provider, model string, transactions []models.N
 		for _, txIdx := range indices {
 			transactions[txIdx].Title = result.Title
 			transactions[txIdx].Location = result.Location
-			transactions[txIdx].Category = result.Category
+			// An empty or unknown category keeps the bank's own, if it was valid
+			if category := matchCategory(result.Category, categories); category != "" {
+				transactions[txIdx].Category = category
+			}
 			enrichedCount++
 		}
 	}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// stubProvider answers every batch with the same category for each item.
+type stubProvider struct {
+	category string
+}
+
+func (p stubProvider) Name() string { return "stub" }
+
+func (p stubProvider) Generate(req GenerateRequest) (GenerateResponse, error) {
+	var inputs []EnrichInput
+	prompt := req.UserPrompt[strings.Index(req.UserPrompt, "Transactions: ")+len("Transactions: "):]
+	if err := json.Unmarshal([]byte(prompt), &inputs); err != nil {
+		return GenerateResponse{}, err
+	}
+	var outputs []EnrichOutput
+	for _, in := range inputs {
+		outputs = append(outputs, EnrichOutput{Index: in.Index, Title: in.RawText, Category: p.category})
+	}
+	content, _ := json.Marshal(map[string]interface{}{"enriched": outputs})
+	return GenerateResponse{Content: string(content)}, nil
+}
+
+// ── Category validation ───────────────────────────────────────────────────────
+
+func TestMatchCategory(t *testing.T) {
+	categories := []string{"Dining Out", "Groceries"}
+	tests := map[string]string{
+		"Dining Out":         "Dining Out",
+		" dining out ":       "Dining Out",
+		"GROCERIES":          "Groceries",
+		"Restaurants & Bars": "",
+		"":                   "",
+	}
+	for input, want := range tests {
+		if got := matchCategory(input, categories); got != want {
+			t.Errorf("matchCategory(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestEnrichTransactions_OnlyValidCategories(t *testing.T) {
+	enrichCacheFilePath = filepath.Join(t.TempDir(), "enrichment_cache.json")
+	categories := []string{"Dining Out", "Groceries"}
+
+	for _, llmCategory := range []string{"", "Restaurants"} {
+		transactions := []models.NormalizedTransaction{
+			{Title: "PIZZERIA GUERRIN " + llmCategory, Category: "dining out"},
+			{Title: "COTO SUPERMERCADO " + llmCategory, Category: "Supermercados"},
+			{Title: "FARMACITY " + llmCategory},
+		}
+		enriched, _, _, err := EnrichTransactions(stubProvider{category: llmCategory}, "stub", transactions, categories, 10, 1, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The LLM gave no valid category: a valid bank category is kept in
+		// its canonical spelling, any other category is cleared
+		want := []string{"Dining Out", "", ""}
+		for i, tx := range enriched {
+			if tx.Category != want[i] {
+				t.Errorf("LLM category %q: transaction %d category = %q, want %q", llmCategory, i, tx.Category, want[i])
+			}
+		}
+	}
+}
+
+func TestEnrichTransactions_ValidLLMCategoryWins(t *testing.T) {
+	enrichCacheFilePath = filepath.Join(t.TempDir(), "enrichment_cache.json")
+	transactions := []models.NormalizedTransaction{{Title: "JUMBO", Category: "Supermercados"}}
+
+	enriched, _, _, err := EnrichTransactions(stubProvider{category: "groceries"}, "stub", transactions, []string{"Groceries"}, 10, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if enriched[0].Category != "Groceries" {
+		t.Errorf("expected Groceries, got %q", enriched[0].Category)
+	}
+}
@@ -21,8 +21,17 @@ func DiscoverCSVSchema(provider Provider, model string, header string, sampleRow
   3. 'amount_col_idx': The index of the transaction amount. Omit this if there are separate debit and credit columns.
   4. 'debit_col_idx': The index of the debit amount column. Omit if single amount column.
   5. 'credit_col_idx': The index of the credit amount column. Omit if single amount column.
-  6. 'date_format': Provide the exact Go time package layout string. Use '1/2/2006' for M/D/YYYY formats, or '2006-01-02' for YYYY-MM-DD.
+  6. 'date_format': Provide the exact Go time package layout string. Use '1/2/2006' for M/D/YYYY formats, '2/1/2006' for D/M/YYYY (day first, common outside the US), or '2006-01-02' for YYYY-MM-DD.
   7. 'invert_amounts': Return true if the sample EXPENSES are represented as positive numbers (e.g. a charge of $10 is listed as 10.00), or false if they are negative. Use false if using separate debit/credit columns.
+  8. 'decimal_separator': "," if amounts use a decimal comma (e.g. 1.234,56 or 12,50), "." otherwise. Omit if the sample rows do not make it clear.
+  9. 'thousands_separator': The digit grouping character ("," "." " " or "'"). Omit if none is visible.
+  10. 'negative_style': How negative amounts are written: "leading_minus" (-45.00), "trailing_minus" (45.00-) or "parentheses" ((45.00)). Omit if no negatives are visible.
+  11. 'currency_col_idx': The index of a column holding the ISO currency code of each amount (e.g. 'Currency', 'Moeda'). Omit if there is none.
+  12. 'description_col_idx': The index, or an array of indices, of extra description columns such as 'Memo', 'Notes' or 'Reference text'. List the merchant column first if it should be included. Omit if there are none.
+  13. 'category_col_idx': The index of the bank's own category column. Omit if there is none.
+  14. 'location_col_idx': The index of a city, address or country column. Omit if there is none.
+  15. 'original_amount_col_idx' and 'original_currency_col_idx': The indices of the amount and currency before conversion, for statements listing foreign purchases. Omit both if either is missing.
+  16. 'type_col_idx': The index of a column marking each row as a debit or credit (e.g. 'DR'/'CR', 'Debit'/'Credit', 'Sale'/'Payment'). Omit if there is none.
 
   Return ONLY a valid JSON object matching these exact keys.`
 
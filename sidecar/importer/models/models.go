@@ -1,20 +1,49 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
 // NormalizedTransaction represents a single financial transaction in a standard format
-// compatible with the Retrospend database schema.
+// compatible with the Retrospend database schema. Money fields are exact decimals
+// and serialize as JSON strings (e.g. "12.50") so no precision is lost in transit.
 type NormalizedTransaction struct {
-	Title            string  `json:"title"`            // Merchant or description
-	Amount           float64 `json:"amount"`           // Amount in the transaction's currency
-	Currency         string  `json:"currency"`         // ISO 3-letter currency code
-	ExchangeRate     float64 `json:"exchangeRate"`     // Rate used to convert from USD or to USD
-	AmountInUSD      float64 `json:"amountInUSD"`      // Normalized amount in US Dollars
-	Date             string  `json:"date"`             // YYYY-MM-DD
-	Location         string  `json:"location"`         // City/Country if available
-	Description      string  `json:"description"`      // Extra context
-	PricingSource    string  `json:"pricingSource"`    // Source of the data (e.g., "IMPORTED")
-	Category         string  `json:"category"`         // Transaction category (e.g., "Groceries")
-	OriginalCurrency string  `json:"original_currency"` // Raw currency before normalization
-	OriginalAmount   float64 `json:"original_amount"`   // Raw amount before normalization
+	Title            string          `json:"title"`                     // Merchant or description
+	Amount           decimal.Decimal `json:"amount"`                    // Amount in the transaction's currency
+	Currency         string          `json:"currency"`                  // ISO 3-letter currency code
+	ExchangeRate     decimal.Decimal `json:"exchangeRate"`              // Rate used to convert from USD or to USD
+	AmountInUSD      decimal.Decimal `json:"amountInUSD"`               // Normalized amount in US Dollars
+	Date             string          `json:"date"`                      // YYYY-MM-DD
+	Location         string          `json:"location"`                  // City/Country if available
+	Description      string          `json:"description"`               // Extra context
+	PricingSource    string          `json:"pricingSource"`             // Source of the data (e.g., "IMPORTED")
+	Category         string          `json:"category"`                  // Transaction category (e.g., "Groceries")
+	OriginalCurrency string          `json:"original_currency"`         // Raw currency before normalization
+	OriginalAmount   decimal.Decimal `json:"original_amount"`           // Raw amount before normalization
+	SourcePage       int             `json:"sourcePage,omitempty"`      // PDF page the transaction was read from (1-based)
+	SourceLineStart  int             `json:"sourceLineStart,omitempty"` // First raw PDF text line of the transaction (1-based)
+	SourceLineEnd    int             `json:"sourceLineEnd,omitempty"`   // Last raw PDF text line, including continuation lines
+	SourceRow        int             `json:"sourceRow,omitempty"`       // CSV record number (the header is row 1)
+	SourceFile       string          `json:"sourceFile,omitempty"`      // Name of the uploaded file
+	ImportID         string          `json:"importId,omitempty"`        // Stable import-local ID, e.g. "row-12" or "p2-l40"
+	RawText          string          `json:"rawText,omitempty"`         // Original row or lines, before cleaning and enrichment; CSV rows are masked
+}
+
+// SourceRef describes where the transaction was read from, e.g. "row 12" or
+// "page 2, lines 40-43". Returns "" when the source position is unknown.
+func (t NormalizedTransaction) SourceRef() string {
+	switch {
+	case t.SourceRow > 0:
+		return fmt.Sprintf("row %d", t.SourceRow)
+	case t.SourceLineStart > 0 && t.SourceLineEnd > t.SourceLineStart:
+		return fmt.Sprintf("page %d, lines %d-%d", t.SourcePage, t.SourceLineStart, t.SourceLineEnd)
+	case t.SourceLineStart > 0:
+		return fmt.Sprintf("page %d, line %d", t.SourcePage, t.SourceLineStart)
+	}
+	return ""
 }
 type CSVSchema struct {
 	DateColIdx     int    `json:"date_col_idx"`
@@ -24,6 +53,58 @@ type CSVSchema struct {
 	MerchantColIdx int    `json:"merchant_col_idx"`
 	DateFormat     string `json:"date_format"`
 	InvertAmounts  bool   `json:"invert_amounts"`
+
+	// Optional columns; nil (or empty) when the file has no such column
+	CurrencyColIdx         *int       `json:"currency_col_idx,omitempty"`          // ISO code of the amount
+	DescriptionColIdx      ColumnList `json:"description_col_idx,omitempty"`       // Memo/notes, merged in order
+	CategoryColIdx         *int       `json:"category_col_idx,omitempty"`          // Bank category, used as an enrichment hint
+	LocationColIdx         *int       `json:"location_col_idx,omitempty"`          // City/country/address
+	OriginalAmountColIdx   *int       `json:"original_amount_col_idx,omitempty"`   // Amount before conversion
+	OriginalCurrencyColIdx *int       `json:"original_currency_col_idx,omitempty"` // Currency before conversion
+	TypeColIdx             *int       `json:"type_col_idx,omitempty"`              // Debit/credit indicator (DR/CR)
+
+	// Number format. Empty values are inferred from the file's amount column(s).
+	DecimalSeparator   string `json:"decimal_separator,omitempty"`   // "." or ","
+	ThousandsSeparator string `json:"thousands_separator,omitempty"` // ",", ".", " " or "'"
+	NegativeStyle      string `json:"negative_style,omitempty"`      // "leading_minus", "trailing_minus" or "parentheses"
+
+	// Table position. Preamble rows before the header and footers after the
+	// last transaction (totals, disclaimers) are not parsed.
+	HeaderRowIdx   int `json:"header_row_idx,omitempty"`   // 0-based record index of the header row
+	SkipFooterRows int `json:"skip_footer_rows,omitempty"` // Trailing records to always ignore
+
+	Dialect CSVDialect `json:"dialect"` // Sniffed file dialect, replayed with cached schemas
+}
+
+// ColumnList is a list of column indices. It also decodes from a single
+// number, since models often return one index where a list is expected.
+type ColumnList []int
+
+func (c *ColumnList) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*c = nil
+		return nil
+	}
+	var single int
+	if err := json.Unmarshal(data, &single); err == nil {
+		*c = ColumnList{single}
+		return nil
+	}
+	var list []int
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// CSVDialect describes how a CSV file is encoded and delimited.
+// Zero values mean the encoding/csv defaults: UTF-8, comma, double quote.
+type CSVDialect struct {
+	Delimiter string `json:"delimiter,omitempty"` // Field separator, e.g. ";" or "\t"
+	Quote     string `json:"quote,omitempty"`     // Quote character, '"' or "'"
+	Encoding  string `json:"encoding,omitempty"`  // "utf-8", "utf-16le", "utf-16be" or "windows-1252"
+	HasBOM    bool   `json:"has_bom,omitempty"`   // File starts with a byte order mark
 }
 
 // ImportMetadata tracks success/failure statistics for import operations
@@ -35,6 +116,74 @@ type ImportMetadata struct {
 	SkippedTransactions int      `json:"skippedTransactions"` // Transactions skipped due to validation
 	TotalTokensUsed     int      `json:"totalTokensUsed"`     // Total LLM tokens consumed
 	Warnings            []string `json:"warnings"`            // User-facing warning messages
+
+	ValidationFailures []ValidationFailure `json:"validationFailures,omitempty"` // Why each skipped transaction failed validation
+
+	BankProfile string `json:"bankProfile,omitempty"` // CSV only: matched bank profile, if any
+
+	TotalRows      int             `json:"totalRows,omitempty"`      // CSV only: data rows read, excluding header and totals footers
+	RejectedRows   int             `json:"rejectedRows,omitempty"`   // CSV only: rows that produced no transaction
+	RowDiagnostics []RowDiagnostic `json:"rowDiagnostics,omitempty"` // CSV only: why each rejected row was skipped
+
+	Reconciliation  *Reconciliation  `json:"reconciliation,omitempty"`  // PDF only: extracted vs. statement totals
+	StatementPeriod *StatementPeriod `json:"statementPeriod,omitempty"` // PDF only: detected billing period
+}
+
+// Reasons a CSV row was rejected, reported in RowDiagnostic.Reason.
+const (
+	RowTooFewColumns = "too_few_columns"
+	RowInvalidDate   = "invalid_date"
+	RowInvalidAmount = "invalid_amount"
+	RowMissingAmount = "missing_amount"
+	RowFooter        = "footer/short row"
+)
+
+// RowDiagnostic explains why a CSV row produced no transaction. Cells are the
+// row as read, with sensitive columns masked.
+type RowDiagnostic struct {
+	Row    int      `json:"row"` // 1-based record number in the file, as in SourceRow
+	Reason string   `json:"reason"`
+	Detail string   `json:"detail,omitempty"`
+	Cells  []string `json:"cells"`
+}
+
+// ValidationFailure records a transaction skipped by validation, with the
+// structured code of the rule it broke (e.g. "amount_exceeds_limit").
+type ValidationFailure struct {
+	Transaction int    `json:"transaction"`      // 1-based position in the batch validated
+	Source      string `json:"source,omitempty"` // "row 12" or "page 3", as in SourceRef
+	Field       string `json:"field"`
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+}
+
+// StatementPeriod is the date range a statement covers (YYYY-MM-DD).
+type StatementPeriod struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// StatementTotals holds the summary figures printed on a bank statement.
+// A nil field means the figure was not found in the statement text.
+type StatementTotals struct {
+	PreviousBalance *decimal.Decimal `json:"previousBalance,omitempty"`
+	Purchases       *decimal.Decimal `json:"purchases,omitempty"`
+	Payments        *decimal.Decimal `json:"payments,omitempty"`
+	Credits         *decimal.Decimal `json:"credits,omitempty"`
+	Fees            *decimal.Decimal `json:"fees,omitempty"`
+	Interest        *decimal.Decimal `json:"interest,omitempty"`
+	NewBalance      *decimal.Decimal `json:"newBalance,omitempty"`
+}
+
+// Reconciliation compares the sum of extracted transactions against the
+// charges a statement reports, to detect rows the parser missed.
+type Reconciliation struct {
+	Status       string          `json:"status"`                 // "matched", "mismatch" or "unavailable"
+	Expected     decimal.Decimal `json:"expected"`               // Charges reported by the statement
+	Extracted    decimal.Decimal `json:"extracted"`              // Sum of extracted transaction amounts
+	Difference   decimal.Decimal `json:"difference"`             // Expected minus extracted
+	Totals       StatementTotals `json:"totals"`                 // Raw figures captured from the statement
+	SuspectPages []int           `json:"suspectPages,omitempty"` // 1-based pages most likely missing rows
 }
 
 // ImportResult wraps transactions with metadata about the import process
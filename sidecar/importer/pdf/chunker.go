@@ -0,0 +1,168 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"retrospend-sidecar/importer/llm"
+)
+
+// systemPromptTokens approximates the size of the extraction system prompt.
+const systemPromptTokens = 500
+
+// maxHeaderLines caps how many statement header lines are carried into each chunk.
+const maxHeaderLines = 5
+
+// ChunkOptions controls how statements too large for one LLM call are split.
+type ChunkOptions struct {
+	MaxContextTokens int // Token budget per LLM call, including the system prompt
+	OverlapRecords   int // Transaction records repeated at the start of the next chunk
+}
+
+// DefaultChunkOptions returns conservative limits that fit most local models.
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		MaxContextTokens: 8192,
+		OverlapRecords:   2,
+	}
+}
+
+// lineChunk is a contiguous range of sanitized lines sent to the LLM together.
+type lineChunk struct {
+	startLine int // 0-based, inclusive
+	endLine   int // 0-based, exclusive
+	text      string
+}
+
+// transactionRecords groups lines into records. Each record starts at a
+// transaction line and carries its continuation lines (foreign currency
+// amounts, exchange rates, wrapped descriptions) up to the next transaction
+// line, so a chunk boundary never separates a charge from its foreign data.
+// Lines before the first transaction form a leading record of their own.
+// Records are returned as [start, end) line index pairs.
+func transactionRecords(lines []string) [][2]int {
+	var records [][2]int
+	start := 0
+	for i, line := range lines {
+		if i > start && isTransactionLine(strings.TrimSpace(line)) {
+			records = append(records, [2]int{start, i})
+			start = i
+		}
+	}
+	if start < len(lines) {
+		records = append(records, [2]int{start, len(lines)})
+	}
+	return records
+}
+
+// chunkByTransactionLines splits text without page boundaries into chunks of
+// whole transaction records sized to the context budget. The statement header
+// is prepended to every chunk so the model keeps the year and period, and the
+// last opts.OverlapRecords records of each chunk are repeated in the next.
+func chunkByTransactionLines(lines []sourceLine, header string, opts ChunkOptions) []lineChunk {
+	records := transactionRecords(lineTexts(lines))
+
+	budget := opts.MaxContextTokens - systemPromptTokens - llm.EstimateTokenCount(header)
+	if budget <= 0 {
+		budget = 1
+	}
+
+	var chunks []lineChunk
+	for first := 0; first < len(records); {
+		// Always take at least one record so an oversized record still makes progress
+		last := first + 1
+		for last < len(records) {
+			candidate := numberLines(lines[records[first][0]:records[last][1]])
+			if llm.EstimateTokenCount(candidate) > budget {
+				break
+			}
+			last++
+		}
+
+		startLine, endLine := records[first][0], records[last-1][1]
+		body := numberLines(lines[startLine:endLine])
+		if header != "" {
+			body = header + "\n\n" + body
+		}
+		chunks = append(chunks, lineChunk{startLine: startLine, endLine: endLine, text: body})
+
+		if last >= len(records) {
+			break
+		}
+		next := last - opts.OverlapRecords
+		if next <= first {
+			next = first + 1
+		}
+		first = next
+	}
+
+	return chunks
+}
+
+// splitPages groups sanitized lines into pages. pdftotext starts each new
+// page with a form feed, so a line containing one opens the next page.
+func splitPages(lines []sourceLine) [][]sourceLine {
+	var pages [][]sourceLine
+	start := 0
+	for i, l := range lines {
+		if i > start && strings.Contains(l.text, "\f") {
+			pages = append(pages, lines[start:i])
+			start = i
+		}
+	}
+	return append(pages, lines[start:])
+}
+
+// numberLines renders lines for the model, prefixing each non-blank line with
+// its raw line number ("L12: ...") so every transaction can be traced back to
+// the line it was read from. Form feeds are dropped: pages are already split.
+func numberLines(lines []sourceLine) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		text := strings.ReplaceAll(l.text, "\f", "")
+		if strings.TrimSpace(text) != "" {
+			fmt.Fprintf(&b, "L%d: %s", l.num, text)
+		}
+	}
+	return b.String()
+}
+
+func lineTexts(lines []sourceLine) []string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	return texts
+}
+
+var yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// extractStatementHeader collects the lines before the first transaction that
+// anchor the statement in time (period/billing lines or lines mentioning a
+// year). It runs on the unsanitized text because the sanitizer strips them.
+// Account numbers and address lines are never carried over.
+func extractStatementHeader(rawText string) string {
+	var header []string
+	for _, line := range strings.Split(rawText, "\n") {
+		trimmed := strings.TrimSpace(strings.ReplaceAll(line, "\f", ""))
+		if isTransactionLine(trimmed) {
+			break
+		}
+		if !yearPattern.MatchString(trimmed) || matchAccountNumber(trimmed) || zipPattern.MatchString(trimmed) {
+			continue
+		}
+		header = append(header, trimmed)
+		if len(header) >= maxHeaderLines {
+			break
+		}
+	}
+
+	if len(header) == 0 {
+		return ""
+	}
+	return "STATEMENT HEADER (context only, not transactions):\n" + strings.Join(header, "\n")
+}
@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildLongStatement(n int) string {
+	var b strings.Builder
+	b.WriteString("Recent activity\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "11/%02d   11/%02d   MERCHANT NUMBER %03d WITH A LONG DESCRIPTION   $%d.00\n", i%28+1, i%28+1, i, i+1)
+		if i%5 == 0 {
+			b.WriteString("$49.96\nBRL\n5.331910352 Exchange Rate\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func TestTransactionRecords_KeepsForeignLinesWithCharge(t *testing.T) {
+	lines := strings.Split(`Header line
+11/21   11/21   UBER* TRIPOSASCO SP   $9.37
+$49.96
+BRL
+5.331910352 Exchange Rate
+11/22   11/24   GROCERY STORE   $12.10`, "\n")
+
+	records := transactionRecords(lines)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records (preamble + 2 transactions), got %d: %v", len(records), records)
+	}
+	if records[1] != [2]int{1, 5} {
+		t.Errorf("expected foreign-currency lines to stay with the Uber charge, got %v", records[1])
+	}
+}
+
+func TestChunkByTransactionLines_RespectsBudgetAndBoundaries(t *testing.T) {
+	text := buildLongStatement(200)
+	opts := ChunkOptions{MaxContextTokens: 1500, OverlapRecords: 0}
+
+	chunks := chunkByTransactionLines(sanitizeLines(text), "", opts)
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+
+	lines := strings.Split(text, "\n")
+	covered := 0
+	for i, c := range chunks {
+		if tokens := len(c.text) / 4; tokens+systemPromptTokens > opts.MaxContextTokens {
+			t.Errorf("chunk %d exceeds budget: %d tokens", i, tokens)
+		}
+		// Chunks must never start on a foreign-currency continuation line
+		if first := strings.TrimSpace(lines[c.startLine]); i > 0 && !isTransactionLine(first) {
+			t.Errorf("chunk %d starts mid-record: %q", i, first)
+		}
+		if c.startLine != covered {
+			t.Errorf("chunk %d starts at line %d; expected %d with no overlap", i, c.startLine, covered)
+		}
+		covered = c.endLine
+	}
+	if covered != len(lines) {
+		t.Errorf("chunks cover %d lines; expected %d", covered, len(lines))
+	}
+}
+
+func TestChunkByTransactionLines_OverlapRepeatsRecords(t *testing.T) {
+	text := buildLongStatement(120)
+	chunks := chunkByTransactionLines(sanitizeLines(text), "", ChunkOptions{MaxContextTokens: 1500, OverlapRecords: 2})
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	for i := 1; i < len(chunks); i++ {
+		if chunks[i].startLine >= chunks[i-1].endLine {
+			t.Errorf("chunk %d does not overlap previous chunk (%d >= %d)", i, chunks[i].startLine, chunks[i-1].endLine)
+		}
+		if chunks[i].startLine <= chunks[i-1].startLine {
+			t.Errorf("chunk %d does not make progress", i)
+		}
+	}
+}
+
+func TestChunkByTransactionLines_CarriesHeader(t *testing.T) {
+	header := extractStatementHeader("Statement Period: 12/01/2025 - 01/02/2026\n" + buildLongStatement(100))
+	if !strings.Contains(header, "12/01/2025 - 01/02/2026") {
+		t.Fatalf("expected statement period in header, got %q", header)
+	}
+
+	chunks := chunkByTransactionLines(sanitizeLines(buildLongStatement(100)), header, ChunkOptions{MaxContextTokens: 1500, OverlapRecords: 1})
+	for i, c := range chunks {
+		if !strings.HasPrefix(c.text, header) {
+			t.Errorf("chunk %d is missing the statement header", i)
+		}
+	}
+}
+
+func TestExtractStatementHeader_SkipsAccountNumbersAndAddresses(t *testing.T) {
+	raw := `Account Number: xxxx-1234 2025
+123 Main St, Springfield IL 62704
+Billing Period: November 2025
+11/21   11/21   COFFEE SHOP   $4.50
+Page 2 of 3 2025`
+
+	header := extractStatementHeader(raw)
+	if strings.Contains(header, "xxxx") || strings.Contains(header, "Main St") {
+		t.Errorf("header leaked PII: %q", header)
+	}
+	if !strings.Contains(header, "Billing Period: November 2025") {
+		t.Errorf("header missing billing period: %q", header)
+	}
+	if strings.Contains(header, "Page 2") {
+		t.Errorf("header should stop at the first transaction: %q", header)
+	}
+}
+
+func TestNumberLines_PrefixesRawLineNumbers(t *testing.T) {
+	lines := sanitizeLines("Page 1 of 2\n11/21   11/21   COFFEE SHOP   $4.50\n\n\f11/22   11/22   BAKERY   $3.00")
+
+	got := numberLines(lines)
+	want := "L2: 11/21   11/21   COFFEE SHOP   $4.50\n\nL4: 11/22   11/22   BAKERY   $3.00"
+	if got != want {
+		t.Errorf("numberLines() = %q; expected %q", got, want)
+	}
+	if pages := splitPages(lines); len(pages) != 2 || pages[1][0].num != 4 {
+		t.Errorf("expected the form feed to open page 2 at line 4, got %v", pages)
+	}
+}
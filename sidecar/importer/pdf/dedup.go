@@ -0,0 +1,167 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// sourceIndex resolves raw line numbers to pages and transaction records so
+// transactions can be traced back to the statement text they came from.
+type sourceIndex struct {
+	rawLines    []string
+	pages       []int       // raw line number -> 1-based page
+	recordStart map[int]int // raw line number -> first line of its record
+	recordEnd   map[int]int // first line of a record -> last non-blank line
+}
+
+// newSourceIndex builds the index from the raw text and its sanitized lines.
+func newSourceIndex(rawText string, lines []sourceLine) *sourceIndex {
+	idx := &sourceIndex{
+		recordStart: make(map[int]int),
+		recordEnd:   make(map[int]int),
+	}
+
+	rawLines := strings.Split(rawText, "\n")
+	idx.rawLines = rawLines
+	idx.pages = make([]int, len(rawLines)+1)
+	page := 1
+	for i, line := range rawLines {
+		page += strings.Count(line, "\f")
+		idx.pages[i+1] = page
+	}
+
+	for _, r := range transactionRecords(lineTexts(lines)) {
+		start := lines[r[0]].num
+		end := start
+		for _, l := range lines[r[0]:r[1]] {
+			idx.recordStart[l.num] = start
+			if strings.TrimSpace(strings.ReplaceAll(l.text, "\f", "")) != "" {
+				end = l.num
+			}
+		}
+		idx.recordEnd[start] = end
+	}
+
+	return idx
+}
+
+// attachSource replaces the line number reported by the model with the full
+// source span of its record and the record's raw text. Line numbers outside
+// the chunk the model was shown are cleared rather than trusted.
+func (idx *sourceIndex) attachSource(transactions []models.NormalizedTransaction, chunk []sourceLine) {
+	shown := make(map[int]bool, len(chunk))
+	for _, l := range chunk {
+		shown[l.num] = true
+	}
+
+	for i := range transactions {
+		tx := &transactions[i]
+		start, ok := idx.recordStart[tx.SourceLineStart]
+		if !shown[tx.SourceLineStart] || !ok {
+			tx.SourcePage, tx.SourceLineStart, tx.SourceLineEnd = 0, 0, 0
+			tx.RawText = ""
+			continue
+		}
+		tx.SourceLineStart = start
+		tx.SourceLineEnd = idx.recordEnd[start]
+		tx.SourcePage = idx.pages[start]
+		tx.RawText = idx.rawSpan(start, tx.SourceLineEnd)
+	}
+}
+
+// rawSpan returns the raw text of lines start..end (1-based, inclusive) with
+// blank lines dropped and column padding collapsed.
+func (idx *sourceIndex) rawSpan(start, end int) string {
+	var parts []string
+	for num := start; num <= end && num <= len(idx.rawLines); num++ {
+		line := strings.Join(strings.Fields(strings.ReplaceAll(idx.rawLines[num-1], "\f", "")), " ")
+		if line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// chunkTransactions is the output of one chunk together with the lines it covered.
+type chunkTransactions struct {
+	transactions []models.NormalizedTransaction
+	lines        []sourceLine
+}
+
+// deduplicateOverlapping merges chunk results in order. Overlapping chunks show
+// the model some lines twice, so a transaction is dropped only when an earlier
+// chunk already produced one from the same source line; identical purchases on
+// different lines are always kept. Transactions without a usable source line
+// fall back to date and amount, matched only against transactions the
+// previous chunk read from the shared lines.
+func deduplicateOverlapping(chunks []chunkTransactions) []models.NormalizedTransaction {
+	var result []models.NormalizedTransaction
+	kept := make(map[int]int) // source line -> transactions kept for it so far
+	var prev chunkTransactions
+
+	for _, c := range chunks {
+		current := make(map[int]bool, len(c.lines))
+		for _, l := range c.lines {
+			current[l.num] = true
+		}
+		previous := make(map[int]bool, len(prev.lines))
+		for _, l := range prev.lines {
+			previous[l.num] = true
+		}
+
+		// Previous-chunk transactions that may reappear in this chunk
+		unlined := make(map[string]int)
+		shared := make(map[string]int)
+		for _, tx := range prev.transactions {
+			switch {
+			case tx.SourceLineStart == 0:
+				unlined[fallbackKey(tx)]++
+			case current[tx.SourceLineStart]:
+				shared[fallbackKey(tx)]++
+			}
+		}
+		overlaps := false
+		for num := range current {
+			if previous[num] {
+				overlaps = true
+				break
+			}
+		}
+
+		perLine := make(map[int]int)
+		for _, tx := range c.transactions {
+			key := fallbackKey(tx)
+			if line := tx.SourceLineStart; line > 0 {
+				perLine[line]++
+				if perLine[line] <= kept[line] {
+					continue // Same source line already produced by an earlier chunk
+				}
+				if previous[line] && unlined[key] > 0 {
+					unlined[key]--
+					continue // The previous chunk read this line without reporting it
+				}
+				kept[line]++
+			} else if overlaps {
+				if unlined[key] > 0 {
+					unlined[key]--
+					continue
+				}
+				if shared[key] > 0 {
+					shared[key]--
+					continue
+				}
+			}
+			result = append(result, tx)
+		}
+
+		prev = c
+	}
+
+	return result
+}
+
+func fallbackKey(tx models.NormalizedTransaction) string {
+	return fmt.Sprintf("%s|%d", tx.Date, amountCents(tx.Amount))
+}
@@ -0,0 +1,107 @@
+package pdf
+
+import (
+	"testing"
+
+	"retrospend-sidecar/importer/models"
+)
+
+const overlapStatement = `11/21   11/21   COFFEE SHOP   $4.50
+11/21   11/21   COFFEE SHOP   $4.50
+11/21   11/21   UBER* TRIPOSASCO SP   $9.37
+$49.96
+BRL
+5.331910352 Exchange Rate
+` + "\f" + `11/22   11/24   GROCERY STORE   $12.10
+11/23   11/23   BAKERY   $3.00`
+
+func TestAttachSource_ResolvesRecordSpanAndPage(t *testing.T) {
+	lines := sanitizeLines(overlapStatement)
+	idx := newSourceIndex(overlapStatement, lines)
+
+	txs := []models.NormalizedTransaction{
+		{Title: "Uber", SourceLineStart: 5}, // model pointed at the currency line
+		{Title: "Grocery", SourceLineStart: 7},
+		{Title: "Invented", SourceLineStart: 99},
+	}
+	idx.attachSource(txs, lines)
+
+	if txs[0].SourceLineStart != 3 || txs[0].SourceLineEnd != 6 || txs[0].SourcePage != 1 {
+		t.Errorf("Uber: got page %d lines %d-%d; expected page 1 lines 3-6", txs[0].SourcePage, txs[0].SourceLineStart, txs[0].SourceLineEnd)
+	}
+	if want := "11/21 11/21 UBER* TRIPOSASCO SP $9.37\n$49.96\nBRL\n5.331910352 Exchange Rate"; txs[0].RawText != want {
+		t.Errorf("Uber: RawText = %q; expected %q", txs[0].RawText, want)
+	}
+	if txs[1].SourceLineStart != 7 || txs[1].SourceLineEnd != 7 || txs[1].SourcePage != 2 {
+		t.Errorf("Grocery: got page %d lines %d-%d; expected page 2 line 7", txs[1].SourcePage, txs[1].SourceLineStart, txs[1].SourceLineEnd)
+	}
+	if txs[2].SourceLineStart != 0 || txs[2].SourcePage != 0 {
+		t.Errorf("line numbers outside the chunk should be cleared, got %+v", txs[2])
+	}
+}
+
+func TestDeduplicateOverlapping_KeepsRepeatedPurchases(t *testing.T) {
+	lines := sanitizeLines(overlapStatement)
+	chunks := []chunkTransactions{
+		{
+			lines: lines[:6],
+			transactions: []models.NormalizedTransaction{
+				{Title: "Coffee Shop", Date: "2025-11-21", Amount: dec("4.50"), SourceLineStart: 1},
+				{Title: "Coffee Shop", Date: "2025-11-21", Amount: dec("4.50"), SourceLineStart: 2},
+				{Title: "Uber Triposasco SP", Date: "2025-11-21", Amount: dec("9.37"), SourceLineStart: 3},
+			},
+		},
+		{
+			lines: lines[2:],
+			transactions: []models.NormalizedTransaction{
+				// Same line, differently cleaned title
+				{Title: "Uber Triposasco", Date: "2025-11-21", Amount: dec("9.37"), SourceLineStart: 3},
+				{Title: "Grocery Store", Date: "2025-11-22", Amount: dec("12.10"), SourceLineStart: 7},
+				{Title: "Bakery", Date: "2025-11-23", Amount: dec("3.00"), SourceLineStart: 8},
+			},
+		},
+	}
+
+	result := deduplicateOverlapping(chunks)
+	if len(result) != 5 {
+		t.Fatalf("expected 5 transactions, got %d: %+v", len(result), result)
+	}
+	if result[0].Title != "Coffee Shop" || result[1].Title != "Coffee Shop" {
+		t.Errorf("both identical coffees must be kept, got %q and %q", result[0].Title, result[1].Title)
+	}
+	if result[2].Title != "Uber Triposasco SP" {
+		t.Errorf("the first chunk's copy should win, got %q", result[2].Title)
+	}
+}
+
+func TestDeduplicateOverlapping_FallbackWithoutLines(t *testing.T) {
+	lines := sanitizeLines(overlapStatement)
+	first := chunkTransactions{
+		lines: lines[:6],
+		transactions: []models.NormalizedTransaction{
+			{Title: "Coffee Shop", Date: "2025-11-21", Amount: dec("4.50"), SourceLineStart: 1},
+			{Title: "Uber", Date: "2025-11-21", Amount: dec("9.37"), SourceLineStart: 3},
+		},
+	}
+
+	// The model omitted line numbers in the overlapping chunk
+	overlapping := chunkTransactions{
+		lines: lines[2:],
+		transactions: []models.NormalizedTransaction{
+			{Title: "UBER TRIPOSASCO", Date: "2025-11-21", Amount: dec("9.37")},
+			{Title: "Coffee Shop", Date: "2025-11-21", Amount: dec("4.50")}, // not a shared line
+		},
+	}
+	if result := deduplicateOverlapping([]chunkTransactions{first, overlapping}); len(result) != 3 {
+		t.Errorf("expected only the shared Uber line to be dropped, got %d: %+v", len(result), result)
+	}
+
+	// Chunks that share no lines never drop anything
+	disjoint := chunkTransactions{
+		lines:        lines[6:],
+		transactions: []models.NormalizedTransaction{{Title: "Uber", Date: "2025-11-21", Amount: dec("9.37")}},
+	}
+	if result := deduplicateOverlapping([]chunkTransactions{first, disjoint}); len(result) != 3 {
+		t.Errorf("expected no deduplication across disjoint chunks, got %d", len(result))
+	}
+}
@@ -32,11 +32,12 @@ var transactionSchema = map[string]interface{}{
 					"description":       map[string]interface{}{"type": "string"},
 					"original_currency": map[string]interface{}{"type": "string"},
 					"original_amount":   map[string]interface{}{"type": "number"},
+					"line":              map[string]interface{}{"type": "integer"},
 				},
 				"required": []string{
 					"title", "amount", "currency", "date",
 					"category", "location", "description",
-					"original_currency", "original_amount",
+					"original_currency", "original_amount", "line",
 				},
 			},
 		},
@@ -44,68 +45,57 @@ var transactionSchema = map[string]interface{}{
 	"required": []string{"transactions"},
 }
 
-// deduplicateTransactions removes duplicate transactions based on date, title, and amount
-func deduplicateTransactions(transactions []models.NormalizedTransaction) []models.NormalizedTransaction {
-	seen := make(map[string]bool)
-	var result []models.NormalizedTransaction
-
-	for _, tx := range transactions {
-		// Create a unique key from date + title + amount
-		key := fmt.Sprintf("%s|%s|%.2f", tx.Date, tx.Title, tx.Amount)
-		if !seen[key] {
-			seen[key] = true
-			result = append(result, tx)
-		}
-	}
-	return result
-}
-
 // ParsePDFTransactions extracts transaction data from raw PDF text using an LLM.
-// It automatically chunks large PDFs by page boundaries to avoid context limit issues.
+// Large PDFs are chunked by page boundaries, or by transaction-line boundaries
+// when the text has no form feeds, to avoid context limit issues.
 // Returns transactions and metadata about the parsing process.
-func ParsePDFTransactions(provider llm.Provider, model string, rawText string, maxConcurrency int, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, int, error) {
+func ParsePDFTransactions(provider llm.Provider, model string, rawText string, maxConcurrency int, chunkOpts ChunkOptions, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, int, error) {
 	metadata := &models.ImportMetadata{
 		Warnings: []string{},
 	}
 	totalTokens := 0
-	// Sanitize text before token estimation (may reduce chunk count)
-	rawText = SanitizePDFText(rawText)
+
+	// Capture summary totals, period, page layout and header before the sanitizer strips them
+	totals := ExtractStatementTotals(rawText)
+	period := DetectStatementPeriod(rawText)
+	rawPages := strings.Split(rawText, "\f")
+	header := extractStatementHeader(rawText)
+	metadata.StatementPeriod = period
+	if period != nil {
+		log.Printf("Detected statement period %s to %s", period.Start, period.End)
+	}
+
+	// Sanitize text before token estimation (may reduce chunk count), keeping
+	// raw line numbers so each transaction can be traced to its source line
+	lines := sanitizeLines(rawText)
+	sources := newSourceIndex(rawText, lines)
+
+	if chunkOpts.MaxContextTokens <= 0 {
+		chunkOpts.MaxContextTokens = DefaultChunkOptions().MaxContextTokens
+	}
+	if chunkOpts.OverlapRecords < 0 {
+		chunkOpts.OverlapRecords = 0
+	}
 
 	// Check if we need to chunk the input
-	estimatedTokens := llm.EstimateTokenCount(rawText)
-	const maxContextTokens = 8192 // Conservative limit for most models
-	const systemPromptTokens = 500 // Approximate system prompt size
+	estimatedTokens := llm.EstimateTokenCount(numberLines(lines))
 
 	// If the text is small enough, process it all at once
-	if estimatedTokens+systemPromptTokens < maxContextTokens {
+	if estimatedTokens+systemPromptTokens < chunkOpts.MaxContextTokens {
 		if onProgress != nil {
 			onProgress(0.1, "Parsing bank statement...")
 		}
-		transactions, tokens, err := parsePDFChunk(provider, model, rawText)
-		if err != nil {
-			return nil, metadata, totalTokens, err
-		}
-		totalTokens += tokens
-		metadata.TotalChunks = 1
-		metadata.SuccessfulChunks = 1
-		metadata.TotalTransactions = len(transactions)
-		return transactions, metadata, totalTokens, nil
-	}
-
-	// Split by page boundaries (form-feed character)
-	pages := strings.Split(rawText, "\f")
-	if len(pages) <= 1 {
-		// No form-feed characters, but text is large - split by estimated token count
-		log.Printf("WARNING: Large PDF (%d estimated tokens) without page boundaries, processing as single chunk", estimatedTokens)
-		metadata.Warnings = append(metadata.Warnings, "Large PDF processed as single chunk - some transactions may be missed")
-		transactions, tokens, err := parsePDFChunk(provider, model, rawText)
+		transactions, tokens, err := parsePDFChunk(provider, model, numberLines(lines), period)
 		if err != nil {
 			return nil, metadata, totalTokens, err
 		}
+		sources.attachSource(transactions, lines)
 		totalTokens += tokens
 		metadata.TotalChunks = 1
 		metadata.SuccessfulChunks = 1
 		metadata.TotalTransactions = len(transactions)
+		metadata.Warnings = append(metadata.Warnings, correctDatesToPeriod(transactions, period)...)
+		reconcile(metadata, totals, transactions, rawPages)
 		return transactions, metadata, totalTokens, nil
 	}
 
@@ -113,18 +103,12 @@ func ParsePDFTransactions(provider llm.Provider, model string, rawText string, m
 		maxConcurrency = 3
 	}
 
-	log.Printf("PDF has %d pages, processing in chunks to avoid context limits", len(pages))
-
-	// Process pages in overlapping chunks (2 pages at a time, 1 page overlap)
-	chunkSize := 2
-	overlap := 1
-
 	// Build chunk jobs
 	type pdfChunkJob struct {
-		index     int
-		startPage int
-		endPage   int
-		text      string
+		index int
+		label string // Human-readable span, e.g. "pages 1-2" or "lines 40-180"
+		text  string
+		lines []sourceLine
 	}
 
 	type pdfChunkResult struct {
@@ -134,18 +118,48 @@ func ParsePDFTransactions(provider llm.Provider, model string, rawText string, m
 	}
 
 	var jobs []pdfChunkJob
-	for i := 0; i < len(pages); i += chunkSize - overlap {
-		end := i + chunkSize
-		if end > len(pages) {
-			end = len(pages)
+
+	// Split by page boundaries (form-feed character)
+	pages := splitPages(lines)
+	if len(pages) <= 1 {
+		// No form-feed characters: split on transaction boundaries sized to the context window
+		for _, c := range chunkByTransactionLines(lines, header, chunkOpts) {
+			chunkLines := lines[c.startLine:c.endLine]
+			jobs = append(jobs, pdfChunkJob{
+				index: len(jobs),
+				label: fmt.Sprintf("lines %d-%d", chunkLines[0].num, chunkLines[len(chunkLines)-1].num),
+				text:  c.text,
+				lines: chunkLines,
+			})
+		}
+		log.Printf("Large PDF (%d estimated tokens) without page boundaries, processing in %d line-based chunks", estimatedTokens, len(jobs))
+	} else {
+		log.Printf("PDF has %d pages, processing in chunks to avoid context limits", len(pages))
+
+		// Process pages in overlapping chunks (2 pages at a time, 1 page overlap)
+		chunkSize := 2
+		overlap := 1
+
+		for i := 0; i < len(pages); i += chunkSize - overlap {
+			end := i + chunkSize
+			if end > len(pages) {
+				end = len(pages)
+			}
+			var chunkLines []sourceLine
+			for _, page := range pages[i:end] {
+				chunkLines = append(chunkLines, page...)
+			}
+			chunkText := numberLines(chunkLines)
+			if header != "" && i > 0 {
+				chunkText = header + "\n\n" + chunkText
+			}
+			jobs = append(jobs, pdfChunkJob{
+				index: len(jobs),
+				label: fmt.Sprintf("pages %d-%d", i+1, end),
+				text:  chunkText,
+				lines: chunkLines,
+			})
 		}
-		chunkText := strings.Join(pages[i:end], "\f")
-		jobs = append(jobs, pdfChunkJob{
-			index:     len(jobs),
-			startPage: i,
-			endPage:   end,
-			text:      chunkText,
-		})
 	}
 
 	totalChunks := len(jobs)
@@ -166,22 +180,23 @@ func ParsePDFTransactions(provider llm.Provider, model string, rawText string, m
 			defer func() { <-sem }() // Release semaphore
 
 			chunkTokens := llm.EstimateTokenCount(j.text)
-			log.Printf("Processing pages %d-%d (%d estimated tokens)", j.startPage+1, j.endPage, chunkTokens)
+			log.Printf("Processing %s (%d estimated tokens)", j.label, chunkTokens)
 
 			if onProgress != nil {
 				progressMu.Lock()
 				completed := atomic.LoadInt32(&completedChunks)
-				onProgress(float64(completed)/float64(totalChunks), fmt.Sprintf("Parsing bank statement (pages %d-%d)...", j.startPage+1, j.endPage))
+				onProgress(float64(completed)/float64(totalChunks), fmt.Sprintf("Parsing bank statement (%s)...", j.label))
 				progressMu.Unlock()
 			}
 
-			transactions, tokens, err := parsePDFChunk(provider, model, j.text)
+			transactions, tokens, err := parsePDFChunk(provider, model, j.text, period)
 			if err != nil {
 				atomic.AddInt32(&atomicFailedChunks, 1)
-				warningMsg := fmt.Sprintf("Failed to parse pages %d-%d: %v", j.startPage+1, j.endPage, err)
+				warningMsg := fmt.Sprintf("Failed to parse %s: %v", j.label, err)
 				log.Printf("WARNING: %s", warningMsg)
 				results[j.index] = pdfChunkResult{warning: warningMsg}
 			} else {
+				sources.attachSource(transactions, j.lines)
 				results[j.index] = pdfChunkResult{transactions: transactions, tokens: tokens}
 			}
 
@@ -197,12 +212,12 @@ func ParsePDFTransactions(provider llm.Provider, model string, rawText string, m
 	wg.Wait()
 
 	// Collect results in order
-	var allTransactions []models.NormalizedTransaction
-	for _, r := range results {
+	chunks := make([]chunkTransactions, len(results))
+	for i, r := range results {
 		if r.warning != "" {
 			metadata.Warnings = append(metadata.Warnings, r.warning)
 		}
-		allTransactions = append(allTransactions, r.transactions...)
+		chunks[i] = chunkTransactions{transactions: r.transactions, lines: jobs[i].lines}
 		totalTokens += r.tokens
 	}
 
@@ -219,9 +234,16 @@ func ParsePDFTransactions(provider llm.Provider, model string, rawText string, m
 			failureRate*100, failedChunks, totalChunks)
 	}
 
-	// Deduplicate transactions that may have appeared in overlapping chunks
-	beforeDedup := len(allTransactions)
-	allTransactions = deduplicateTransactions(allTransactions)
+	// Fix year rollovers before deduplication, which falls back to the date
+	// for transactions without a source line
+	beforeDedup := 0
+	for _, c := range chunks {
+		metadata.Warnings = append(metadata.Warnings, correctDatesToPeriod(c.transactions, period)...)
+		beforeDedup += len(c.transactions)
+	}
+
+	// Deduplicate transactions read twice from the lines shared by overlapping chunks
+	allTransactions := deduplicateOverlapping(chunks)
 	afterDedup := len(allTransactions)
 
 	if beforeDedup > afterDedup {
@@ -235,18 +257,40 @@ func ParsePDFTransactions(provider llm.Provider, model string, rawText string, m
 		return nil, metadata, totalTokens, fmt.Errorf("CRITICAL: No transactions extracted from PDF. The file may be empty, corrupted, or in an unsupported format")
 	}
 
-	log.Printf("Extracted %d unique transactions from %d pages (%d chunks, %d failed)", len(allTransactions), len(pages), totalChunks, failedChunks)
+	log.Printf("Extracted %d unique transactions from %d pages (%d chunks, %d failed)", len(allTransactions), len(rawPages), totalChunks, failedChunks)
+
+	reconcile(metadata, totals, allTransactions, rawPages)
 
 	return allTransactions, metadata, totalTokens, nil
 }
 
-// parsePDFChunk processes a single chunk of PDF text
-func parsePDFChunk(provider llm.Provider, model string, rawText string) ([]models.NormalizedTransaction, int, error) {
+// reconcile attaches the statement reconciliation to metadata and surfaces
+// a warning when the extracted total disagrees with the statement.
+func reconcile(metadata *models.ImportMetadata, totals models.StatementTotals, transactions []models.NormalizedTransaction, rawPages []string) {
+	rec := ReconcileStatement(totals, transactions, rawPages)
+	metadata.Reconciliation = rec
+	if rec.Status == ReconciliationMismatch {
+		warning := reconciliationWarning(rec)
+		log.Printf("WARNING: %s", warning)
+		metadata.Warnings = append(metadata.Warnings, warning)
+	}
+}
+
+// llmTransaction is a transaction as returned by the model, with the number of
+// the statement line it was read from.
+type llmTransaction struct {
+	models.NormalizedTransaction
+	Line int `json:"line"`
+}
+
+// parsePDFChunk processes a single chunk of PDF text. When the statement period
+// is known it is passed to the model explicitly so it never has to guess the year.
+func parsePDFChunk(provider llm.Provider, model string, rawText string, period *models.StatementPeriod) ([]models.NormalizedTransaction, int, error) {
 	systemPrompt := `You are a highly precise financial data extraction tool. Extract individual debit transactions from bank statement text and output them as a JSON object with a "transactions" key.
 
 CRITICAL RULES:
 1. EXPENSES ONLY: Only include actual purchases/charges (debit transactions). Skip payments, credits, refunds, and "THANK YOU" entries.
-2. DATE: Use the Trans Date (first date column). Format as YYYY-MM-DD. Use the statement year shown in the text (e.g. if billing period is Nov 2025 - Dec 2025, use 2025 for Nov/Dec dates).
+2. DATE: Use the Trans Date (first date column). Format as YYYY-MM-DD. Take the year from the STATEMENT PERIOD given before the text: each date belongs to the year that places it inside (or shortly before) that period, so in a Dec 2025 - Jan 2026 period, Dec dates are 2025 and Jan dates are 2026. If no period is given, use the statement year shown in the text.
 3. AMOUNT: The USD charge amount listed on the same line as the merchant name. Output as a positive number (e.g. 9.37, not "$9.37").
 4. TITLE: The merchant/description text. Clean it up (remove exchange rate text).
 5. LOCATION: Extract city/country if present at the end of the merchant string, e.g. "FLORIANOPOLIS BR". Otherwise "".
@@ -258,24 +302,28 @@ CRITICAL RULES:
    In this case, original_amount = 49.96, original_currency = "BRL".
    If no foreign currency lines follow, use original_currency = "" and original_amount = 0.
 8. CATEGORY and DESCRIPTION: Leave as empty strings "".
+9. LINE: Each statement line starts with its line number, like "L40: ". Set line to the number of the line holding the merchant name and USD amount. Never copy the "L40:" prefix into any other field.
 
 Example - given this raw text segment:
-  Nov 21   Nov 21   UBER* TRIPOSASCOSP   $9.37
-  $49.96
-  BRL
-  5.331910352 Exchange Rate
-  Nov 21   Nov 24   PAYU*AR*UBERCAP.FEDERAL   $12.10
-  $16,322.00
-  ARS
-  1348.925619835 Exchange Rate
+  L40: Nov 21   Nov 21   UBER* TRIPOSASCOSP   $9.37
+  L41: $49.96
+  L42: BRL
+  L43: 5.331910352 Exchange Rate
+  L44: Nov 21   Nov 24   PAYU*AR*UBERCAP.FEDERAL   $12.10
+  L45: $16,322.00
+  L46: ARS
+  L47: 1348.925619835 Exchange Rate
 
 Output:
 {"transactions":[
-  {"title":"Uber Triposasco SP","amount":9.37,"currency":"USD","date":"2025-11-21","category":"","location":"SP","description":"","original_currency":"BRL","original_amount":49.96},
-  {"title":"PayU AR Uber Cap Federal","amount":12.10,"currency":"USD","date":"2025-11-21","category":"","location":"","description":"","original_currency":"ARS","original_amount":16322.00}
+  {"title":"Uber Triposasco SP","amount":9.37,"currency":"USD","date":"2025-11-21","category":"","location":"SP","description":"","original_currency":"BRL","original_amount":49.96,"line":40},
+  {"title":"PayU AR Uber Cap Federal","amount":12.10,"currency":"USD","date":"2025-11-21","category":"","location":"","description":"","original_currency":"ARS","original_amount":16322.00,"line":44}
 ]}`
 
 	prompt := "EXTRACT ALL EXPENSE TRANSACTIONS FROM THE FOLLOWING BANK STATEMENT TEXT:\n\n" + rawText
+	if period != nil {
+		prompt = fmt.Sprintf("STATEMENT PERIOD: %s to %s\n\n", period.Start, period.End) + prompt
+	}
 
 	genReq := llm.GenerateRequest{
 		SystemPrompt: systemPrompt,
@@ -295,26 +343,27 @@ Output:
 
 	cleanJSON := llm.CleanJSONResponse(resp.Content)
 
-	var transactions []models.NormalizedTransaction
+	var parsed []llmTransaction
 	// Primary parse: expect {"transactions": [...]}
 	var wrapper struct {
-		Transactions []models.NormalizedTransaction `json:"transactions"`
+		Transactions []llmTransaction `json:"transactions"`
 	}
 	if err := json.Unmarshal([]byte(cleanJSON), &wrapper); err == nil {
-		transactions = wrapper.Transactions
+		parsed = wrapper.Transactions
 	} else {
 		// Fallback: try parsing as a bare array
-		if err2 := json.Unmarshal([]byte(cleanJSON), &transactions); err2 != nil {
+		if err2 := json.Unmarshal([]byte(cleanJSON), &parsed); err2 != nil {
 			return nil, resp.TotalTokens, fmt.Errorf("LLM did not output a JSON object with a 'transactions' key containing an array of expense transaction objects. Fallback to bare array also failed: %w (raw response: %s)", err2, resp.Content)
 		}
 	}
 
-	if len(transactions) == 0 {
-		return transactions, resp.TotalTokens, nil
-	}
-
-	for i := range transactions {
+	transactions := make([]models.NormalizedTransaction, len(parsed))
+	for i, p := range parsed {
+		transactions[i] = p.NormalizedTransaction
+		// The prompt always asks for "USD"; the account currency is applied later
+		transactions[i].Currency = ""
 		transactions[i].OriginalCurrency = processor.NormalizeCurrency(transactions[i].OriginalCurrency)
+		transactions[i].SourceLineStart = p.Line // Resolved to a full span by attachSource
 	}
 
 	return transactions, resp.TotalTokens, nil
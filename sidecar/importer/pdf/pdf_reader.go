@@ -1,16 +1,83 @@
 package pdf
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
+)
+
+var (
+	// ErrPasswordRequired is returned when the PDF is encrypted and no password was given.
+	ErrPasswordRequired = errors.New("PDF is password protected")
+	// ErrPasswordIncorrect is returned when the given password does not open the PDF.
+	ErrPasswordIncorrect = errors.New("incorrect PDF password")
 )
 
 // ExtractTextFromPDF extracts all text from a PDF file located at filePath using pdftotext.
-func ExtractTextFromPDF(filePath string) (string, error) {
+// password opens encrypted statements; pass "" for unencrypted files. The password is
+// never put on a command line, where other local users could read it from the process
+// list, and never included in returned errors.
+func ExtractTextFromPDF(filePath string, password string) (string, error) {
+	if password != "" {
+		decrypted, err := decryptPDF(filePath, password)
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(decrypted)
+		filePath = decrypted
+	}
+
+	var stdout, stderr bytes.Buffer
 	cmd := exec.Command("pdftotext", "-layout", filePath, "-")
-	output, err := cmd.CombinedOutput()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if isPasswordError(stderr.String()) {
+			return "", ErrPasswordRequired
+		}
+		return "", fmt.Errorf("pdftotext failed: %v, output: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// decryptPDF writes a decrypted copy of the PDF to a temporary file and returns its
+// path; the caller removes it. qpdf reads the password from stdin, as pdftotext can
+// only take it as an argument.
+func decryptPDF(filePath string, password string) (string, error) {
+	out, err := os.CreateTemp("", "statement-*.pdf")
 	if err != nil {
-		return "", fmt.Errorf("pdftotext failed: %v, output: %s", err, string(output))
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	out.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("qpdf", "--password-file=-", "--decrypt", filePath, out.Name())
+	cmd.Stdin = strings.NewReader(password + "\n")
+	cmd.Stderr = &stderr
+
+	// qpdf exits with 3 when it succeeded with warnings
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+			return out.Name(), nil
+		}
+		os.Remove(out.Name())
+		if isPasswordError(stderr.String()) {
+			return "", ErrPasswordIncorrect
+		}
+		return "", fmt.Errorf("qpdf failed: %v, output: %s", err, stderr.String())
 	}
-	return string(output), nil
+	return out.Name(), nil
+}
+
+// isPasswordError reports whether pdftotext or qpdf failed because of the password.
+// Poppler prints "Command Line Error: Incorrect password" both when a password is
+// missing and when it is wrong; qpdf prints "invalid password".
+func isPasswordError(output string) bool {
+	output = strings.ToLower(output)
+	return strings.Contains(output, "incorrect password") || strings.Contains(output, "invalid password")
 }
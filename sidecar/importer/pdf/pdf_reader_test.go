@@ -8,7 +8,7 @@ func TestExtractTextFromPDF(t *testing.T) {
 	// Using an existing PDF from the data folder for testing
 	filePath := "../../data/Capital One REI Mastercard CC/Statement_012026_5258.pdf"
 
-	text, err := ExtractTextFromPDF(filePath)
+	text, err := ExtractTextFromPDF(filePath, "")
 	if err != nil {
 		t.Fatalf("Failed to extract text from PDF: %v", err)
 	}
@@ -17,3 +17,15 @@ func TestExtractTextFromPDF(t *testing.T) {
 		t.Error("Extracted text is empty, expected content")
 	}
 }
+
+func TestIsPasswordError(t *testing.T) {
+	if !isPasswordError("Command Line Error: Incorrect password\n") {
+		t.Error("expected poppler's incorrect password message to be detected")
+	}
+	if !isPasswordError("qpdf: statement.pdf: invalid password\n") {
+		t.Error("expected qpdf's invalid password message to be detected")
+	}
+	if isPasswordError("Syntax Error: Couldn't find trailer dictionary") {
+		t.Error("corrupt PDF errors must not be reported as password errors")
+	}
+}
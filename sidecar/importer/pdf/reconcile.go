@@ -0,0 +1,240 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"retrospend-sidecar/importer/models"
+)
+
+const (
+	ReconciliationMatched     = "matched"
+	ReconciliationMismatch    = "mismatch"
+	ReconciliationUnavailable = "unavailable"
+)
+
+// reconciliationTolerance absorbs cent-level rounding differences.
+var reconciliationTolerance = decimal.New(1, -2)
+
+// --- Statement total capture ---
+
+// Labels are anchored to the start of the line (after optional +/-/= markers)
+// so merchant names containing these words are never mistaken for totals.
+var (
+	previousBalanceLabel = regexp.MustCompile(`(?i)^[-+=\s]*(previous|opening|beginning)\s+balance\b`)
+	newBalanceLabel      = regexp.MustCompile(`(?i)^[-+=\s]*(new|closing|ending)\s+balance\b`)
+	purchasesLabel       = regexp.MustCompile(`(?i)^[-+=\s]*(new\s+charges|purchases|total\s+(debits|charges|purchases))\b`)
+	paymentsLabel        = regexp.MustCompile(`(?i)^[-+=\s]*(total\s+)?payments\b`)
+	creditsLabel         = regexp.MustCompile(`(?i)^[-+=\s]*(total\s+)?(other\s+)?credits\b`)
+	feesLabel            = regexp.MustCompile(`(?i)^[-+=\s]*(total\s+)?fees(\s+charged)?\b`)
+	interestLabel        = regexp.MustCompile(`(?i)^[-+=\s]*(total\s+)?interest(\s+charged)?\b`)
+
+	// summaryAmount matches a signed figure such as "$1,234.56", "-$12.00" or "+ 5.00".
+	// A trailing "%" is captured so APR columns can be rejected.
+	summaryAmount = regexp.MustCompile(`[-+]?\s*\$?\d[\d,]*\.\d{2}%?`)
+)
+
+// ExtractStatementTotals captures the account summary figures from raw PDF text.
+// It must run before SanitizePDFText, which strips these lines.
+func ExtractStatementTotals(text string) models.StatementTotals {
+	var totals models.StatementTotals
+
+	fields := []struct {
+		label  *regexp.Regexp
+		target **decimal.Decimal
+		signed bool
+	}{
+		{previousBalanceLabel, &totals.PreviousBalance, true},
+		{newBalanceLabel, &totals.NewBalance, true},
+		{purchasesLabel, &totals.Purchases, false},
+		{paymentsLabel, &totals.Payments, false},
+		{creditsLabel, &totals.Credits, false},
+		{feesLabel, &totals.Fees, false},
+		{interestLabel, &totals.Interest, false},
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(strings.ReplaceAll(line, "\f", ""))
+		if trimmed == "" {
+			continue
+		}
+		for _, f := range fields {
+			if *f.target != nil {
+				continue // first occurrence wins (the summary box precedes the details)
+			}
+			loc := f.label.FindStringIndex(trimmed)
+			if loc == nil {
+				continue
+			}
+			val, ok := lastSummaryAmount(trimmed[loc[1]:])
+			if !ok {
+				continue
+			}
+			if !f.signed {
+				val = val.Abs()
+			}
+			*f.target = &val
+			break
+		}
+	}
+
+	return totals
+}
+
+// lastSummaryAmount returns the right-most non-percentage figure in s.
+func lastSummaryAmount(s string) (decimal.Decimal, bool) {
+	matches := summaryAmount.FindAllString(s, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if strings.HasSuffix(matches[i], "%") {
+			continue
+		}
+		return parseSummaryAmount(matches[i])
+	}
+	return decimal.Zero, false
+}
+
+func parseSummaryAmount(s string) (decimal.Decimal, bool) {
+	cleaned := strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	val, err := decimal.NewFromString(cleaned)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return val, true
+}
+
+// --- Reconciliation ---
+
+// expectedCharges returns the charges the statement reports, preferring an
+// explicit purchases line and falling back to the balance equation
+// (new = previous + purchases + fees + interest - payments - credits).
+func expectedCharges(t models.StatementTotals) (decimal.Decimal, bool) {
+	if t.Purchases != nil {
+		return *t.Purchases, true
+	}
+	if t.PreviousBalance == nil || t.NewBalance == nil {
+		return decimal.Zero, false
+	}
+	charges := t.NewBalance.Sub(*t.PreviousBalance).Add(valueOr(t.Payments)).Add(valueOr(t.Credits)).
+		Sub(valueOr(t.Fees)).Sub(valueOr(t.Interest))
+	return charges, true
+}
+
+func valueOr(v *decimal.Decimal) decimal.Decimal {
+	if v == nil {
+		return decimal.Zero
+	}
+	return *v
+}
+
+// ReconcileStatement compares extracted transactions against the statement
+// totals. rawPages is the unsanitized text split on form feeds; it is used to
+// point at the pages most likely missing rows when the totals disagree.
+func ReconcileStatement(totals models.StatementTotals, transactions []models.NormalizedTransaction, rawPages []string) *models.Reconciliation {
+	rec := &models.Reconciliation{Totals: totals}
+
+	extracted := decimal.Zero
+	for _, tx := range transactions {
+		if tx.Amount.IsPositive() {
+			extracted = extracted.Add(tx.Amount)
+		}
+	}
+	rec.Extracted = extracted.Round(2)
+
+	expected, ok := expectedCharges(totals)
+	if !ok {
+		rec.Status = ReconciliationUnavailable
+		return rec
+	}
+	rec.Expected = expected.Round(2)
+	rec.Difference = rec.Expected.Sub(rec.Extracted)
+
+	// Fees and interest are sometimes listed as line items and sometimes only
+	// in the summary box, so either interpretation counts as a match.
+	withExtras := rec.Expected.Add(valueOr(totals.Fees)).Add(valueOr(totals.Interest)).Round(2)
+	if rec.Difference.Abs().LessThanOrEqual(reconciliationTolerance) ||
+		withExtras.Sub(rec.Extracted).Abs().LessThanOrEqual(reconciliationTolerance) {
+		rec.Status = ReconciliationMatched
+		return rec
+	}
+
+	rec.Status = ReconciliationMismatch
+	rec.SuspectPages = findSuspectPages(transactions, rawPages)
+	return rec
+}
+
+// findSuspectPages matches the charge amounts printed on each page against
+// the extracted amounts and returns pages with unmatched charges, ordered by
+// the number of unmatched charges (most first).
+func findSuspectPages(transactions []models.NormalizedTransaction, rawPages []string) []int {
+	remaining := make(map[int64]int)
+	for _, tx := range transactions {
+		remaining[amountCents(tx.Amount)]++
+	}
+
+	type pageMiss struct {
+		page   int
+		misses int
+	}
+	var misses []pageMiss
+
+	for i, page := range rawPages {
+		count := 0
+		for _, line := range strings.Split(page, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if !isTransactionLine(trimmed) {
+				continue
+			}
+			amounts := dollarPattern.FindAllStringIndex(trimmed, -1)
+			last := amounts[len(amounts)-1]
+			// Payments and credits are printed as negatives and intentionally skipped by the parser
+			if strings.HasSuffix(strings.TrimSpace(trimmed[:last[0]]), "-") {
+				continue
+			}
+			val, ok := parseSummaryAmount(trimmed[last[0]:last[1]])
+			if !ok {
+				continue
+			}
+			cents := amountCents(val)
+			if remaining[cents] > 0 {
+				remaining[cents]--
+				continue
+			}
+			count++
+		}
+		if count > 0 {
+			misses = append(misses, pageMiss{page: i + 1, misses: count})
+		}
+	}
+
+	sort.SliceStable(misses, func(a, b int) bool { return misses[a].misses > misses[b].misses })
+
+	pages := make([]int, len(misses))
+	for i, m := range misses {
+		pages[i] = m.page
+	}
+	return pages
+}
+
+// reconciliationWarning renders a mismatch as a user-facing warning.
+func reconciliationWarning(rec *models.Reconciliation) string {
+	msg := fmt.Sprintf("Extracted transactions total $%s but the statement reports $%s in charges (difference $%s)",
+		rec.Extracted.StringFixed(2), rec.Expected.StringFixed(2), rec.Difference.StringFixed(2))
+	if len(rec.SuspectPages) > 0 {
+		pages := make([]string, len(rec.SuspectPages))
+		for i, p := range rec.SuspectPages {
+			pages[i] = strconv.Itoa(p)
+		}
+		msg += "; check page(s) " + strings.Join(pages, ", ")
+	}
+	return msg
+}
+
+// amountCents converts an exact amount to whole cents.
+func amountCents(d decimal.Decimal) int64 {
+	return d.Shift(2).Round(0).IntPart()
+}
@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"retrospend-sidecar/importer/models"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+const summaryStatement = `Account Summary
+Previous Balance                    $1,234.56
+- Payments                          $1,234.56
++ Purchases                         $21.47
++ Fees Charged                      $0.00
+Interest Charged                    $0.00
+= New Balance                       $21.47
+Credit Limit                        $5,000.00
+Purchases APR 24.99%
+11/21   11/21   UBER* TRIPOSASCO SP   $9.37
+11/22   11/24   PAYU*AR*UBER CAP.FEDERAL   $12.10`
+
+func TestExtractStatementTotals_SummaryBox(t *testing.T) {
+	totals := ExtractStatementTotals(summaryStatement)
+
+	checks := []struct {
+		name string
+		got  *decimal.Decimal
+		want string
+	}{
+		{"PreviousBalance", totals.PreviousBalance, "1234.56"},
+		{"Payments", totals.Payments, "1234.56"},
+		{"Purchases", totals.Purchases, "21.47"},
+		{"Fees", totals.Fees, "0"},
+		{"Interest", totals.Interest, "0"},
+		{"NewBalance", totals.NewBalance, "21.47"},
+	}
+	for _, c := range checks {
+		if c.got == nil {
+			t.Errorf("%s was not captured", c.name)
+			continue
+		}
+		if !c.got.Equal(dec(c.want)) {
+			t.Errorf("%s = %s; expected %s", c.name, c.got, c.want)
+		}
+	}
+	if totals.Credits != nil {
+		t.Errorf("Credits should not be captured from 'Credit Limit', got %s", totals.Credits)
+	}
+}
+
+func TestExtractStatementTotals_IgnoresMerchantKeywords(t *testing.T) {
+	input := `11/21   11/21   NEW BALANCE GYM   $45.00
+11/22   11/22   PURCHASES ONLINE STORE   $12.00`
+
+	totals := ExtractStatementTotals(input)
+	if totals.NewBalance != nil || totals.Purchases != nil {
+		t.Errorf("Transaction lines should never be read as totals: %+v", totals)
+	}
+}
+
+func TestExtractStatementTotals_TotalDebits(t *testing.T) {
+	totals := ExtractStatementTotals("Total Debits   1,500.25\nTotal Credits   -200.00")
+
+	if totals.Purchases == nil || !totals.Purchases.Equal(dec("1500.25")) {
+		t.Errorf("expected Purchases=1500.25 from 'Total Debits', got %v", totals.Purchases)
+	}
+	if totals.Credits == nil || !totals.Credits.Equal(dec("200")) {
+		t.Errorf("expected Credits=200 (absolute), got %v", totals.Credits)
+	}
+}
+
+func TestReconcileStatement_Matched(t *testing.T) {
+	totals := ExtractStatementTotals(summaryStatement)
+	txs := []models.NormalizedTransaction{{Amount: dec("9.37")}, {Amount: dec("12.10")}}
+
+	rec := ReconcileStatement(totals, txs, []string{summaryStatement})
+	if rec.Status != ReconciliationMatched {
+		t.Fatalf("expected status matched, got %s (diff %s)", rec.Status, rec.Difference)
+	}
+	if len(rec.SuspectPages) != 0 {
+		t.Errorf("expected no suspect pages, got %v", rec.SuspectPages)
+	}
+}
+
+func TestReconcileStatement_MismatchNamesSuspectPages(t *testing.T) {
+	page1 := `Purchases   $30.00
+11/21   11/21   COFFEE SHOP   $4.50
+11/21   11/21   COFFEE SHOP   $4.50`
+	page2 := `11/22   11/22   GROCERY STORE   $21.00
+11/23   11/23   PAYMENT THANK YOU   - $100.00`
+
+	totals := ExtractStatementTotals(page1)
+	// The grocery row on page 2 was missed by the parser
+	txs := []models.NormalizedTransaction{{Amount: dec("4.50")}, {Amount: dec("4.50")}}
+
+	rec := ReconcileStatement(totals, txs, []string{page1, page2})
+	if rec.Status != ReconciliationMismatch {
+		t.Fatalf("expected status mismatch, got %s", rec.Status)
+	}
+	if !rec.Difference.Equal(dec("21")) {
+		t.Errorf("expected difference 21.00, got %s", rec.Difference)
+	}
+	if len(rec.SuspectPages) != 1 || rec.SuspectPages[0] != 2 {
+		t.Errorf("expected suspect pages [2], got %v", rec.SuspectPages)
+	}
+	if !strings.Contains(reconciliationWarning(rec), "check page(s) 2") {
+		t.Errorf("warning should name the suspect page: %s", reconciliationWarning(rec))
+	}
+}
+
+func TestReconcileStatement_BalanceEquationFallback(t *testing.T) {
+	totals := ExtractStatementTotals(`Previous Balance   $100.00
+Payments           -$100.00
+New Balance        $50.00`)
+	txs := []models.NormalizedTransaction{{Amount: dec("50")}}
+
+	rec := ReconcileStatement(totals, txs, nil)
+	if rec.Status != ReconciliationMatched {
+		t.Errorf("expected matched via balance equation, got %s (expected %s)", rec.Status, rec.Expected)
+	}
+}
+
+func TestReconcileStatement_UnavailableWithoutTotals(t *testing.T) {
+	rec := ReconcileStatement(models.StatementTotals{}, []models.NormalizedTransaction{{Amount: dec("5")}}, nil)
+	if rec.Status != ReconciliationUnavailable {
+		t.Errorf("expected status unavailable, got %s", rec.Status)
+	}
+	if !rec.Extracted.Equal(dec("5")) {
+		t.Errorf("expected extracted total 5.00, got %s", rec.Extracted)
+	}
+}
+
+func TestReconcileStatement_ExactCents(t *testing.T) {
+	// 0.10 + 0.20 is not 0.30 in float64; the decimal sum matches exactly
+	totals := ExtractStatementTotals("Purchases   $0.30")
+	txs := []models.NormalizedTransaction{{Amount: dec("0.10")}, {Amount: dec("0.20")}}
+
+	rec := ReconcileStatement(totals, txs, nil)
+	if rec.Status != ReconciliationMatched || !rec.Difference.IsZero() {
+		t.Errorf("expected an exact match, got %s (diff %s)", rec.Status, rec.Difference)
+	}
+}
@@ -0,0 +1,204 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"retrospend-sidecar/importer/models"
+)
+
+const (
+	// periodLeadDays allows transaction dates before the period start: purchases
+	// often post days or weeks after they were made.
+	periodLeadDays = 45
+	// periodTrailDays allows small clock/timezone slips past the period end.
+	periodTrailDays = 7
+)
+
+var (
+	// periodKeyword marks lines that state the statement period or closing date.
+	periodKeyword = regexp.MustCompile(`(?i)(statement|billing)\s+(period|cycle|dates?)|opening\s*/\s*closing\s+date|period\s+(covered|ending)|closing\s+date|days\s+in\s+billing`)
+
+	numericDate  = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})/(\d{4}|\d{2})\b`)
+	monthDayDate = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+(\d{1,2})\b(?:,?\s+(\d{4}))?`)
+	dayMonthDate = regexp.MustCompile(`(?i)\b(\d{1,2})\s+(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?(?:,?\s+(\d{4}))?`)
+	monthYear    = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+(\d{4})\b`)
+)
+
+var monthNumbers = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// periodDate is a date found in a period line; year is 0 when not printed.
+type periodDate struct {
+	pos   int
+	year  int
+	month time.Month
+	day   int
+}
+
+// DetectStatementPeriod finds the statement period in raw PDF text. It must
+// run before SanitizePDFText, which strips period lines as page headers.
+// Returns nil when no period could be determined.
+func DetectStatementPeriod(rawText string) *models.StatementPeriod {
+	lines := strings.Split(rawText, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(strings.ReplaceAll(line, "\f", ""))
+		if isTransactionLine(trimmed) || !periodKeyword.MatchString(trimmed) {
+			continue
+		}
+
+		candidate := trimmed
+		// The value is often printed on the line below its label
+		if dates := findPeriodDates(candidate); len(dates) == 0 && i+1 < len(lines) {
+			candidate += " " + strings.TrimSpace(lines[i+1])
+		}
+
+		if start, end, ok := periodFromLine(candidate); ok {
+			return &models.StatementPeriod{
+				Start: start.Format("2006-01-02"),
+				End:   end.Format("2006-01-02"),
+			}
+		}
+	}
+	return nil
+}
+
+// periodFromLine resolves the dates found in a period line into a range.
+func periodFromLine(line string) (time.Time, time.Time, bool) {
+	dates := findPeriodDates(line)
+
+	switch {
+	case len(dates) >= 2:
+		start, end := dates[0], dates[1]
+		if end.year == 0 {
+			return time.Time{}, time.Time{}, false
+		}
+		if start.year == 0 {
+			// "Nov 21 - Dec 20, 2025" or a Dec-Jan rollover "Dec 21 - Jan 20, 2026"
+			start.year = end.year
+			if start.month > end.month {
+				start.year--
+			}
+		}
+		s, e := start.toTime(), end.toTime()
+		if e.Before(s) {
+			return time.Time{}, time.Time{}, false
+		}
+		return s, e, true
+
+	case len(dates) == 1 && dates[0].year != 0:
+		// A lone closing date: assume a one-month cycle ending on it
+		end := dates[0].toTime()
+		return end.AddDate(0, -1, 1), end, true
+	}
+
+	// "Billing Period: November 2025"
+	if m := monthYear.FindStringSubmatch(line); m != nil {
+		year, _ := strconv.Atoi(m[2])
+		start := time.Date(year, monthNumbers[strings.ToLower(m[1][:3])], 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1), true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// findPeriodDates returns the dates in a line in reading order. Numeric dates
+// are read as MM/DD unless the first field cannot be a month.
+func findPeriodDates(line string) []periodDate {
+	var dates []periodDate
+
+	for _, m := range numericDate.FindAllStringSubmatchIndex(line, -1) {
+		a, _ := strconv.Atoi(line[m[2]:m[3]])
+		b, _ := strconv.Atoi(line[m[4]:m[5]])
+		year, _ := strconv.Atoi(line[m[6]:m[7]])
+		if year < 100 {
+			year += 2000
+		}
+		month, day := a, b
+		if a > 12 {
+			month, day = b, a
+		}
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			continue
+		}
+		dates = append(dates, periodDate{pos: m[0], year: year, month: time.Month(month), day: day})
+	}
+
+	if len(dates) == 0 {
+		for _, m := range monthDayDate.FindAllStringSubmatchIndex(line, -1) {
+			dates = append(dates, namedPeriodDate(line, m[0], line[m[2]:m[3]], line[m[4]:m[5]], m[6], m[7]))
+		}
+		for _, m := range dayMonthDate.FindAllStringSubmatchIndex(line, -1) {
+			dates = append(dates, namedPeriodDate(line, m[0], line[m[4]:m[5]], line[m[2]:m[3]], m[6], m[7]))
+		}
+		// Restore reading order after combining both named patterns
+		for i := 1; i < len(dates); i++ {
+			for j := i; j > 0 && dates[j].pos < dates[j-1].pos; j-- {
+				dates[j], dates[j-1] = dates[j-1], dates[j]
+			}
+		}
+	}
+
+	return dates
+}
+
+func namedPeriodDate(line string, pos int, monthName string, dayStr string, yearStart, yearEnd int) periodDate {
+	day, _ := strconv.Atoi(dayStr)
+	d := periodDate{pos: pos, month: monthNumbers[strings.ToLower(monthName[:3])], day: day}
+	if yearStart >= 0 {
+		d.year, _ = strconv.Atoi(line[yearStart:yearEnd])
+	}
+	return d
+}
+
+func (d periodDate) toTime() time.Time {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC)
+}
+
+// correctDatesToPeriod validates parsed dates against the statement period and
+// shifts dates that are exactly one year off (the classic Dec/Jan mistake)
+// back into the period. It returns one warning per shifted transaction.
+func correctDatesToPeriod(transactions []models.NormalizedTransaction, period *models.StatementPeriod) []string {
+	if period == nil {
+		return nil
+	}
+	start, err1 := time.Parse("2006-01-02", period.Start)
+	end, err2 := time.Parse("2006-01-02", period.End)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	windowStart := start.AddDate(0, 0, -periodLeadDays)
+	windowEnd := end.AddDate(0, 0, periodTrailDays)
+	inWindow := func(d time.Time) bool {
+		return !d.Before(windowStart) && !d.After(windowEnd)
+	}
+
+	var warnings []string
+	for i, tx := range transactions {
+		date, err := time.Parse("2006-1-2", tx.Date)
+		if err != nil || inWindow(date) {
+			continue
+		}
+		for _, years := range []int{-1, 1} {
+			shifted := date.AddDate(years, 0, 0)
+			if !inWindow(shifted) {
+				continue
+			}
+			transactions[i].Date = shifted.Format("2006-01-02")
+			ref := ""
+			if r := tx.SourceRef(); r != "" {
+				ref = " (" + r + ")"
+			}
+			warnings = append(warnings, fmt.Sprintf("Shifted date of '%s'%s from %s to %s to fit the statement period %s to %s",
+				tx.Title, ref, tx.Date, transactions[i].Date, period.Start, period.End))
+			break
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+
+	"retrospend-sidecar/importer/models"
+)
+
+func TestDetectStatementPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantStart string
+		wantEnd   string
+	}{
+		{"numeric range", "Statement Period: 11/01/2025 - 11/30/2025", "2025-11-01", "2025-11-30"},
+		{"two-digit years", "Opening/Closing Date 11/21/25 - 12/20/25", "2025-11-21", "2025-12-20"},
+		{"named months with shared year", "Billing Cycle: Nov 21 - Dec 20, 2025", "2025-11-21", "2025-12-20"},
+		{"year rollover", "Statement Period Dec 21, 2025 - Jan 20, 2026", "2025-12-21", "2026-01-20"},
+		{"rollover with shared year", "Billing Period: Dec 21 - Jan 20, 2026", "2025-12-21", "2026-01-20"},
+		{"day-month order", "Statement period 21 Dec 2025 to 20 Jan 2026", "2025-12-21", "2026-01-20"},
+		{"whole month", "Billing Period: November 2025", "2025-11-01", "2025-11-30"},
+		{"value on next line", "Statement Period\n12/01/2025 - 12/31/2025", "2025-12-01", "2025-12-31"},
+		{"closing date only", "Statement Closing Date 01/20/2026", "2025-12-21", "2026-01-20"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := DetectStatementPeriod("Page 1 of 3\n" + tt.input + "\n11/21   11/21   COFFEE SHOP   $4.50")
+			if period == nil {
+				t.Fatal("expected a period, got nil")
+			}
+			if period.Start != tt.wantStart || period.End != tt.wantEnd {
+				t.Errorf("got %s to %s; expected %s to %s", period.Start, period.End, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestDetectStatementPeriod_NoneFound(t *testing.T) {
+	if period := DetectStatementPeriod("11/21   11/21   STATEMENT PERIOD CAFE   $4.50"); period != nil {
+		t.Errorf("transaction lines must not be read as periods, got %+v", period)
+	}
+	if period := DetectStatementPeriod("Payment Due Date 01/15/2026"); period != nil {
+		t.Errorf("due dates are not statement periods, got %+v", period)
+	}
+}
+
+func TestCorrectDatesToPeriod_ShiftsYearRollover(t *testing.T) {
+	period := &models.StatementPeriod{Start: "2025-12-21", End: "2026-01-20"}
+	txs := []models.NormalizedTransaction{
+		{Title: "December coffee", Date: "2026-12-28"}, // model used the closing year
+		{Title: "January lunch", Date: "2025-01-05"},   // model used the opening year
+		{Title: "Correct", Date: "2026-01-10"},
+		{Title: "Posted late", Date: "2025-11-30"}, // before the period, within lead window
+	}
+
+	warnings := correctDatesToPeriod(txs, period)
+
+	want := []string{"2025-12-28", "2026-01-05", "2026-01-10", "2025-11-30"}
+	for i, w := range want {
+		if txs[i].Date != w {
+			t.Errorf("tx %d (%s): date = %s; expected %s", i, txs[i].Title, txs[i].Date, w)
+		}
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 shift warnings, got %d: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], "from 2026-12-28 to 2025-12-28") {
+		t.Errorf("warning should describe the shift: %s", warnings[0])
+	}
+}
+
+func TestCorrectDatesToPeriod_LeavesUnfixableDates(t *testing.T) {
+	period := &models.StatementPeriod{Start: "2025-11-01", End: "2025-11-30"}
+	txs := []models.NormalizedTransaction{{Title: "Way off", Date: "2021-06-01"}}
+
+	if warnings := correctDatesToPeriod(txs, period); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	if txs[0].Date != "2021-06-01" {
+		t.Errorf("date should be unchanged, got %s", txs[0].Date)
+	}
+}
+
+func TestCorrectDatesToPeriod_NilPeriod(t *testing.T) {
+	txs := []models.NormalizedTransaction{{Title: "A", Date: "2026-12-28"}}
+	if warnings := correctDatesToPeriod(txs, nil); warnings != nil {
+		t.Errorf("expected nil warnings without a period, got %v", warnings)
+	}
+}
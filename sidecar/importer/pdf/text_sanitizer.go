@@ -11,8 +11,25 @@ import (
 // token usage and hallucination risk before sending to the LLM.
 // It never removes lines that look like transactions (date + dollar amount).
 func SanitizePDFText(text string) string {
+	return joinSourceLines(sanitizeLines(text))
+}
+
+// sourceLine is a line that survived sanitization, tagged with its 1-based
+// line number in the raw PDF text so parsed transactions can point back to it.
+type sourceLine struct {
+	num  int
+	text string
+}
+
+func joinSourceLines(lines []sourceLine) string {
+	return strings.Join(lineTexts(lines), "\n")
+}
+
+// sanitizeLines implements SanitizePDFText, keeping the raw line number of
+// every line it preserves.
+func sanitizeLines(text string) []sourceLine {
 	lines := strings.Split(text, "\n")
-	var result []string
+	var result []sourceLine
 	var stripped []string
 	blankRun := 0
 	inLegalBlock := false
@@ -26,7 +43,7 @@ func SanitizePDFText(text string) string {
 		if strings.Contains(line, "\f") {
 			inLegalBlock = false
 			blankRun = 0
-			result = append(result, line)
+			result = append(result, sourceLine{num: i + 1, text: line})
 			continue
 		}
 
@@ -34,14 +51,14 @@ func SanitizePDFText(text string) string {
 		if isTransactionLine(trimmed) {
 			inLegalBlock = false
 			blankRun = 0
-			result = append(result, line)
+			result = append(result, sourceLine{num: i + 1, text: line})
 			continue
 		}
 
 		// Always preserve foreign currency data lines
 		if isForeignCurrencyLine(trimmed) {
 			blankRun = 0
-			result = append(result, line)
+			result = append(result, sourceLine{num: i + 1, text: line})
 			continue
 		}
 
@@ -51,7 +68,7 @@ func SanitizePDFText(text string) string {
 			inLegalBlock = false
 			blankRun++
 			if blankRun <= 1 {
-				result = append(result, "")
+				result = append(result, sourceLine{num: i + 1})
 			}
 			continue
 		}
@@ -104,7 +121,7 @@ func SanitizePDFText(text string) string {
 			continue
 		}
 
-		result = append(result, line)
+		result = append(result, sourceLine{num: i + 1, text: line})
 	}
 
 	if len(stripped) > 0 && isDebugLog() {
@@ -114,7 +131,7 @@ func SanitizePDFText(text string) string {
 		}
 	}
 
-	return strings.Join(result, "\n")
+	return result
 }
 
 // --- Transaction line detector (safety mechanism) ---
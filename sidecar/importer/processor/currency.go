@@ -1,51 +1,33 @@
 package processor
 
 import (
-	"encoding/json"
-	"log"
-	"os"
 	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"retrospend-sidecar/currencies"
 )
 
 // CurrencyData holds information about a specific currency.
-type CurrencyData struct {
-	Symbol        string  `json:"symbol"`
-	Name          string  `json:"name"`
-	SymbolNative  string  `json:"symbol_native"`
-	DecimalDigits int     `json:"decimal_digits"`
-	Rounding      float64 `json:"rounding"`
-	Code          string  `json:"code"`
-	NamePlural    string  `json:"name_plural"`
-}
+type CurrencyData = currencies.Data
 
 var nameToCodeMap map[string]string
 
+// decimalDigits holds each currency's minor-unit digits from currency.json.
+var decimalDigits map[string]int
+
+// MaxDecimalDigits is the scale of stored expense amounts (Decimal(19,8)),
+// used for currencies currency.json does not list, such as crypto assets.
+const MaxDecimalDigits = 8
+
 func init() {
 	nameToCodeMap = make(map[string]string)
-	
-	paths := []string{"currency.json", "../../currency.json", "../currency.json"}
-	var data []byte
-	var err error
-	for _, p := range paths {
-		data, err = os.ReadFile(p)
-		if err == nil {
-			break
-		}
-	}
-	
-	if err != nil {
-		log.Printf("Warning: could not read currency.json: %v. Currency normalization will be limited.", err)
-		return
-	}
-
-	var currencies map[string]CurrencyData
-	if err := json.Unmarshal(data, &currencies); err != nil {
-		log.Printf("Warning: could not parse currency.json: %v. Currency normalization will be limited.", err)
-		return
-	}
+	decimalDigits = make(map[string]int)
 
-	for _, currency := range currencies {
+	for _, currency := range currencies.Fiat() {
 		nameToCodeMap[strings.ToUpper(currency.Name)] = currency.Code
+		decimalDigits[currency.Code] = currency.DecimalDigits
+		addSymbols(currency)
 	}
 }
 
@@ -58,4 +40,31 @@ func NormalizeCurrency(rawCurrency string) string {
 	return cleaned
 }
 
+// DecimalDigits returns how many decimal places amounts in a currency carry,
+// e.g. 2 for USD and 0 for JPY. Unknown currencies keep MaxDecimalDigits.
+func DecimalDigits(currency string) int {
+	if digits, ok := decimalDigits[strings.ToUpper(currency)]; ok {
+		return digits
+	}
+	return MaxDecimalDigits
+}
+
+// IsKnownCurrency reports whether code is a currency in currency.json or a
+// supported crypto asset from crypto_currency.json. When currency.json could
+// not be loaded, any code of three uppercase letters is accepted rather than
+// rejecting every import.
+func IsKnownCurrency(code string) bool {
+	if strings.ToUpper(code) == code && currencies.IsCrypto(code) {
+		return true
+	}
+	if len(decimalDigits) == 0 {
+		return len(code) == 3 && strings.ToUpper(code) == code
+	}
+	_, ok := decimalDigits[code]
+	return ok
+}
 
+// RoundAmount rounds an amount to its currency's decimal places.
+func RoundAmount(amount decimal.Decimal, currency string) decimal.Decimal {
+	return amount.Round(int32(DecimalDigits(currency)))
+}
@@ -1,15 +1,35 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"retrospend-sidecar/db"
 	"retrospend-sidecar/importer/models"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Validation codes reported in ValidationError.Code.
+const (
+	CodeEmptyTitle         = "empty_title"
+	CodeAmountNotPositive  = "amount_not_positive"
+	CodeAmountExceedsLimit = "amount_exceeds_limit"
+	CodeInvalidCurrency    = "invalid_currency"
+	CodeUnknownCurrency    = "unknown_currency"
+	CodeInvalidDate        = "invalid_date"
+	CodeDateTooOld         = "date_too_old"
+	CodeDateInFuture       = "date_in_future"
 )
 
 // ValidationError represents a validation failure for a transaction
 type ValidationError struct {
 	Field   string
+	Code    string // One of the Code* constants
 	Message string
 }
 
@@ -17,28 +37,64 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
-// ValidateTransaction validates a single transaction and returns an error if invalid.
-// Performs sanity checks on LLM-generated data to prevent silent corruption.
+// ValidationRules are the per-deployment limits ValidateTransaction enforces.
+type ValidationRules struct {
+	// MaxAmountUSD caps a transaction's value in US dollars, so the same
+	// limit holds for USD and for ARS, COP, IDR or VND amounts.
+	MaxAmountUSD decimal.Decimal
+	// MaxFutureDays is how far past today a date may fall, for timezone slack.
+	MaxFutureDays int
+	// ExtraCurrencies are accepted in addition to currency.json and the
+	// supported crypto assets.
+	ExtraCurrencies []string
+	// UnitsPerUSD holds current exchange rates as units of each currency per
+	// US dollar. Without a rate, the statement's own rate is used if present,
+	// else the amount is compared to MaxAmountUSD as is.
+	UnitsPerUSD map[string]decimal.Decimal
+}
+
+// DefaultValidationRules returns the limits used when a deployment sets none.
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		MaxAmountUSD:  decimal.NewFromInt(1000000),
+		MaxFutureDays: 7,
+	}
+}
+
+// exchangeRateDigits is the precision kept when deriving an exchange rate,
+// enough for crypto and high-inflation currency pairs.
+const exchangeRateDigits = 10
+
+// ValidateTransaction validates a transaction against DefaultValidationRules.
 func ValidateTransaction(tx models.NormalizedTransaction) error {
+	return DefaultValidationRules().Validate(tx)
+}
+
+// Validate validates a single transaction and returns a ValidationError if invalid.
+// Performs sanity checks on LLM-generated data to prevent silent corruption.
+func (r ValidationRules) Validate(tx models.NormalizedTransaction) error {
 	// Validate title
 	if strings.TrimSpace(tx.Title) == "" {
-		return ValidationError{Field: "title", Message: "title cannot be empty"}
+		return ValidationError{Field: "title", Code: CodeEmptyTitle, Message: "title cannot be empty"}
 	}
 
-	// Validate amount (must be positive and reasonable)
-	if tx.Amount <= 0 {
-		return ValidationError{Field: "amount", Message: fmt.Sprintf("amount must be positive, got %.2f", tx.Amount)}
+	// Validate currency (must be uppercase and a currency we can price)
+	if tx.Currency == "" || strings.ToUpper(tx.Currency) != tx.Currency {
+		return ValidationError{Field: "currency", Code: CodeInvalidCurrency, Message: fmt.Sprintf("currency must be an uppercase code, got '%s'", tx.Currency)}
 	}
-	if tx.Amount > 1000000 {
-		return ValidationError{Field: "amount", Message: fmt.Sprintf("amount exceeds maximum (1,000,000), got %.2f", tx.Amount)}
+	if !IsKnownCurrency(tx.Currency) && !slices.Contains(r.ExtraCurrencies, tx.Currency) {
+		return ValidationError{Field: "currency", Code: CodeUnknownCurrency, Message: fmt.Sprintf("unknown currency '%s'", tx.Currency)}
 	}
 
-	// Validate currency (must be 3 uppercase letters)
-	if len(tx.Currency) != 3 {
-		return ValidationError{Field: "currency", Message: fmt.Sprintf("currency must be 3 letters, got '%s'", tx.Currency)}
+	// Validate amount (must be positive and reasonable)
+	if !tx.Amount.IsPositive() {
+		return ValidationError{Field: "amount", Code: CodeAmountNotPositive, Message: fmt.Sprintf("amount must be positive, got %s", tx.Amount)}
 	}
-	if strings.ToUpper(tx.Currency) != tx.Currency {
-		return ValidationError{Field: "currency", Message: fmt.Sprintf("currency must be uppercase, got '%s'", tx.Currency)}
+	if r.MaxAmountUSD.IsPositive() {
+		if usd := r.usdEquivalent(tx); usd.GreaterThan(r.MaxAmountUSD) {
+			return ValidationError{Field: "amount", Code: CodeAmountExceedsLimit, Message: fmt.Sprintf("amount %s %s (about %s USD) exceeds the maximum of %s USD",
+				tx.Amount, tx.Currency, usd.StringFixed(2), r.MaxAmountUSD)}
+		}
 	}
 
 	// Validate date format and range
@@ -47,34 +103,56 @@ func ValidateTransaction(tx models.NormalizedTransaction) error {
 		// Try parsing with single-digit month/day
 		dateVal, err = time.Parse("2006-1-2", tx.Date)
 		if err != nil {
-			return ValidationError{Field: "date", Message: fmt.Sprintf("invalid date format '%s', expected YYYY-MM-DD", tx.Date)}
+			return ValidationError{Field: "date", Code: CodeInvalidDate, Message: fmt.Sprintf("invalid date format '%s', expected YYYY-MM-DD", tx.Date)}
 		}
 	}
 
 	// Date must be after 1970 and not in the future
 	minDate := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	maxDate := time.Now().AddDate(0, 0, 7) // Allow up to 7 days in future for timezone issues
+	maxDate := time.Now().AddDate(0, 0, r.MaxFutureDays)
 	if dateVal.Before(minDate) {
-		return ValidationError{Field: "date", Message: fmt.Sprintf("date %s is before 1970", tx.Date)}
+		return ValidationError{Field: "date", Code: CodeDateTooOld, Message: fmt.Sprintf("date %s is before 1970", tx.Date)}
 	}
 	if dateVal.After(maxDate) {
-		return ValidationError{Field: "date", Message: fmt.Sprintf("date %s is in the future", tx.Date)}
+		return ValidationError{Field: "date", Code: CodeDateInFuture, Message: fmt.Sprintf("date %s is in the future", tx.Date)}
 	}
 
 	return nil
 }
 
+// usdEquivalent converts a transaction's amount to US dollars: with the
+// current rate for its currency, else the rate printed on the statement.
+func (r ValidationRules) usdEquivalent(tx models.NormalizedTransaction) decimal.Decimal {
+	if tx.Currency == "USD" {
+		return tx.Amount
+	}
+	if rate, ok := r.UnitsPerUSD[tx.Currency]; ok && rate.IsPositive() {
+		return tx.Amount.DivRound(rate, exchangeRateDigits)
+	}
+	if tx.AmountInUSD.IsPositive() && !tx.ExchangeRate.Equal(decimal.NewFromInt(1)) {
+		return tx.AmountInUSD
+	}
+	return tx.Amount
+}
+
 // ValidateTransactions validates all transactions and returns valid ones + warnings for invalid ones.
 // Invalid transactions are skipped and a warning is added to metadata.
-func ValidateTransactions(transactions []models.NormalizedTransaction, metadata *models.ImportMetadata) []models.NormalizedTransaction {
+func ValidateTransactions(transactions []models.NormalizedTransaction, metadata *models.ImportMetadata, rules ValidationRules) []models.NormalizedTransaction {
 	valid := make([]models.NormalizedTransaction, 0, len(transactions))
 
 	for i, tx := range transactions {
-		if err := ValidateTransaction(tx); err != nil {
-			warning := fmt.Sprintf("Skipped transaction #%d: %v (title: '%s', amount: %.2f, date: '%s')",
-				i+1, err, tx.Title, tx.Amount, tx.Date)
+		if err := rules.Validate(tx); err != nil {
+			warning := fmt.Sprintf("Skipped transaction #%d%s: %v (title: '%s', amount: %s, date: '%s')",
+				i+1, sourceSuffix(tx), err, tx.Title, tx.Amount, tx.Date)
 			metadata.Warnings = append(metadata.Warnings, warning)
 			metadata.SkippedTransactions++
+
+			failure := models.ValidationFailure{Transaction: i + 1, Source: tx.SourceRef(), Message: err.Error()}
+			var verr ValidationError
+			if errors.As(err, &verr) {
+				failure.Field, failure.Code = verr.Field, verr.Code
+			}
+			metadata.ValidationFailures = append(metadata.ValidationFailures, failure)
 			continue
 		}
 		valid = append(valid, tx)
@@ -83,33 +161,134 @@ func ValidateTransactions(transactions []models.NormalizedTransaction, metadata
 	return valid
 }
 
+// RateLookup finds exchange rates for transaction dates. Amounts are
+// converted with db.ExchangeRate.ToUSD, as recurring expenses and the /rates
+// endpoints convert them.
+type RateLookup struct {
+	// Find returns the rate for a currency nearest a date; ok is false when
+	// there is none
+	Find func(currency string, date time.Time) (rate db.ExchangeRate, ok bool)
+	// MaxDaysAway is how far from a transaction's date a rate may be before
+	// the import warns about it
+	MaxDaysAway int
+}
+
 // ApplyExchangeRates calculates and applies exchange rates for transactions that were
 // originally in a foreign currency. It updates the transaction's main amount and currency
-// with the foreign data while preserving the USD value.
+// with the foreign data while preserving the USD value. Transactions without a
+// currency (no currency column in the source) are assigned defaultCurrency.
+// The account amount is taken to be in US dollars; ApplyHistoricalRates
+// converts it at the rate of the day instead.
 func ApplyExchangeRates(transactions []models.NormalizedTransaction, defaultCurrency string) {
+	ApplyHistoricalRates(transactions, defaultCurrency, RateLookup{}, nil)
+}
+
+// ApplyHistoricalRates is ApplyExchangeRates with the account amount of each
+// transaction converted to US dollars at the rate for its date, so a EUR or
+// ARS account is not read as dollars. Currencies without a rate, and rates
+// more than rates.MaxDaysAway from the transaction, are reported as warnings
+// on metadata, which may be nil.
+func ApplyHistoricalRates(transactions []models.NormalizedTransaction, defaultCurrency string, rates RateLookup, metadata *models.ImportMetadata) {
 	if defaultCurrency == "" {
 		defaultCurrency = "USD"
 	}
 
+	type lookupKey struct{ currency, date string }
+	type lookupResult struct {
+		rate *db.ExchangeRate
+		days int // Distance between the rate's date and the transaction's
+	}
+	cache := make(map[lookupKey]lookupResult)
+	lookup := func(currency string, dateStr string) lookupResult {
+		key := lookupKey{currency, dateStr}
+		if result, ok := cache[key]; ok {
+			return result
+		}
+		var result lookupResult
+		if date, err := time.Parse("2006-1-2", dateStr); err == nil {
+			if rate, ok := rates.Find(currency, date); ok && rate.Rate.IsPositive() {
+				result = lookupResult{rate: &rate, days: int(math.Abs(date.Sub(rate.Date).Hours()) / 24)}
+			}
+		}
+		cache[key] = result
+		return result
+	}
+
+	missing := make(map[string]int)  // Transactions per currency without a rate
+	farCount := make(map[string]int) // Transactions per currency with a distant rate
+	farthest := make(map[string]int) // Largest distance in days per currency
 	for i := range transactions {
-		transactions[i].PricingSource = "IMPORTED"
+		tx := &transactions[i]
+		tx.PricingSource = "IMPORTED"
 
-		if transactions[i].OriginalAmount > 0 && transactions[i].OriginalCurrency != "" {
-			// Calculate rate based on USD amount (Amount) vs Foreign amount (OriginalAmount)
-			if transactions[i].Amount > 0 {
-				transactions[i].ExchangeRate = transactions[i].OriginalAmount / transactions[i].Amount
+		// The amount is in the account currency, even for a foreign purchase
+		account := tx.Currency
+		if account == "" {
+			account = defaultCurrency
+		}
+		usd := tx.Amount
+		var rate *db.ExchangeRate
+		if account != "USD" && rates.Find != nil {
+			result := lookup(account, tx.Date)
+			if rate = result.rate; rate == nil {
+				missing[account]++
 			} else {
-				transactions[i].ExchangeRate = 1.0
+				usd = rate.ToUSD(tx.Amount)
+				if result.days > rates.MaxDaysAway {
+					farCount[account]++
+					farthest[account] = max(farthest[account], result.days)
+				}
 			}
-			transactions[i].AmountInUSD = transactions[i].Amount
-			transactions[i].Amount = transactions[i].OriginalAmount
-			transactions[i].Currency = transactions[i].OriginalCurrency
+		}
+
+		if tx.OriginalAmount.IsPositive() && tx.OriginalCurrency != "" {
+			// Calculate rate based on USD amount vs Foreign amount (OriginalAmount)
+			if usd.IsPositive() {
+				tx.ExchangeRate = tx.OriginalAmount.DivRound(usd, exchangeRateDigits)
+			} else {
+				tx.ExchangeRate = decimal.NewFromInt(1)
+			}
+			tx.AmountInUSD = RoundAmount(usd, "USD")
+			tx.Amount = RoundAmount(tx.OriginalAmount, tx.OriginalCurrency)
+			tx.Currency = tx.OriginalCurrency
 		} else {
-			transactions[i].ExchangeRate = 1.0
-			transactions[i].AmountInUSD = transactions[i].Amount
-			transactions[i].Currency = defaultCurrency
+			// Stored as in exchange_rate, as for recurring expenses
+			tx.ExchangeRate = decimal.NewFromInt(1)
+			if rate != nil {
+				tx.ExchangeRate = rate.Rate
+			}
+			tx.AmountInUSD = RoundAmount(usd, "USD")
+			tx.Currency = account
+			tx.Amount = RoundAmount(tx.Amount, tx.Currency)
 		}
 	}
+
+	if metadata == nil {
+		return
+	}
+	for _, currency := range slices.Sorted(maps.Keys(missing)) {
+		metadata.Warnings = append(metadata.Warnings, fmt.Sprintf(
+			"No exchange rate found for %s; %d transaction(s) use their %s amount as the USD amount", currency, missing[currency], currency))
+	}
+	for _, currency := range slices.Sorted(maps.Keys(farCount)) {
+		metadata.Warnings = append(metadata.Warnings, fmt.Sprintf(
+			"%d %s transaction(s) were converted with a rate up to %d days from their date; check their USD amounts", farCount[currency], currency, farthest[currency]))
+	}
+}
+
+// PrepareTransactions runs the steps every parsed statement goes through
+// before enrichment: currencies resolved to ISO codes, provenance assigned,
+// dates normalized, amounts charged in another currency detected from the
+// title, US dollar amounts priced at the rate of each date, and payments
+// removed. The order matters: charged currencies must be found before
+// pricing, which converts from the original amount.
+func PrepareTransactions(transactions []models.NormalizedTransaction, sourceFile string, currency CurrencyContext, rates RateLookup, metadata *models.ImportMetadata) []models.NormalizedTransaction {
+	ResolveCurrencies(transactions, currency)
+	AssignProvenance(transactions, sourceFile)
+	NormalizeDate(transactions)
+	DetectChargedCurrencies(transactions, currency)
+	ApplyHistoricalRates(transactions, currency.Account, rates, metadata)
+	return FilterPayments(transactions)
 }
 
 // NormalizeDate ensures dates are in YYYY-MM-DD format (zero-padded).
@@ -142,7 +321,7 @@ func FilterPayments(transactions []models.NormalizedTransaction) []models.Normal
 	filtered := make([]models.NormalizedTransaction, 0, len(transactions))
 	for _, t := range transactions {
 		// Primary filter: negative USD amount means it's a credit/payment
-		if t.AmountInUSD < 0 || t.Amount < 0 {
+		if t.AmountInUSD.IsNegative() || t.Amount.IsNegative() {
 			continue
 		}
 		titleLower := strings.ToLower(t.Title)
@@ -161,6 +340,6 @@ func FilterPayments(transactions []models.NormalizedTransaction) []models.Normal
 }
 
 // FormatExchangeRate formats the exchange rate for display
-func FormatExchangeRate(rate float64) string {
-	return fmt.Sprintf("%.6f", rate)
+func FormatExchangeRate(rate decimal.Decimal) string {
+	return rate.StringFixed(6)
 }
@@ -1,22 +1,29 @@
 package processor
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"retrospend-sidecar/db"
 	"retrospend-sidecar/importer/models"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
 // ── ApplyExchangeRates ────────────────────────────────────────────────────────
 
 func TestApplyExchangeRates_WithForeignCurrency(t *testing.T) {
 	txs := []models.NormalizedTransaction{
 		{
 			Title:            "Coffee",
-			Amount:           10.00, // USD amount from bank statement
+			Amount:           dec("10.00"), // USD amount from bank statement
 			Currency:         "USD",
-			OriginalAmount:   14200, // ARS equivalent
+			OriginalAmount:   dec("14200"), // ARS equivalent
 			OriginalCurrency: "ARS",
 		},
 	}
@@ -27,16 +34,16 @@ func TestApplyExchangeRates_WithForeignCurrency(t *testing.T) {
 	if tx.Currency != "ARS" {
 		t.Errorf("expected Currency=ARS, got %s", tx.Currency)
 	}
-	if tx.Amount != 14200 {
-		t.Errorf("expected Amount=14200 (original), got %.2f", tx.Amount)
+	if !tx.Amount.Equal(dec("14200")) {
+		t.Errorf("expected Amount=14200 (original), got %s", tx.Amount)
 	}
-	if tx.AmountInUSD != 10.00 {
-		t.Errorf("expected AmountInUSD=10.00, got %.2f", tx.AmountInUSD)
+	if !tx.AmountInUSD.Equal(dec("10.00")) {
+		t.Errorf("expected AmountInUSD=10.00, got %s", tx.AmountInUSD)
 	}
 	// Rate = OriginalAmount / Amount = 14200 / 10 = 1420
-	wantRate := 14200.0 / 10.0
-	if tx.ExchangeRate != wantRate {
-		t.Errorf("expected ExchangeRate=%.2f, got %.2f", wantRate, tx.ExchangeRate)
+	wantRate := dec("1420")
+	if !tx.ExchangeRate.Equal(wantRate) {
+		t.Errorf("expected ExchangeRate=%s, got %s", wantRate, tx.ExchangeRate)
 	}
 	if tx.PricingSource != "IMPORTED" {
 		t.Errorf("expected PricingSource=IMPORTED, got %s", tx.PricingSource)
@@ -45,7 +52,7 @@ func TestApplyExchangeRates_WithForeignCurrency(t *testing.T) {
 
 func TestApplyExchangeRates_NoForeignFields(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Rent", Amount: 1500, Currency: ""},
+		{Title: "Rent", Amount: dec("1500"), Currency: ""},
 	}
 
 	ApplyExchangeRates(txs, "USD")
@@ -54,20 +61,20 @@ func TestApplyExchangeRates_NoForeignFields(t *testing.T) {
 	if tx.Currency != "USD" {
 		t.Errorf("expected Currency=USD (default), got %s", tx.Currency)
 	}
-	if tx.ExchangeRate != 1.0 {
-		t.Errorf("expected ExchangeRate=1.0, got %.6f", tx.ExchangeRate)
+	if !tx.ExchangeRate.Equal(dec("1.0")) {
+		t.Errorf("expected ExchangeRate=1.0, got %s", tx.ExchangeRate)
 	}
-	if tx.AmountInUSD != 1500 {
-		t.Errorf("expected AmountInUSD=1500, got %.2f", tx.AmountInUSD)
+	if !tx.AmountInUSD.Equal(dec("1500")) {
+		t.Errorf("expected AmountInUSD=1500, got %s", tx.AmountInUSD)
 	}
-	if tx.Amount != 1500 {
-		t.Errorf("expected Amount unchanged=1500, got %.2f", tx.Amount)
+	if !tx.Amount.Equal(dec("1500")) {
+		t.Errorf("expected Amount unchanged=1500, got %s", tx.Amount)
 	}
 }
 
 func TestApplyExchangeRates_EmptyDefaultCurrency(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Lunch", Amount: 20},
+		{Title: "Lunch", Amount: dec("20")},
 	}
 	// Empty default should fall back to "USD"
 	ApplyExchangeRates(txs, "")
@@ -76,21 +83,56 @@ func TestApplyExchangeRates_EmptyDefaultCurrency(t *testing.T) {
 	}
 }
 
+func TestApplyExchangeRates_KeepsParsedCurrency(t *testing.T) {
+	txs := []models.NormalizedTransaction{
+		{Title: "Padaria", Amount: dec("12.5"), Currency: "BRL"},
+	}
+	// A currency read from the statement must not be overwritten by the default
+	ApplyExchangeRates(txs, "USD")
+	if txs[0].Currency != "BRL" {
+		t.Errorf("expected Currency=BRL, got %s", txs[0].Currency)
+	}
+}
+
+func TestApplyExchangeRates_RoundsToCurrencyDigits(t *testing.T) {
+	txs := []models.NormalizedTransaction{
+		{Title: "Ramen", Amount: dec("1500.4"), Currency: "JPY"},
+		{Title: "Node fee", Amount: dec("0.123456789"), Currency: "BTC"}, // not in currency.json
+		{Title: "Lunch", Amount: dec("12.345"), Currency: "EUR"},
+	}
+	ApplyExchangeRates(txs, "USD")
+
+	for i, want := range []string{"1500", "0.12345679", "12.35"} {
+		if !txs[i].Amount.Equal(dec(want)) {
+			t.Errorf("%s: expected Amount=%s, got %s", txs[i].Title, want, txs[i].Amount)
+		}
+	}
+
+	// Money serializes as exact strings
+	data, err := json.Marshal(txs[1])
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"amount":"0.12345679"`) {
+		t.Errorf("expected the amount as a JSON string, got %s", data)
+	}
+}
+
 func TestApplyExchangeRates_ZeroAmountDivisionGuard(t *testing.T) {
 	// Amount=0 with a non-zero OriginalAmount: rate should default to 1.0
 	txs := []models.NormalizedTransaction{
-		{Title: "Zero", Amount: 0, OriginalAmount: 500, OriginalCurrency: "EUR"},
+		{Title: "Zero", Amount: dec("0"), OriginalAmount: dec("500"), OriginalCurrency: "EUR"},
 	}
 	ApplyExchangeRates(txs, "USD")
 
-	if txs[0].ExchangeRate != 1.0 {
-		t.Errorf("expected ExchangeRate=1.0 (division-by-zero guard), got %.6f", txs[0].ExchangeRate)
+	if !txs[0].ExchangeRate.Equal(dec("1.0")) {
+		t.Errorf("expected ExchangeRate=1.0 (division-by-zero guard), got %s", txs[0].ExchangeRate)
 	}
 }
 
 func TestApplyExchangeRates_SetsImportedPricingSource(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Coffee", Amount: 5},
+		{Title: "Coffee", Amount: dec("5")},
 	}
 	ApplyExchangeRates(txs, "USD")
 	if txs[0].PricingSource != "IMPORTED" {
@@ -98,6 +140,131 @@ func TestApplyExchangeRates_SetsImportedPricingSource(t *testing.T) {
 	}
 }
 
+// ── ApplyHistoricalRates ──────────────────────────────────────────────────────
+
+func fixedRates(rates map[string]db.ExchangeRate) RateLookup {
+	return RateLookup{
+		MaxDaysAway: 7,
+		Find: func(currency string, date time.Time) (db.ExchangeRate, bool) {
+			rate, ok := rates[currency]
+			return rate, ok
+		},
+	}
+}
+
+func TestApplyHistoricalRates_ConvertsAccountCurrency(t *testing.T) {
+	day := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	rates := fixedRates(map[string]db.ExchangeRate{"EUR": {Rate: dec("0.8"), Date: day}})
+	txs := []models.NormalizedTransaction{{Title: "Bakery", Amount: dec("10"), Date: "2024-06-15"}}
+	meta := &models.ImportMetadata{}
+
+	ApplyHistoricalRates(txs, "EUR", rates, meta)
+
+	if txs[0].Currency != "EUR" || !txs[0].AmountInUSD.Equal(dec("12.5")) || !txs[0].ExchangeRate.Equal(dec("0.8")) {
+		t.Errorf("expected 10 EUR = 12.50 USD at 0.8, got %s %s = %s USD at %s", txs[0].Amount, txs[0].Currency, txs[0].AmountInUSD, txs[0].ExchangeRate)
+	}
+	if len(meta.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", meta.Warnings)
+	}
+}
+
+func TestApplyHistoricalRates_ForeignPurchaseOnEURAccount(t *testing.T) {
+	day := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	rates := fixedRates(map[string]db.ExchangeRate{"EUR": {Rate: dec("0.8"), Date: day}})
+	txs := []models.NormalizedTransaction{
+		{Title: "Taxi", Amount: dec("8"), Date: "2024-06-15", OriginalAmount: dec("50"), OriginalCurrency: "BRL"},
+	}
+
+	ApplyHistoricalRates(txs, "EUR", rates, nil)
+
+	if txs[0].Currency != "BRL" || !txs[0].AmountInUSD.Equal(dec("10")) || !txs[0].ExchangeRate.Equal(dec("5")) {
+		t.Errorf("expected 50 BRL = 10 USD at 5, got %s %s = %s USD at %s", txs[0].Amount, txs[0].Currency, txs[0].AmountInUSD, txs[0].ExchangeRate)
+	}
+}
+
+func TestApplyHistoricalRates_CryptoRateIsUSDPerCoin(t *testing.T) {
+	day := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	rates := fixedRates(map[string]db.ExchangeRate{"BTC": {Rate: dec("60000"), Type: "crypto", Date: day}})
+	txs := []models.NormalizedTransaction{{Title: "Node", Amount: dec("0.001"), Currency: "BTC", Date: "2024-06-15"}}
+
+	ApplyHistoricalRates(txs, "USD", rates, nil)
+
+	if !txs[0].AmountInUSD.Equal(dec("60")) {
+		t.Errorf("expected 0.001 BTC = 60 USD, got %s", txs[0].AmountInUSD)
+	}
+}
+
+func TestApplyHistoricalRates_WarnsOnDistantAndMissingRates(t *testing.T) {
+	rates := fixedRates(map[string]db.ExchangeRate{"EUR": {Rate: dec("0.8"), Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}})
+	txs := []models.NormalizedTransaction{
+		{Title: "A", Amount: dec("10"), Currency: "EUR", Date: "2024-06-15"},
+		{Title: "B", Amount: dec("10"), Currency: "EUR", Date: "2024-06-16"},
+		{Title: "C", Amount: dec("10"), Currency: "GBP", Date: "2024-06-16"},
+	}
+	meta := &models.ImportMetadata{}
+
+	ApplyHistoricalRates(txs, "USD", rates, meta)
+
+	if len(meta.Warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", meta.Warnings)
+	}
+	if !strings.Contains(meta.Warnings[0], "GBP") || !strings.Contains(meta.Warnings[1], "2 EUR transaction(s)") || !strings.Contains(meta.Warnings[1], "167 days") {
+		t.Errorf("unexpected warnings: %v", meta.Warnings)
+	}
+	if !txs[2].AmountInUSD.Equal(dec("10")) {
+		t.Errorf("without a rate the amount should be kept, got %s", txs[2].AmountInUSD)
+	}
+}
+
+// ── PrepareTransactions ───────────────────────────────────────────────────────
+
+func TestPrepareTransactions_ChargedCurrencyOnCSVRows(t *testing.T) {
+	day := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	rates := fixedRates(map[string]db.ExchangeRate{"BRL": {Rate: dec("5"), Date: day}})
+	// As the CSV adapter leaves them: the account currency resolved from a
+	// currency column, the foreign charge only in the description
+	txs := []models.NormalizedTransaction{
+		{Title: "UBER TRIP R$49,96", Amount: dec("10"), Currency: "usd", Date: "2024-6-15", SourceRow: 2},
+		{Title: "COFFEE", Amount: dec("4.50"), Currency: "usd", Date: "2024-6-15", SourceRow: 3},
+		{Title: "REFUND R$20,00", Amount: dec("-4"), Currency: "usd", Date: "2024-6-15", SourceRow: 4},
+	}
+
+	got := PrepareTransactions(txs, "statement.csv", CurrencyContext{Account: "USD"}, rates, &models.ImportMetadata{})
+
+	if len(got) != 2 {
+		t.Fatalf("expected the refund filtered out, got %d transactions", len(got))
+	}
+	uber := got[0]
+	if uber.Currency != "BRL" || !uber.Amount.Equal(dec("49.96")) || !uber.AmountInUSD.Equal(dec("10")) || !uber.ExchangeRate.Equal(dec("4.996")) {
+		t.Errorf("expected 49.96 BRL = 10 USD at 4.996, got %s %s = %s USD at %s", uber.Amount, uber.Currency, uber.AmountInUSD, uber.ExchangeRate)
+	}
+	if uber.ImportID != "row-2" || uber.Date != "2024-06-15" {
+		t.Errorf("expected provenance and a normalized date, got %q and %q", uber.ImportID, uber.Date)
+	}
+	if coffee := got[1]; coffee.Currency != "USD" || !coffee.Amount.Equal(dec("4.50")) {
+		t.Errorf("expected the coffee to stay in USD, got %s %s", coffee.Amount, coffee.Currency)
+	}
+}
+
+func TestPrepareTransactions_ChargedCurrencyOnPDFLines(t *testing.T) {
+	day := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	rates := fixedRates(map[string]db.ExchangeRate{"EUR": {Rate: dec("0.8"), Date: day}})
+	// As the PDF parser leaves them: no currency, the account's from the request
+	txs := []models.NormalizedTransaction{
+		{Title: "STEAM US$ 5.00", Amount: dec("4"), Date: "2024-06-15", SourcePage: 1, SourceLineStart: 12},
+		{Title: "CAFE €3,00", Amount: dec("3"), Date: "2024-06-15", SourcePage: 1, SourceLineStart: 13},
+	}
+
+	got := PrepareTransactions(txs, "statement.pdf", CurrencyContext{Account: "EUR"}, rates, nil)
+
+	if steam := got[0]; steam.Currency != "USD" || steam.OriginalCurrency != "USD" || !steam.AmountInUSD.Equal(dec("5")) {
+		t.Errorf("expected a 5 USD charge, got %s %s = %s USD", steam.Amount, steam.Currency, steam.AmountInUSD)
+	}
+	if cafe := got[1]; cafe.Currency != "EUR" || cafe.OriginalCurrency != "" || !cafe.AmountInUSD.Equal(dec("3.75")) {
+		t.Errorf("expected a charge in the account currency to stay EUR, got %s %s (original %q)", cafe.Amount, cafe.Currency, cafe.OriginalCurrency)
+	}
+}
+
 // ── ValidateTransaction ───────────────────────────────────────────────────────
 
 func TestValidateTransaction_EmptyTitle(t *testing.T) {
@@ -114,7 +281,7 @@ func TestValidateTransaction_EmptyTitle(t *testing.T) {
 
 func TestValidateTransaction_ZeroAmount(t *testing.T) {
 	tx := validTx()
-	tx.Amount = 0
+	tx.Amount = dec("0")
 	err := ValidateTransaction(tx)
 	if err == nil {
 		t.Fatal("expected error for zero amount")
@@ -123,7 +290,7 @@ func TestValidateTransaction_ZeroAmount(t *testing.T) {
 
 func TestValidateTransaction_NegativeAmount(t *testing.T) {
 	tx := validTx()
-	tx.Amount = -10
+	tx.Amount = dec("-10")
 	err := ValidateTransaction(tx)
 	if err == nil {
 		t.Fatal("expected error for negative amount")
@@ -132,7 +299,7 @@ func TestValidateTransaction_NegativeAmount(t *testing.T) {
 
 func TestValidateTransaction_AmountExceedsMax(t *testing.T) {
 	tx := validTx()
-	tx.Amount = 1_000_001
+	tx.Amount = dec("1000001")
 	err := ValidateTransaction(tx)
 	if err == nil {
 		t.Fatal("expected error for amount > 1,000,000")
@@ -141,7 +308,7 @@ func TestValidateTransaction_AmountExceedsMax(t *testing.T) {
 
 func TestValidateTransaction_AmountAtMaxIsValid(t *testing.T) {
 	tx := validTx()
-	tx.Amount = 1_000_000
+	tx.Amount = dec("1000000")
 	if err := ValidateTransaction(tx); err != nil {
 		t.Errorf("expected no error for amount=1,000,000, got: %v", err)
 	}
@@ -156,12 +323,75 @@ func TestValidateTransaction_CurrencyTooShort(t *testing.T) {
 	}
 }
 
-func TestValidateTransaction_CurrencyTooLong(t *testing.T) {
+func TestValidateTransaction_UnknownCurrency(t *testing.T) {
 	tx := validTx()
-	tx.Currency = "USDT"
+	tx.Currency = "XYZ"
 	err := ValidateTransaction(tx)
-	if err == nil {
-		t.Fatal("expected error for 4-letter currency")
+	var verr ValidationError
+	if !errors.As(err, &verr) || verr.Code != CodeUnknownCurrency {
+		t.Fatalf("expected %s for XYZ, got %v", CodeUnknownCurrency, err)
+	}
+}
+
+func TestValidateTransaction_CryptoCurrency(t *testing.T) {
+	tx := validTx()
+	tx.Currency = "USDT"
+	if err := ValidateTransaction(tx); err != nil {
+		t.Errorf("expected USDT to be accepted, got: %v", err)
+	}
+}
+
+func TestValidateTransaction_ExtraCurrency(t *testing.T) {
+	tx := validTx()
+	tx.Currency = "XYZ"
+	rules := DefaultValidationRules()
+	rules.ExtraCurrencies = []string{"XYZ"}
+	if err := rules.Validate(tx); err != nil {
+		t.Errorf("expected an extra currency to be accepted, got: %v", err)
+	}
+}
+
+func TestValidateTransaction_LimitInUSDEquivalent(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.UnitsPerUSD = map[string]decimal.Decimal{"ARS": dec("1200"), "VND": dec("25000")}
+
+	// 5,000,000 ARS is about 4,167 USD
+	tx := validTx()
+	tx.Currency = "ARS"
+	tx.Amount = dec("5000000")
+	if err := rules.Validate(tx); err != nil {
+		t.Errorf("expected a large ARS amount to pass, got: %v", err)
+	}
+
+	// 30 billion VND is about 1.2 million USD
+	tx.Currency = "VND"
+	tx.Amount = dec("30000000000")
+	err := rules.Validate(tx)
+	var verr ValidationError
+	if !errors.As(err, &verr) || verr.Code != CodeAmountExceedsLimit {
+		t.Fatalf("expected %s, got %v", CodeAmountExceedsLimit, err)
+	}
+}
+
+func TestValidateTransaction_LimitUsesStatementRate(t *testing.T) {
+	// No table rate: the converted amount from the statement is used
+	tx := validTx()
+	tx.Currency = "COP"
+	tx.Amount = dec("8000000")
+	tx.AmountInUSD = dec("2000")
+	tx.ExchangeRate = dec("4000")
+	if err := ValidateTransaction(tx); err != nil {
+		t.Errorf("expected the statement's USD amount to be checked, got: %v", err)
+	}
+}
+
+func TestValidateTransaction_ConfiguredLimit(t *testing.T) {
+	rules := DefaultValidationRules()
+	rules.MaxAmountUSD = dec("500")
+	tx := validTx()
+	tx.Amount = dec("501")
+	if err := rules.Validate(tx); err == nil {
+		t.Fatal("expected error for amount over the configured limit")
 	}
 }
 
@@ -241,12 +471,12 @@ func TestValidateTransaction_ValidTransaction(t *testing.T) {
 func TestValidateTransactions_FiltersInvalidSkipsWithWarnings(t *testing.T) {
 	txs := []models.NormalizedTransaction{
 		validTx(),
-		{Title: "", Amount: 50, Currency: "USD", Date: "2024-01-01"}, // invalid: empty title
+		{Title: "", Amount: dec("50"), Currency: "USD", Date: "2024-01-01"}, // invalid: empty title
 		validTx(),
 	}
 	meta := &models.ImportMetadata{}
 
-	valid := ValidateTransactions(txs, meta)
+	valid := ValidateTransactions(txs, meta, DefaultValidationRules())
 
 	if len(valid) != 2 {
 		t.Errorf("expected 2 valid transactions, got %d", len(valid))
@@ -257,12 +487,15 @@ func TestValidateTransactions_FiltersInvalidSkipsWithWarnings(t *testing.T) {
 	if len(meta.Warnings) != 1 {
 		t.Errorf("expected 1 warning, got %d", len(meta.Warnings))
 	}
+	if len(meta.ValidationFailures) != 1 || meta.ValidationFailures[0].Code != CodeEmptyTitle || meta.ValidationFailures[0].Transaction != 2 {
+		t.Errorf("expected an %s failure for transaction 2, got %+v", CodeEmptyTitle, meta.ValidationFailures)
+	}
 }
 
 func TestValidateTransactions_AllValid(t *testing.T) {
 	txs := []models.NormalizedTransaction{validTx(), validTx()}
 	meta := &models.ImportMetadata{}
-	valid := ValidateTransactions(txs, meta)
+	valid := ValidateTransactions(txs, meta, DefaultValidationRules())
 	if len(valid) != 2 {
 		t.Errorf("expected 2 valid, got %d", len(valid))
 	}
@@ -273,7 +506,7 @@ func TestValidateTransactions_AllValid(t *testing.T) {
 
 func TestValidateTransactions_EmptyInput(t *testing.T) {
 	meta := &models.ImportMetadata{}
-	valid := ValidateTransactions([]models.NormalizedTransaction{}, meta)
+	valid := ValidateTransactions([]models.NormalizedTransaction{}, meta, DefaultValidationRules())
 	if len(valid) != 0 {
 		t.Errorf("expected empty result, got %d", len(valid))
 	}
@@ -283,8 +516,8 @@ func TestValidateTransactions_EmptyInput(t *testing.T) {
 
 func TestFilterPayments_RemovesNegativeAmountInUSD(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Payment", Amount: 167.97, AmountInUSD: -167.97, Currency: "USD", Date: "2024-06-01"},
-		{Title: "Coffee", Amount: 5.00, AmountInUSD: 5.00, Currency: "USD", Date: "2024-06-02"},
+		{Title: "Payment", Amount: dec("167.97"), AmountInUSD: dec("-167.97"), Currency: "USD", Date: "2024-06-01"},
+		{Title: "Coffee", Amount: dec("5.00"), AmountInUSD: dec("5.00"), Currency: "USD", Date: "2024-06-02"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 1 {
@@ -297,7 +530,7 @@ func TestFilterPayments_RemovesNegativeAmountInUSD(t *testing.T) {
 
 func TestFilterPayments_RemovesNegativeAmount(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Refund", Amount: -20.00, AmountInUSD: -20.00, Currency: "USD", Date: "2024-06-01"},
+		{Title: "Refund", Amount: dec("-20.00"), AmountInUSD: dec("-20.00"), Currency: "USD", Date: "2024-06-01"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 0 {
@@ -307,8 +540,8 @@ func TestFilterPayments_RemovesNegativeAmount(t *testing.T) {
 
 func TestFilterPayments_RemovesAutopay(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "AUTOPAY CREDIT CARD", Amount: 500, AmountInUSD: 500, Currency: "USD", Date: "2024-06-01"},
-		{Title: "Groceries", Amount: 80, AmountInUSD: 80, Currency: "USD", Date: "2024-06-02"},
+		{Title: "AUTOPAY CREDIT CARD", Amount: dec("500"), AmountInUSD: dec("500"), Currency: "USD", Date: "2024-06-01"},
+		{Title: "Groceries", Amount: dec("80"), AmountInUSD: dec("80"), Currency: "USD", Date: "2024-06-02"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 1 || result[0].Title != "Groceries" {
@@ -318,7 +551,7 @@ func TestFilterPayments_RemovesAutopay(t *testing.T) {
 
 func TestFilterPayments_RemovesMobilePymt(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Mobile Pymt Thank You", Amount: 300, AmountInUSD: 300, Currency: "USD", Date: "2024-06-01"},
+		{Title: "Mobile Pymt Thank You", Amount: dec("300"), AmountInUSD: dec("300"), Currency: "USD", Date: "2024-06-01"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 0 {
@@ -329,7 +562,7 @@ func TestFilterPayments_RemovesMobilePymt(t *testing.T) {
 func TestFilterPayments_DoesNotRemovePaymentWordAlone(t *testing.T) {
 	// "payment" alone should NOT be filtered — only exact keywords like "autopay", "mobile pymt"
 	txs := []models.NormalizedTransaction{
-		{Title: "Uber Payment", Amount: 25, AmountInUSD: 25, Currency: "USD", Date: "2024-06-01"},
+		{Title: "Uber Payment", Amount: dec("25"), AmountInUSD: dec("25"), Currency: "USD", Date: "2024-06-01"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 1 {
@@ -339,7 +572,7 @@ func TestFilterPayments_DoesNotRemovePaymentWordAlone(t *testing.T) {
 
 func TestFilterPayments_RemovesThankYou(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Thank You For Your Payment", Amount: 200, AmountInUSD: 200, Currency: "USD", Date: "2024-06-01"},
+		{Title: "Thank You For Your Payment", Amount: dec("200"), AmountInUSD: dec("200"), Currency: "USD", Date: "2024-06-01"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 0 {
@@ -349,8 +582,8 @@ func TestFilterPayments_RemovesThankYou(t *testing.T) {
 
 func TestFilterPayments_KeepsNormalPositiveExpense(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Amazon Purchase", Amount: 49.99, AmountInUSD: 49.99, Currency: "USD", Date: "2024-06-01"},
-		{Title: "Netflix", Amount: 15.99, AmountInUSD: 15.99, Currency: "USD", Date: "2024-06-02"},
+		{Title: "Amazon Purchase", Amount: dec("49.99"), AmountInUSD: dec("49.99"), Currency: "USD", Date: "2024-06-01"},
+		{Title: "Netflix", Amount: dec("15.99"), AmountInUSD: dec("15.99"), Currency: "USD", Date: "2024-06-02"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 2 {
@@ -367,7 +600,7 @@ func TestFilterPayments_EmptyInput(t *testing.T) {
 
 func TestFilterPayments_CaseInsensitiveKeywordMatch(t *testing.T) {
 	txs := []models.NormalizedTransaction{
-		{Title: "Online Pymt Reference 12345", Amount: 100, AmountInUSD: 100, Currency: "USD", Date: "2024-06-01"},
+		{Title: "Online Pymt Reference 12345", Amount: dec("100"), AmountInUSD: dec("100"), Currency: "USD", Date: "2024-06-01"},
 	}
 	result := FilterPayments(txs)
 	if len(result) != 0 {
@@ -454,7 +687,7 @@ func TestNormalizeDate_ProcessesMultipleTransactions(t *testing.T) {
 func validTx() models.NormalizedTransaction {
 	return models.NormalizedTransaction{
 		Title:    fmt.Sprintf("Test Expense %d", time.Now().UnixNano()),
-		Amount:   42.50,
+		Amount:   dec("42.50"),
 		Currency: "USD",
 		Date:     "2024-06-15",
 	}
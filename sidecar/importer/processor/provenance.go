@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// AssignProvenance stamps each transaction with the uploaded file name and a
+// stable import-local ID derived from its source position ("row-12" for CSV
+// rows, "p2-l40" for PDF lines), so the review UI and warnings can point back
+// to the original data. It must run before enrichment so RawText is captured
+// before titles are cleaned up.
+func AssignProvenance(transactions []models.NormalizedTransaction, sourceFile string) {
+	sourceFile = filepath.Base(sourceFile)
+	if sourceFile == "." {
+		sourceFile = ""
+	}
+
+	seen := make(map[string]int)
+	for i := range transactions {
+		tx := &transactions[i]
+		tx.SourceFile = sourceFile
+
+		var id string
+		switch {
+		case tx.SourceRow > 0:
+			id = fmt.Sprintf("row-%d", tx.SourceRow)
+		case tx.SourceLineStart > 0:
+			id = fmt.Sprintf("p%d-l%d", tx.SourcePage, tx.SourceLineStart)
+		default:
+			id = fmt.Sprintf("tx-%d", i+1)
+		}
+		// Keep IDs unique when a model reports two transactions on one line
+		seen[id]++
+		if seen[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, seen[id])
+		}
+		tx.ImportID = id
+
+		if tx.RawText == "" {
+			// No source text available: keep the title as first parsed
+			tx.RawText = strings.TrimSpace(tx.Title)
+		}
+	}
+}
+
+// sourceSuffix formats a transaction's source for warnings, e.g. " (row 12)".
+func sourceSuffix(tx models.NormalizedTransaction) string {
+	if ref := tx.SourceRef(); ref != "" {
+		return " (" + ref + ")"
+	}
+	return ""
+}
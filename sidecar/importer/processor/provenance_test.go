@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"retrospend-sidecar/importer/models"
+)
+
+func TestAssignProvenance_StableUniqueIDs(t *testing.T) {
+	txs := []models.NormalizedTransaction{
+		{Title: "Coffee", SourceRow: 12, RawText: "01/02/2025,COFFEE,-4.50"},
+		{Title: "Uber", SourcePage: 2, SourceLineStart: 40, SourceLineEnd: 43},
+		{Title: "Uber again", SourcePage: 2, SourceLineStart: 40, SourceLineEnd: 43},
+		{Title: "  Unknown  "},
+	}
+
+	AssignProvenance(txs, "/tmp/uploads/statement.csv")
+
+	wantIDs := []string{"row-12", "p2-l40", "p2-l40-2", "tx-4"}
+	for i, want := range wantIDs {
+		if txs[i].ImportID != want {
+			t.Errorf("tx %d: ImportID = %q; expected %q", i, txs[i].ImportID, want)
+		}
+		if txs[i].SourceFile != "statement.csv" {
+			t.Errorf("tx %d: SourceFile = %q; expected the base name", i, txs[i].SourceFile)
+		}
+	}
+	if txs[0].RawText != "01/02/2025,COFFEE,-4.50" {
+		t.Errorf("existing raw text should be kept, got %q", txs[0].RawText)
+	}
+	if txs[3].RawText != "Unknown" {
+		t.Errorf("raw text should fall back to the parsed title, got %q", txs[3].RawText)
+	}
+}
+
+func TestValidateTransactions_WarningReferencesSource(t *testing.T) {
+	meta := &models.ImportMetadata{}
+	ValidateTransactions([]models.NormalizedTransaction{{Title: "", Amount: dec("5"), Currency: "USD", Date: "2024-01-01", SourceRow: 7}}, meta, DefaultValidationRules())
+
+	if len(meta.Warnings) != 1 || !strings.Contains(meta.Warnings[0], "(row 7)") {
+		t.Errorf("expected the warning to reference row 7, got %v", meta.Warnings)
+	}
+}
@@ -0,0 +1,333 @@
+package processor
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+
+	"retrospend-sidecar/importer/models"
+)
+
+// CurrencyContext tells DetectCurrency how to read a symbol shared by several
+// currencies, such as "$" or "kr".
+type CurrencyContext struct {
+	Account string // The account's currency: "$" on an ARS account is pesos
+	Locale  string // BCP 47 tag of the user or statement, e.g. "es-AR" or "en-CA"
+}
+
+// symbols maps each symbol in currency.json ("R$", "€", "$") to the
+// currencies using it, as symbol or symbol_native.
+var symbols = map[string][]string{}
+
+// symbolAliases are common notations currency.json does not list.
+var symbolAliases = map[string]string{
+	"US$": "USD", "U$S": "USD", "U$D": "USD", "USD$": "USD",
+	"¥": "CNY", "￥": "CNY", "RMB": "CNY", "元": "CNY",
+	"£": "GBP",
+}
+
+// symbolDefaults picks a currency for a shared symbol when neither the
+// account nor the locale settles it.
+var symbolDefaults = map[string]string{
+	"$":  "USD",
+	"¥":  "JPY",
+	"￥":  "JPY",
+	"kr": "SEK",
+	"Br": "BYN",
+}
+
+// addSymbols records the symbols of a currency from currency.json.
+func addSymbols(c CurrencyData) {
+	for _, s := range []string{c.Symbol, c.SymbolNative} {
+		s = strings.TrimSpace(s)
+		if s == "" || s == c.Code || slices.Contains(symbols[s], c.Code) {
+			continue
+		}
+		symbols[s] = append(symbols[s], c.Code)
+	}
+}
+
+// Runs after currency.go's init has added the symbols from currency.json.
+func init() {
+	for symbol, code := range symbolAliases {
+		if !slices.Contains(symbols[symbol], code) {
+			symbols[symbol] = append(symbols[symbol], code)
+		}
+	}
+	for _, codes := range symbols {
+		slices.Sort(codes)
+	}
+}
+
+// DetectCurrency returns the currency an amount is written in, from a symbol
+// ("€12.50", "R$30", "US$5", "¥1200") or a code ("12,50 EUR"). ok is false
+// when the amount names no currency.
+func DetectCurrency(amount string, ctx CurrencyContext) (code string, ok bool) {
+	for _, marker := range currencyMarkers(amount) {
+		if code, ok := ctx.resolve(marker); ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// ResolveCurrency maps a currency name, code or symbol to its ISO code:
+// "Brazilian Real", "brl" and "R$" all give "BRL". Unrecognized values are
+// returned upper-cased, as by NormalizeCurrency.
+func ResolveCurrency(raw string, ctx CurrencyContext) string {
+	if code := NormalizeCurrency(raw); code == "" || IsKnownCurrency(code) {
+		return code
+	}
+	if code, ok := DetectCurrency(raw, ctx); ok {
+		return code
+	}
+	return NormalizeCurrency(raw)
+}
+
+// ResolveCurrencies rewrites each transaction's Currency and OriginalCurrency
+// as ISO codes, see ResolveCurrency.
+func ResolveCurrencies(transactions []models.NormalizedTransaction, ctx CurrencyContext) {
+	for i := range transactions {
+		transactions[i].Currency = ResolveCurrency(transactions[i].Currency, ctx)
+		transactions[i].OriginalCurrency = ResolveCurrency(transactions[i].OriginalCurrency, ctx)
+	}
+}
+
+// StripCurrencyAmounts removes amounts written with a currency ("R$30",
+// "12,50 EUR", "US$ 5") from free text such as a merchant line, so the same
+// merchant reads alike whatever it charged. Returns the remaining text and
+// the currency of the first amount removed, or "" when there was none.
+func StripCurrencyAmounts(text string, ctx CurrencyContext) (string, string) {
+	kept, charged, ok := stripCurrencyAmounts(text, ctx)
+	if !ok {
+		return kept, ""
+	}
+	return kept, charged.Currency
+}
+
+// chargedAmount is an amount written with its currency in free text.
+type chargedAmount struct {
+	Currency string
+	Number   string // As written, without the currency: "49,96"
+}
+
+// stripCurrencyAmounts is StripCurrencyAmounts, returning the first amount
+// removed; ok is false when there was none.
+func stripCurrencyAmounts(text string, ctx CurrencyContext) (string, chargedAmount, bool) {
+	fields := strings.Fields(text)
+	kept := make([]string, 0, len(fields))
+	var first chargedAmount
+	found := false
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		code, number, ok := "", "", false
+		switch {
+		case isNumeric(f):
+			// "12,50 EUR"
+			if i+1 < len(fields) && isAmountMarker(fields[i+1], f) {
+				if code, ok = ctx.resolve(fields[i+1]); ok {
+					number = f
+					i++
+				}
+			}
+		case hasDigit(f) && len(currencyMarkers(f)) == 1:
+			// "R$30", "12.50€"
+			code, ok = DetectCurrency(f, ctx)
+			number = strings.Map(keepNumber, f)
+		case i+1 < len(fields) && isNumeric(fields[i+1]) && isAmountMarker(f, fields[i+1]):
+			// "US$ 5", "EUR 12.50"
+			if code, ok = ctx.resolve(f); ok {
+				number = fields[i+1]
+				i++
+			}
+		}
+		if !ok {
+			kept = append(kept, f)
+			continue
+		}
+		if !found {
+			first, found = chargedAmount{Currency: code, Number: number}, true
+		}
+	}
+	return strings.Join(kept, " "), first, found
+}
+
+// DetectChargedCurrencies sets OriginalCurrency and OriginalAmount of the
+// transactions whose title or location names an amount in a currency other
+// than the account's, such as "UBER TRIP R$49,96" on a USD account, when the
+// source gave no original amount. It must run after ResolveCurrencies and
+// before ApplyHistoricalRates, which prices the transaction in the original
+// currency. Only charges are considered; payments and credits are filtered
+// out later.
+func DetectChargedCurrencies(transactions []models.NormalizedTransaction, ctx CurrencyContext) {
+	for i := range transactions {
+		tx := &transactions[i]
+		if tx.OriginalCurrency != "" || !tx.Amount.IsPositive() {
+			continue
+		}
+		account := tx.Currency
+		if account == "" {
+			account = ctx.Account
+		}
+		if account == "" {
+			account = "USD"
+		}
+		txCtx := ctx
+		txCtx.Account = account
+		_, charged, ok := stripCurrencyAmounts(strings.TrimSpace(tx.Title+" "+tx.Location), txCtx)
+		if !ok || charged.Currency == account {
+			continue
+		}
+		amount, ok := parseChargedNumber(charged.Number)
+		if !ok || !amount.IsPositive() {
+			continue
+		}
+		tx.OriginalCurrency = charged.Currency
+		tx.OriginalAmount = amount
+	}
+}
+
+// parseChargedNumber reads a number written in free text in either notation:
+// the last "." or "," is the decimal separator unless exactly three digits
+// follow it, as in "1,234" or "1.234".
+func parseChargedNumber(number string) (decimal.Decimal, bool) {
+	number = strings.Trim(number, "+-−() ")
+	sep := strings.LastIndexAny(number, ".,")
+	intPart, fraction := number, ""
+	if sep >= 0 && len(number)-sep-1 != 3 {
+		intPart, fraction = number[:sep], number[sep+1:]
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r == '.' || r == ',' || r == '\'' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, intPart)
+	if fraction != "" {
+		digits += "." + fraction
+	}
+	amount, err := decimal.NewFromString(digits)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return amount, true
+}
+
+// keepNumber keeps the digits and separators of an amount, for use with
+// strings.Map.
+func keepNumber(r rune) rune {
+	if unicode.IsDigit(r) || r == '.' || r == ',' {
+		return r
+	}
+	return -1
+}
+
+// currencyMarkers splits what remains of an amount once digits, separators
+// and sign notation are removed: "-R$1.234,56" gives ["R$"], "12,50 EUR CR"
+// gives ["EUR"].
+func currencyMarkers(amount string) []string {
+	var markers []string
+	for _, m := range strings.Fields(strings.Map(func(r rune) rune {
+		if dropMarker(r) == -1 {
+			return ' '
+		}
+		return r
+	}, amount)) {
+		if u := strings.ToUpper(strings.TrimSuffix(m, ".")); u == "CR" || u == "DR" {
+			continue
+		}
+		markers = append(markers, m)
+	}
+	return markers
+}
+
+// dropMarker drops the runes of an amount that are not part of a currency
+// marker, for use with strings.Map.
+func dropMarker(r rune) rune {
+	switch {
+	case unicode.IsDigit(r), unicode.IsSpace(r), strings.ContainsRune(".,'+-−()", r):
+		return -1
+	}
+	return r
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+// isNumeric reports whether s is a bare number such as "1.234,56" or "-30".
+func isNumeric(s string) bool {
+	return hasDigit(s) && strings.Map(dropMarker, s) == ""
+}
+
+// isAmountMarker reports whether marker, written apart from number, reads
+// as its currency. A bare code needs a decimal amount, so "TOP 10" or
+// "ALL 24" in a merchant name are left alone.
+func isAmountMarker(marker string, number string) bool {
+	if hasDigit(marker) {
+		return false
+	}
+	return !isCode(strings.ToUpper(marker)) || strings.ContainsAny(number, ".,")
+}
+
+// resolve reads one marker as a currency: an ISO code, a symbol used by a
+// single currency, or a shared symbol settled by the account currency, then
+// the locale's region, then symbolDefaults.
+func (ctx CurrencyContext) resolve(marker string) (string, bool) {
+	if code := strings.ToUpper(marker); isCode(code) && IsKnownCurrency(code) {
+		return code, true
+	}
+	candidates := symbols[marker]
+	if len(candidates) == 0 {
+		return "", false
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	if account := strings.ToUpper(ctx.Account); slices.Contains(candidates, account) {
+		return account, true
+	}
+	if local := ctx.localCurrency(); local != "" && slices.Contains(candidates, local) {
+		return local, true
+	}
+	if code, ok := symbolDefaults[marker]; ok {
+		return code, true
+	}
+	return candidates[0], true
+}
+
+// localCurrency is the currency of the locale's region, if it has one.
+func (ctx CurrencyContext) localCurrency() string {
+	if ctx.Locale == "" {
+		return ""
+	}
+	tag, err := language.Parse(ctx.Locale)
+	if err != nil {
+		return ""
+	}
+	region, confidence := tag.Region()
+	if confidence == language.No {
+		return ""
+	}
+	unit, ok := currency.FromRegion(region)
+	if !ok {
+		return ""
+	}
+	return unit.String()
+}
+
+func isCode(s string) bool {
+	if len(s) < 3 || len(s) > 5 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
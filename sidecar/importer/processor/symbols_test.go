@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"retrospend-sidecar/importer/models"
+	"testing"
+)
+
+// ── DetectCurrency ────────────────────────────────────────────────────────────
+
+func TestDetectCurrency_SymbolsAndCodes(t *testing.T) {
+	cases := map[string]string{
+		"€12.50":       "EUR",
+		"R$30":         "BRL",
+		"-R$ 1.234,56": "BRL",
+		"US$5":         "USD",
+		"12,50 EUR":    "EUR",
+		"¥1200":        "JPY",
+		"£8.99":        "GBP",
+		"CA$20.00":     "CAD",
+		"0.01 BTC":     "BTC",
+		"(€45.00)":     "EUR",
+		"45.00 CR":     "",
+		"1,234.56":     "",
+	}
+	for input, want := range cases {
+		got, ok := DetectCurrency(input, CurrencyContext{})
+		if ok != (want != "") || got != want {
+			t.Errorf("DetectCurrency(%q) = %q, %t; want %q", input, got, ok, want)
+		}
+	}
+}
+
+func TestDetectCurrency_DollarFollowsAccount(t *testing.T) {
+	got, _ := DetectCurrency("$16,322.00", CurrencyContext{Account: "ARS"})
+	if got != "ARS" {
+		t.Errorf("expected $ on an ARS account to be ARS, got %q", got)
+	}
+}
+
+func TestDetectCurrency_DollarFollowsLocale(t *testing.T) {
+	got, _ := DetectCurrency("$20.00", CurrencyContext{Account: "EUR", Locale: "en-CA"})
+	if got != "CAD" {
+		t.Errorf("expected $ in en-CA to be CAD, got %q", got)
+	}
+}
+
+func TestDetectCurrency_DollarDefaultsToUSD(t *testing.T) {
+	got, _ := DetectCurrency("$20.00", CurrencyContext{Locale: "fr-FR"})
+	if got != "USD" {
+		t.Errorf("expected $ without context to be USD, got %q", got)
+	}
+}
+
+// ── ResolveCurrency ───────────────────────────────────────────────────────────
+
+func TestResolveCurrency(t *testing.T) {
+	cases := map[string]string{
+		"Brazilian Real": "BRL",
+		"brl":            "BRL",
+		"R$":             "BRL",
+		"€":              "EUR",
+		"":               "",
+		"points":         "POINTS",
+	}
+	for input, want := range cases {
+		if got := ResolveCurrency(input, CurrencyContext{}); got != want {
+			t.Errorf("ResolveCurrency(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestResolveCurrencies(t *testing.T) {
+	txs := []models.NormalizedTransaction{{Currency: "$", OriginalCurrency: "R$"}}
+	ResolveCurrencies(txs, CurrencyContext{Account: "MXN"})
+	if txs[0].Currency != "MXN" || txs[0].OriginalCurrency != "BRL" {
+		t.Errorf("expected MXN/BRL, got %s/%s", txs[0].Currency, txs[0].OriginalCurrency)
+	}
+}
+
+// ── StripCurrencyAmounts ──────────────────────────────────────────────────────
+
+func TestStripCurrencyAmounts(t *testing.T) {
+	cases := []struct {
+		input, text, currency string
+	}{
+		{"UBER TRIP R$49,96", "UBER TRIP", "BRL"},
+		{"AMAZON EU 12,50 EUR", "AMAZON EU", "EUR"},
+		{"STEAM US$ 5.00", "STEAM", "USD"},
+		{"TOP 10 RECORDS", "TOP 10 RECORDS", ""},
+		{"7-ELEVEN 1234", "7-ELEVEN 1234", ""},
+	}
+	for _, c := range cases {
+		text, currency := StripCurrencyAmounts(c.input, CurrencyContext{})
+		if text != c.text || currency != c.currency {
+			t.Errorf("StripCurrencyAmounts(%q) = %q, %q; want %q, %q", c.input, text, currency, c.text, c.currency)
+		}
+	}
+}
+
+func TestParseChargedNumber(t *testing.T) {
+	cases := map[string]string{
+		"49,96":    "49.96",
+		"5.00":     "5",
+		"1.234,56": "1234.56",
+		"1,234.56": "1234.56",
+		"1,234":    "1234",
+		"1200":     "1200",
+	}
+	for input, want := range cases {
+		got, ok := parseChargedNumber(input)
+		if !ok || !got.Equal(dec(want)) {
+			t.Errorf("parseChargedNumber(%q) = %s, %v; want %s", input, got, ok, want)
+		}
+	}
+}
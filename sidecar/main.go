@@ -3,8 +3,8 @@ package main
 import (
 	"context"
 	"crypto/subtle"
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -26,6 +26,8 @@ import (
 	"retrospend-sidecar/tasks"
 
 	"github.com/robfig/cron/v3"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
 )
 
 var Version = "0.2.0"
@@ -45,12 +47,30 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	rateSources, err := tasks.NewRateSources(cfg.RateSources, tasks.RateSourceOptions{
+		OracleURL:    cfg.RateOracleURL,
+		ECBURL:       cfg.RateECBURL,
+		JSONLocation: cfg.RateJSONLocation,
+		JSONMapping:  cfg.RateJSONMapping,
+		FileDir:      cfg.RateFileDir,
+	})
+	if err != nil {
+		log.Fatalf("Invalid exchange rate sources: %v", err)
+	}
+
+	rateThresholds := tasks.ParseAnomalyThresholds(cfg.RateMaxChange)
+
+	// `sidecar backfill-rates ...` runs a backfill and exits
+	if len(os.Args) > 1 && os.Args[1] == "backfill-rates" {
+		os.Exit(runBackfillCommand(database, rateSources, os.Args[2:]))
+	}
+
 	// Initialize cron scheduler
 	c := cron.New(cron.WithLogger(cron.VerbosePrintfLogger(log.New(os.Stdout, "[CRON] ", log.LstdFlags))))
 
 	// Schedule exchange rate sync: daily at 09:05 UTC
 	_, err = c.AddFunc("5 9 * * *", func() {
-		if err := tasks.SyncExchangeRates(database); err != nil {
+		if err := tasks.SyncExchangeRates(database, rateSources, rateThresholds, cfg.RateSafeDelete); err != nil {
 			log.Printf("❌ Exchange rate sync failed: %v", err)
 		}
 	})
@@ -60,7 +80,7 @@ func main() {
 
 	// Schedule recurring expense processing: every 15 minutes
 	_, err = c.AddFunc("*/15 * * * *", func() {
-		if err := tasks.ProcessRecurringExpenses(database); err != nil {
+		if err := tasks.ProcessRecurringExpenses(database, cfg.RecurringMaxCatchup); err != nil {
 			log.Printf("❌ Recurring expense processing failed: %v", err)
 		}
 	})
@@ -80,7 +100,7 @@ func main() {
 
 	// Schedule data retention cleanup: daily at 03:00 UTC
 	_, err = c.AddFunc("0 3 * * *", func() {
-		report, err := tasks.RunDataRetentionCleanup(database)
+		report, err := tasks.RunDataRetentionCleanup(database, cfg.RateRetentionDays)
 		if err != nil {
 			log.Printf("❌ Data retention cleanup failed: %v", err)
 			return
@@ -139,7 +159,7 @@ func main() {
 		}
 
 		log.Println("[HTTP] Manual sync triggered")
-		if err := tasks.SyncExchangeRates(database); err != nil {
+		if err := tasks.SyncExchangeRates(database, rateSources, rateThresholds, cfg.RateSafeDelete); err != nil {
 			log.Printf("[HTTP] Sync failed: %v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -149,6 +169,148 @@ func main() {
 		w.Write([]byte("Sync successful"))
 	}))
 
+	mux.HandleFunc("/rates/backfill", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body struct {
+			From       string   `json:"from"`
+			To         string   `json:"to"`
+			Currencies []string `json:"currencies"`
+		}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		req, err := tasks.ParseBackfillRequest(body.From, body.To, body.Currencies)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("[HTTP] Rate backfill triggered: %s to %s", body.From, req.To.Format(time.DateOnly))
+
+		// Stream progress as NDJSON, like /process
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		flusher, _ := w.(http.Flusher)
+		send := func(msg map[string]any) {
+			json.NewEncoder(w).Encode(msg)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		report, err := tasks.BackfillExchangeRates(r.Context(), database, rateSources, req, func(percent float64, message string) {
+			send(map[string]any{"type": "progress", "percent": percent, "message": message})
+		})
+		if err != nil {
+			log.Printf("[HTTP] Rate backfill failed: %v", err)
+			send(map[string]any{"type": "error", "message": err.Error()})
+			return
+		}
+		send(map[string]any{"type": "complete", "data": report})
+	}))
+
+	// GET /rates/at?currency=ARS&date=2024-05-01&type=blue
+	mux.HandleFunc("/rates/at", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		currency := strings.ToUpper(strings.TrimSpace(q.Get("currency")))
+		date, err := parseRateDate(q.Get("date"))
+		if currency == "" || err != nil {
+			http.Error(w, "currency and a YYYY-MM-DD date are required", http.StatusBadRequest)
+			return
+		}
+
+		rate, err := database.RateAt(r.Context(), currency, date, strings.ToLower(q.Get("type")))
+		if err != nil {
+			writeRateError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rate)
+	}))
+
+	// GET /rates/convert?amount=100&from=ARS&to=EUR&date=2024-05-01&type=blue
+	mux.HandleFunc("/rates/convert", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		amount, amountErr := decimal.NewFromString(q.Get("amount"))
+		from := strings.ToUpper(strings.TrimSpace(q.Get("from")))
+		to := strings.ToUpper(strings.TrimSpace(q.Get("to")))
+		date, err := parseRateDate(q.Get("date"))
+		if amountErr != nil || from == "" || to == "" || err != nil {
+			http.Error(w, "amount, from, to and a YYYY-MM-DD date are required", http.StatusBadRequest)
+			return
+		}
+
+		conversion, err := database.Convert(r.Context(), amount, from, to, date, strings.ToLower(q.Get("type")))
+		if err != nil {
+			writeRateError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(conversion)
+	}))
+
+	mux.HandleFunc("/rates/quarantine", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rates, err := tasks.ListQuarantinedRates(r.Context(), database)
+		if err != nil {
+			log.Printf("[HTTP] Failed to list quarantined rates: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rates)
+	}))
+
+	// POST /rates/quarantine/approve and /rates/quarantine/reject with {"ids": [...]}
+	resolveQuarantine := func(approve bool) http.HandlerFunc {
+		return authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			var body struct {
+				IDs []string `json:"ids"`
+			}
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil || len(body.IDs) == 0 {
+				http.Error(w, "ids are required", http.StatusBadRequest)
+				return
+			}
+
+			resolved, err := tasks.ResolveQuarantinedRates(r.Context(), database, body.IDs, approve)
+			if err != nil {
+				log.Printf("[HTTP] Failed to resolve quarantined rates: %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			log.Printf("[HTTP] Resolved %d quarantined rates (approved: %t)", resolved, approve)
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]int{"resolved": resolved})
+		})
+	}
+	mux.HandleFunc("/rates/quarantine/approve", resolveQuarantine(true))
+	mux.HandleFunc("/rates/quarantine/reject", resolveQuarantine(false))
+
 	mux.HandleFunc("/backups", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -196,7 +358,7 @@ func main() {
 		log.Println("[HTTP] Manual data retention cleanup triggered")
 
 		go func() {
-			report, err := tasks.RunDataRetentionCleanup(database)
+			report, err := tasks.RunDataRetentionCleanup(database, cfg.RateRetentionDays)
 			if err != nil {
 				log.Printf("[HTTP] Cleanup failed: %v", err)
 				return
@@ -216,11 +378,12 @@ func main() {
 
 	// Message types for NDJSON streaming
 	type StreamMessage struct {
-		Type     string                 `json:"type"`
-		Percent  float64                `json:"percent,omitempty"`
-		Message  string                 `json:"message,omitempty"`
-		Data     interface{}            `json:"data,omitempty"`
-		Metadata *models.ImportMetadata `json:"metadata,omitempty"`
+		Type      string                 `json:"type"`
+		ErrorType string                 `json:"errorType,omitempty"` // Machine-readable cause for "error" messages
+		Percent   float64                `json:"percent,omitempty"`
+		Message   string                 `json:"message,omitempty"`
+		Data      interface{}            `json:"data,omitempty"`
+		Metadata  *models.ImportMetadata `json:"metadata,omitempty"`
 	}
 
 	mux.HandleFunc("/process", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -295,7 +458,18 @@ func main() {
 			return
 		}
 
-		defaultCurrency := r.FormValue("currency")
+		// The account currency and locale settle symbols such as "$" in amounts
+		currency := processor.CurrencyContext{
+			Account: strings.ToUpper(strings.TrimSpace(r.FormValue("currency"))),
+			Locale:  r.FormValue("locale"),
+		}
+		if currency.Locale == "" {
+			if tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil && len(tags) > 0 {
+				currency.Locale = tags[0].String()
+			}
+		}
+		// Optional password for encrypted PDF statements. Never log or persist it.
+		pdfPassword := r.FormValue("password")
 
 		// Determine LLM provider based on request
 		providerName := r.FormValue("provider")
@@ -318,14 +492,47 @@ func main() {
 			log.Printf("[HTTP] Using Ollama provider (model: %s)", activeModel)
 		}
 
+		validation := processor.ValidationRules{
+			MaxAmountUSD:    decimal.NewFromInt(int64(cfg.MaxAmountUSD)),
+			MaxFutureDays:   cfg.MaxFutureDays,
+			ExtraCurrencies: cfg.ExtraCurrencies,
+		}
+		// Each transaction is converted to USD at the rate for its own date
+		rates := processor.RateLookup{
+			MaxDaysAway: cfg.RateMaxDaysAway,
+			Find: func(currency string, date time.Time) (db.ExchangeRate, bool) {
+				rate, err := database.NearestRate(r.Context(), currency, date)
+				if err != nil {
+					if !errors.Is(err, db.ErrNoRate) {
+						log.Printf("[HTTP] WARNING: exchange rate lookup failed: %v", err)
+					}
+					return db.ExchangeRate{}, false
+				}
+				return rate, true
+			},
+		}
+		if latest, err := database.LatestUnitsPerUSD(r.Context()); err != nil {
+			log.Printf("[HTTP] WARNING: exchange rates unavailable for validation: %v", err)
+		} else {
+			validation.UnitsPerUSD = latest
+		}
+
 		if ext == ".csv" {
 			if _, err := tempFile.Seek(0, 0); err != nil {
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
 				return
 			}
-			transactions, metadata, err = handleCSV(tempFile, provider, activeModel, cfg.EnrichBatchSize, enrichConcurrency, validCategories, defaultCurrency, sendProgress)
+			rejection := adapters.RejectionPolicy{
+				Strict:             cfg.CSVStrictMode,
+				MaxRejectedPercent: cfg.CSVMaxRejectedPct,
+			}
+			transactions, metadata, err = handleCSV(tempFile, header.Filename, provider, activeModel, cfg.EnrichBatchSize, enrichConcurrency, rejection, validation, rates, validCategories, currency, sendProgress)
 		} else if ext == ".pdf" {
-			transactions, metadata, err = handlePDF(tempFile.Name(), provider, activeModel, cfg.EnrichBatchSize, enrichConcurrency, pdfConcurrency, validCategories, defaultCurrency, sendProgress)
+			chunkOpts := pdf.ChunkOptions{
+				MaxContextTokens: cfg.PDFMaxContextTokens,
+				OverlapRecords:   cfg.PDFChunkOverlap,
+			}
+			transactions, metadata, err = handlePDF(tempFile.Name(), header.Filename, pdfPassword, provider, activeModel, cfg.EnrichBatchSize, enrichConcurrency, pdfConcurrency, chunkOpts, validation, rates, validCategories, currency, sendProgress)
 		} else {
 			http.Error(w, "Unsupported file format", http.StatusBadRequest)
 			return
@@ -338,6 +545,14 @@ func main() {
 				Message:  fmt.Sprintf("Processing failed: %v", err),
 				Metadata: metadata,
 			}
+			// Let the UI prompt for a password instead of showing a raw pdftotext error
+			if errors.Is(err, pdf.ErrPasswordRequired) {
+				errMsg.ErrorType = "password_required"
+				errMsg.Message = "This PDF is password protected. Enter its password to continue."
+			} else if errors.Is(err, pdf.ErrPasswordIncorrect) {
+				errMsg.ErrorType = "password_incorrect"
+				errMsg.Message = "The PDF password is incorrect."
+			}
 			json.NewEncoder(w).Encode(errMsg)
 			return
 		}
@@ -400,7 +615,7 @@ func main() {
 	// Start the scheduler
 	c.Start()
 	log.Println("✓ Scheduler started")
-	log.Println("  - Exchange rates: daily at 09:05 UTC")
+	log.Printf("  - Exchange rates: daily at 09:05 UTC (sources: %s)", strings.ToLower(strings.Join(cfg.RateSources, ", ")))
 	log.Println("  - Recurring expenses: every 15 minutes")
 	log.Printf("  - Database backup: %s (retention: %d days)", cfg.BackupCron, cfg.BackupRetentionDays)
 	log.Println("  - Data retention cleanup: daily at 03:00 UTC")
@@ -434,7 +649,27 @@ func main() {
 	log.Println("✓ Sidecar stopped")
 }
 
-func handleCSV(file *os.File, provider llm.Provider, model string, batchSize int, enrichConcurrency int, categories []string, defaultCurrency string, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, error) {
+// parseRateDate reads the date of a /rates request, defaulting to today.
+// Rates are stored at midnight UTC, so any time of day is dropped.
+func parseRateDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+// writeRateError answers a /rates request whose rate lookup failed: 404 when
+// no rate is stored, 500 otherwise.
+func writeRateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, db.ErrNoRate) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Printf("[HTTP] Rate lookup failed: %v", err)
+	http.Error(w, "Rate lookup failed", http.StatusInternalServerError)
+}
+
+func handleCSV(file *os.File, sourceFile string, provider llm.Provider, model string, batchSize int, enrichConcurrency int, rejection adapters.RejectionPolicy, validation processor.ValidationRules, rates processor.RateLookup, categories []string, currency processor.CurrencyContext, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, error) {
 	metadata := &models.ImportMetadata{
 		Warnings: []string{},
 	}
@@ -443,26 +678,55 @@ func handleCSV(file *os.File, provider llm.Provider, model string, batchSize int
 	if onProgress != nil {
 		onProgress(0.1, "Detecting CSV format...")
 	}
-	reader := csv.NewReader(file)
-	headers, err := reader.Read()
-	if err != nil {
-		return nil, metadata, fmt.Errorf("could not read headers: %w", err)
+	// Sniff delimiter, quoting and encoding so EU/LatAm exports, UTF-16 files
+	// and BOMs read correctly and hash to the same schema cache entry
+	sniffBuf := make([]byte, adapters.SniffSize)
+	n, err := io.ReadFull(file, sniffBuf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, metadata, fmt.Errorf("could not read file: %w", err)
 	}
+	dialect := adapters.SniffDialect(sniffBuf[:n])
+	log.Printf("Detected CSV dialect: delimiter=%q quote=%q encoding=%s bom=%t", dialect.Delimiter, dialect.Quote, dialect.Encoding, dialect.HasBOM)
 
-	var sampleRows []string
-	for i := 0; i < 3; i++ {
+	if _, err = file.Seek(0, 0); err != nil {
+		return nil, metadata, fmt.Errorf("failed to seek: %w", err)
+	}
+	// Read enough leading records to find the header below any account preamble
+	reader := adapters.NewCSVReader(file, dialect)
+	var leading [][]string
+	for len(leading) < adapters.HeaderScanRows+3 {
 		row, err := reader.Read()
 		if err != nil {
 			break
 		}
-		sampleRows = append(sampleRows, strings.Join(row, ","))
+		leading = append(leading, row)
+	}
+	if len(leading) == 0 {
+		return nil, metadata, fmt.Errorf("could not read headers: file is empty")
+	}
+	headerIdx := adapters.DetectHeaderRow(leading)
+	if headerIdx > 0 {
+		log.Printf("Skipping %d preamble row(s) before the CSV header", headerIdx)
+	}
+
+	sample := adapters.CSVSample{
+		Header:       leading[headerIdx],
+		Dialect:      dialect,
+		HeaderRowIdx: headerIdx,
+	}
+	for _, row := range leading[headerIdx+1 : min(len(leading), headerIdx+4)] {
+		sample.Rows = append(sample.Rows, adapters.FormatRecord(row))
 	}
 
-	adapter, schemaTokens, err := adapters.DetectAdapter(provider, model, headers, sampleRows)
+	adapter, schemaTokens, err := adapters.DetectAdapter(provider, model, sample)
 	totalTokens += schemaTokens
 	if err != nil {
 		return nil, metadata, err
 	}
+	if dynamic, ok := adapter.(*adapters.DynamicAdapter); ok {
+		metadata.BankProfile = dynamic.Profile
+		dynamic.Currency = currency
+	}
 
 	if onProgress != nil {
 		onProgress(0.2, "Parsing transactions...")
@@ -472,19 +736,15 @@ func handleCSV(file *os.File, provider llm.Provider, model string, batchSize int
 		return nil, metadata, fmt.Errorf("failed to seek: %w", err)
 	}
 
-	parsedTransactions, err := adapter.Parse(file)
+	parsedTransactions, err := adapter.Parse(file, metadata)
 	if err != nil {
 		return nil, metadata, fmt.Errorf("parse error: %w", err)
 	}
-
-	for i := range parsedTransactions {
-		parsedTransactions[i].Currency = processor.NormalizeCurrency(parsedTransactions[i].Currency)
-		parsedTransactions[i].OriginalCurrency = processor.NormalizeCurrency(parsedTransactions[i].OriginalCurrency)
+	if err := rejection.Check(metadata); err != nil {
+		return nil, metadata, err
 	}
 
-	processor.ApplyExchangeRates(parsedTransactions, defaultCurrency)
-	processor.NormalizeDate(parsedTransactions)
-	parsedTransactions = processor.FilterPayments(parsedTransactions)
+	parsedTransactions = processor.PrepareTransactions(parsedTransactions, sourceFile, currency, rates, metadata)
 
 	if onProgress != nil {
 		onProgress(0.3, "Enriching transactions...")
@@ -500,7 +760,8 @@ func handleCSV(file *os.File, provider llm.Provider, model string, batchSize int
 		log.Printf("WARNING: enrichment error: %v (using raw data)", err)
 		metadata.Warnings = append(metadata.Warnings, fmt.Sprintf("Enrichment failed: %v", err))
 
-		validatedTx := processor.ValidateTransactions(parsedTransactions, metadata)
+		llm.MatchCategories(parsedTransactions, categories)
+		validatedTx := processor.ValidateTransactions(parsedTransactions, metadata, validation)
 		metadata.TotalTransactions = len(validatedTx)
 		metadata.TotalTokensUsed = totalTokens
 		return validatedTx, metadata, nil
@@ -512,14 +773,14 @@ func handleCSV(file *os.File, provider llm.Provider, model string, batchSize int
 	metadata.TotalTransactions = enrichMetadata.TotalTransactions
 	metadata.Warnings = append(metadata.Warnings, enrichMetadata.Warnings...)
 
-	validatedTx := processor.ValidateTransactions(enrichedTx, metadata)
+	validatedTx := processor.ValidateTransactions(enrichedTx, metadata, validation)
 	metadata.TotalTransactions = len(validatedTx)
 	metadata.TotalTokensUsed = totalTokens
 
 	return validatedTx, metadata, nil
 }
 
-func handlePDF(filePath string, provider llm.Provider, model string, batchSize int, enrichConcurrency int, pdfConcurrency int, categories []string, defaultCurrency string, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, error) {
+func handlePDF(filePath string, sourceFile string, password string, provider llm.Provider, model string, batchSize int, enrichConcurrency int, pdfConcurrency int, chunkOpts pdf.ChunkOptions, validation processor.ValidationRules, rates processor.RateLookup, categories []string, currency processor.CurrencyContext, onProgress func(float64, string)) ([]models.NormalizedTransaction, *models.ImportMetadata, error) {
 	metadata := &models.ImportMetadata{
 		Warnings: []string{},
 	}
@@ -528,7 +789,7 @@ func handlePDF(filePath string, provider llm.Provider, model string, batchSize i
 	if onProgress != nil {
 		onProgress(0.05, "Extracting text from PDF...")
 	}
-	rawText, err := pdf.ExtractTextFromPDF(filePath)
+	rawText, err := pdf.ExtractTextFromPDF(filePath, password)
 	if err != nil {
 		return nil, metadata, fmt.Errorf("PDF extraction failed: %w", err)
 	}
@@ -537,7 +798,7 @@ func handlePDF(filePath string, provider llm.Provider, model string, batchSize i
 		onProgress(0.1, "Parsing bank statement...")
 	}
 
-	parsedTx, parseMetadata, parseTokens, err := pdf.ParsePDFTransactions(provider, model, rawText, pdfConcurrency, func(p float64, m string) {
+	parsedTx, parseMetadata, parseTokens, err := pdf.ParsePDFTransactions(provider, model, rawText, pdfConcurrency, chunkOpts, func(p float64, m string) {
 		if onProgress != nil {
 			onProgress(0.1+(p*0.4), m)
 		}
@@ -554,10 +815,10 @@ func handlePDF(filePath string, provider llm.Provider, model string, batchSize i
 	metadata.SuccessfulChunks = parseMetadata.SuccessfulChunks
 	metadata.FailedChunks = parseMetadata.FailedChunks
 	metadata.Warnings = append(metadata.Warnings, parseMetadata.Warnings...)
+	metadata.Reconciliation = parseMetadata.Reconciliation
+	metadata.StatementPeriod = parseMetadata.StatementPeriod
 
-	processor.ApplyExchangeRates(parsedTx, defaultCurrency)
-	processor.NormalizeDate(parsedTx)
-	parsedTx = processor.FilterPayments(parsedTx)
+	parsedTx = processor.PrepareTransactions(parsedTx, sourceFile, currency, rates, metadata)
 
 	if onProgress != nil {
 		onProgress(0.5, "Enriching transactions...")
@@ -573,7 +834,8 @@ func handlePDF(filePath string, provider llm.Provider, model string, batchSize i
 		log.Printf("WARNING: enrichment error: %v (using raw data)", err)
 		metadata.Warnings = append(metadata.Warnings, fmt.Sprintf("Enrichment failed: %v", err))
 
-		validatedTx := processor.ValidateTransactions(parsedTx, metadata)
+		llm.MatchCategories(parsedTx, categories)
+		validatedTx := processor.ValidateTransactions(parsedTx, metadata, validation)
 		metadata.TotalTransactions = len(validatedTx)
 		metadata.TotalTokensUsed = totalTokens
 		return validatedTx, metadata, nil
@@ -585,7 +847,7 @@ func handlePDF(filePath string, provider llm.Provider, model string, batchSize i
 	metadata.TotalTransactions = enrichMetadata.TotalTransactions
 	metadata.Warnings = append(metadata.Warnings, enrichMetadata.Warnings...)
 
-	validatedTx := processor.ValidateTransactions(enrichedTx, metadata)
+	validatedTx := processor.ValidateTransactions(enrichedTx, metadata, validation)
 	metadata.TotalTransactions = len(validatedTx)
 	metadata.TotalTokensUsed = totalTokens
 
@@ -16,16 +16,18 @@ type RetentionReport struct {
 	MagicLinksDeleted     int64
 	GuestSessionsCleaned  int64
 	AuditIPsRedacted      int64
+	ExchangeRatesThinned  int64
 }
 
 func (r RetentionReport) String() string {
 	return fmt.Sprintf(
-		"event_logs=%d tokens=%d magic_links=%d guest_sessions=%d audit_ips_redacted=%d",
+		"event_logs=%d tokens=%d magic_links=%d guest_sessions=%d audit_ips_redacted=%d exchange_rates_thinned=%d",
 		r.EventLogsDeleted,
 		r.TokensDeleted,
 		r.MagicLinksDeleted,
 		r.GuestSessionsCleaned,
 		r.AuditIPsRedacted,
+		r.ExchangeRatesThinned,
 	)
 }
 
@@ -38,7 +40,9 @@ func (r RetentionReport) String() string {
 //   - Revoked/inactive MagicLinks older than 90 days → deleted
 //   - GuestSessions inactive for 90+ days → anonymize references then delete
 //   - AuditLogEntry.context IP addresses older than 90 days → redacted in-place
-func RunDataRetentionCleanup(database *db.DB) (RetentionReport, error) {
+//   - Daily exchange rates older than rateDailyDays → thinned to the first of
+//     each month per currency/type (0 keeps every day)
+func RunDataRetentionCleanup(database *db.DB, rateDailyDays int) (RetentionReport, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
@@ -153,6 +157,33 @@ func RunDataRetentionCleanup(database *db.DB) (RetentionReport, error) {
 		report.AuditIPsRedacted = tag.RowsAffected()
 	}
 
+	// ── 7. Exchange rates: thin old daily rows to one per month ──────────────
+	//
+	// Keeps the first row of each month, the latest row of each currency/type,
+	// any favorited row and backfilled history, so old conversions still find
+	// a nearby rate.
+	if rateDailyDays > 0 {
+		tag, err := database.Pool.Exec(ctx,
+			`DELETE FROM exchange_rate er
+			  WHERE er.date < $1
+			    AND NOT er.backfilled
+			    AND NOT EXISTS (SELECT 1 FROM exchange_rate_favorite f WHERE f."exchangeRateId" = er.id)
+			    AND er.id NOT IN (
+			        SELECT DISTINCT ON (currency, type, date_trunc('month', date)) id
+			          FROM exchange_rate
+			         ORDER BY currency, type, date_trunc('month', date), date ASC)
+			    AND er.id NOT IN (
+			        SELECT DISTINCT ON (currency, type) id
+			          FROM exchange_rate
+			         ORDER BY currency, type, date DESC)`,
+			now.AddDate(0, 0, -rateDailyDays),
+		)
+		if err != nil {
+			return report, fmt.Errorf("thin exchange_rate: %w", err)
+		}
+		report.ExchangeRatesThinned = tag.RowsAffected()
+	}
+
 	return report, nil
 }
 
@@ -2,22 +2,21 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"retrospend-sidecar/db"
+
+	"github.com/jackc/pgx/v5"
 )
 
 const (
-	exchangeRateURL = "https://raw.githubusercontent.com/syntheit/exchange-rates/refs/heads/main/rates.json"
-	maxRateEntries  = 2000
-	fetchTimeout    = 8 * time.Second
+	maxRateEntries = 2000
+	fetchTimeout   = 8 * time.Second // Per source
+	staleRateDays  = 7               // Days a pair may be missing from the sources before it is deleted
 )
 
 var (
@@ -25,31 +24,6 @@ var (
 	validType     = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
 )
 
-// supportedCryptoCurrencies mirrors the CRYPTO_CURRENCIES list in src/lib/currencies.ts.
-// Only crypto rates for currencies in this set will be synced; others are ignored and
-// any existing DB records for unsupported cryptos will be deleted by the stale-rate cleanup.
-var supportedCryptoCurrencies = map[string]bool{
-	"BTC":  true,
-	"ETH":  true,
-	"SOL":  true,
-	"BNB":  true,
-	"XRP":  true,
-	"DOGE": true,
-	"ADA":  true,
-	"AVAX": true,
-	"POL":  true,
-	"LTC":  true,
-	"DOT":  true,
-	"LINK": true,
-	"TON":  true,
-	"UNI":  true,
-	"NEAR": true,
-	"SUI":  true,
-	"USDC": true,
-	"USDT": true,
-	"DAI":  true,
-}
-
 type OracleRatesResponse struct {
 	UpdatedAt string             `json:"updatedAt"`
 	Base      string             `json:"base"`
@@ -61,13 +35,8 @@ type ParsedRate struct {
 	Currency string
 	Type     string
 	Rate     float64
-}
-
-type ExistingRate struct {
-	ID            string
-	Currency      string
-	Type          string
-	FavoriteCount int
+	Date     time.Time
+	Source   string // Name of the RateSource the rate came from
 }
 
 func parseRateKey(key string) (currency, rateType string) {
@@ -81,94 +50,21 @@ func parseRateKey(key string) (currency, rateType string) {
 	return
 }
 
-func SyncExchangeRates(database *db.DB) error {
+// SyncExchangeRates stores today's rates from the sources, merged in priority
+// order. Currency/type pairs no source offers any more are deleted, keeping
+// backfilled history, once they are gone: unsupported, or without a new rate
+// for staleRateDays. A pair missing for a day or two keeps its rates, and
+// nothing is deleted unless every source answered, so one feed's outage does
+// not touch its rates.
+// Rates that moved beyond the thresholds are quarantined, see
+// ResolveQuarantinedRates. With safeDelete, pairs still favorited or used by
+// an asset account are never deleted.
+func SyncExchangeRates(database *db.DB, sources []RateSource, thresholds AnomalyThresholds, safeDelete bool) error {
 	log.Println("[EXCHANGE_RATES] Starting sync...")
 
-	// Fetch rates from GitHub
-	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", exchangeRateURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to fetch rates: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	rateEntries, complete, err := fetchRates(context.Background(), sources)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var data OracleRatesResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		return fmt.Errorf("failed to parse JSON: %w", err)
-	}
-
-	if data.Rates == nil && data.CryptoRates == nil {
-		return fmt.Errorf("invalid response: missing both rates and cryptoRates objects")
-	}
-
-	// Parse and validate rates
-	var rateEntries []ParsedRate
-	
-	// Parse the date from the JSON response
-	effectiveDate, err := time.Parse(time.RFC3339, data.UpdatedAt)
-	if err != nil {
-		// Try alternative format if standard RFC3339 fails (sometimes APIs are finicky)
-		// Assuming standard for now, but falling back to Now() with a log is safer than crashing
-		log.Printf("[EXCHANGE_RATES] Warning: failed to parse UpdatedAt '%s': %v. Using current time.", data.UpdatedAt, err)
-		effectiveDate = time.Now().UTC()
-	}
-	effectiveDate = effectiveDate.Truncate(24 * time.Hour)
-
-	for key, rate := range data.Rates {
-		currency, rateType := parseRateKey(key)
-		currency = strings.ToUpper(currency)
-		rateType = strings.ToLower(rateType)
-
-		if !validCurrency.MatchString(currency) || !validType.MatchString(rateType) {
-			continue
-		}
-
-		rateEntries = append(rateEntries, ParsedRate{
-			Currency: currency,
-			Type:     rateType,
-			Rate:     rate,
-		})
-	}
-
-	for currency, rate := range data.CryptoRates {
-		currency = strings.ToUpper(currency)
-
-		if !validCurrency.MatchString(currency) || rate <= 0 {
-			continue
-		}
-
-		// Only sync supported cryptos; unsupported ones are skipped so the
-		// stale-rate cleanup below will remove any existing DB records for them.
-		if !supportedCryptoCurrencies[currency] {
-			continue
-		}
-
-		// Store Crypto rates as-is (USD per coin) to avoid precision loss
-		rateEntries = append(rateEntries, ParsedRate{
-			Currency: currency,
-			Type:     "crypto",
-			Rate:     rate,
-		})
-	}
-
-	if len(rateEntries) == 0 {
-		return fmt.Errorf("no valid rate entries found")
+		return err
 	}
 
 	if len(rateEntries) > maxRateEntries {
@@ -182,104 +78,118 @@ func SyncExchangeRates(database *db.DB) error {
 	}
 	defer tx.Rollback(context.Background())
 
-	// Get existing rates with favorite counts
+	// Get the latest stored rate of each currency/type pair
 	rows, err := tx.Query(context.Background(), `
-		SELECT er.id, er.currency, er.type, 
-			COALESCE((SELECT COUNT(*) FROM exchange_rate_favorite WHERE "exchangeRateId" = er.id), 0) as fav_count
-		FROM exchange_rate er
+		SELECT DISTINCT ON (currency, type) currency, type, rate::float8, date
+		FROM exchange_rate
+		ORDER BY currency, type, date DESC
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to query existing rates: %w", err)
 	}
-
-	existingMap := make(map[string][]ExistingRate)
+	latest := make(map[string]storedRate)
 	for rows.Next() {
-		var rate ExistingRate
-		if err := rows.Scan(&rate.ID, &rate.Currency, &rate.Type, &rate.FavoriteCount); err != nil {
+		var currency, rateType string
+		var stored storedRate
+		if err := rows.Scan(&currency, &rateType, &stored.Rate, &stored.Date); err != nil {
 			rows.Close()
 			return fmt.Errorf("failed to scan existing rate: %w", err)
 		}
-		key := rate.Currency + "_" + rate.Type
-		existingMap[key] = append(existingMap[key], rate)
+		latest[currency+"_"+rateType] = stored
 	}
 	rows.Close()
 
-	validIDs := make(map[string]bool)
-	var idsToDelete []string
+	// One row per currency/type per day: earlier days are kept as history for
+	// backdated conversions, and a second sync on the same day updates its row.
+	// A rate too far from the latest stored one is quarantined for review.
+	syncedKeys := make(map[string]bool)
+	var appliedCurrencies, appliedTypes []string
 	updated := 0
 	created := 0
-
+	quarantined := 0
 	for _, entry := range rateEntries {
 		key := entry.Currency + "_" + entry.Type
-		existing := existingMap[key]
-
-		if len(existing) > 0 {
-			// Find best candidate to keep (highest favorite count)
-			keeper := existing[0]
-			for _, e := range existing[1:] {
-				if e.FavoriteCount > keeper.FavoriteCount {
-					keeper = e
-				}
-			}
+		syncedKeys[key] = true
 
-			// Update the keeper
-			// Note: "updatedAt" is quoted because it's camelCase in DB
-			_, err := tx.Exec(context.Background(), `
-				UPDATE exchange_rate 
-				SET rate = $1, date = $2, "updatedAt" = NOW() 
-				WHERE id = $3
-			`, entry.Rate, effectiveDate, keeper.ID)
-			if err != nil {
-				return fmt.Errorf("failed to update rate: %w", err)
+		if previous, ok := latest[key]; ok && thresholds.exceeds(entry.Type, previous.Rate, entry.Rate) {
+			log.Printf("[EXCHANGE_RATES] Quarantined %s %s: %g → %g (%s)", entry.Currency, entry.Type, previous.Rate, entry.Rate, entry.Source)
+			if err := quarantineRate(context.Background(), tx, entry, previous); err != nil {
+				return err
 			}
+			quarantined++
+			continue
+		}
 
-			validIDs[keeper.ID] = true
-			updated++
-
-			// Mark duplicates for deletion
-			for _, e := range existing {
-				if e.ID != keeper.ID {
-					idsToDelete = append(idsToDelete, e.ID)
-				}
-			}
-		} else {
-			// Create new rate
-			// Note: "createdAt" and "updatedAt" are quoted
-			_, err := tx.Exec(context.Background(), `
-				INSERT INTO exchange_rate (id, date, currency, type, rate, "createdAt", "updatedAt")
-				VALUES (gen_random_uuid()::text, $1, $2, $3, $4, NOW(), NOW())
-			`, effectiveDate, entry.Currency, entry.Type, entry.Rate)
-			if err != nil {
-				return fmt.Errorf("failed to insert rate: %w", err)
-			}
+		inserted, err := upsertRate(context.Background(), tx, entry)
+		if err != nil {
+			return err
+		}
+		if inserted {
 			created++
+		} else {
+			updated++
 		}
+		appliedCurrencies = append(appliedCurrencies, entry.Currency)
+		appliedTypes = append(appliedTypes, entry.Type)
 	}
 
-	// Delete stale rates (not in new payload)
-	for _, rates := range existingMap {
-		for _, rate := range rates {
-			if !validIDs[rate.ID] {
-				idsToDelete = append(idsToDelete, rate.ID)
-			}
-		}
+	// A pair whose rate is back in range no longer needs review
+	_, err = tx.Exec(context.Background(), `
+		DELETE FROM exchange_rate_quarantine q
+		USING unnest($1::text[], $2::text[]) AS applied(currency, type)
+		WHERE q.currency = applied.currency AND q.type = applied.type
+	`, appliedCurrencies, appliedTypes)
+	if err != nil {
+		return fmt.Errorf("failed to clear quarantined rates: %w", err)
+	}
+
+	if err := repointFavorites(context.Background(), tx); err != nil {
+		return err
 	}
 
-	if len(idsToDelete) > 0 {
-		_, err := tx.Exec(context.Background(), `
-			DELETE FROM exchange_rate WHERE id = ANY($1)
-		`, idsToDelete)
+	// Delete the currency/type pairs that are gone from the payload
+	var staleCurrencies, staleTypes []string
+	if !complete {
+		log.Println("[EXCHANGE_RATES] Not every source answered; keeping rates missing from this sync")
+	} else {
+		for _, key := range gonePairs(latest, syncedKeys, time.Now().UTC().Truncate(24*time.Hour)) {
+			currency, rateType, _ := strings.Cut(key, "_")
+			staleCurrencies = append(staleCurrencies, currency)
+			staleTypes = append(staleTypes, rateType)
+		}
+	}
+	if safeDelete && len(staleCurrencies) > 0 {
+		staleCurrencies, staleTypes, err = dropProtectedPairs(context.Background(), tx, staleCurrencies, staleTypes)
+		if err != nil {
+			return err
+		}
+	}
+	var deleted int64
+	if len(staleCurrencies) > 0 {
+		if err := keepFavorites(context.Background(), tx, staleCurrencies, staleTypes); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(context.Background(), `
+			DELETE FROM exchange_rate er
+			USING unnest($1::text[], $2::text[]) AS stale(currency, type)
+			WHERE er.currency = stale.currency AND er.type = stale.type AND NOT er.backfilled
+		`, staleCurrencies, staleTypes)
 		if err != nil {
 			return fmt.Errorf("failed to delete stale rates: %w", err)
 		}
+		deleted = tag.RowsAffected()
+	}
+
+	if err := updateQuarantineStatus(context.Background(), tx); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(context.Background()); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("[EXCHANGE_RATES] ✓ Synced %d rates (created: %d, updated: %d, deleted: %d)",
-		len(rateEntries), created, updated, len(idsToDelete))
+	log.Printf("[EXCHANGE_RATES] ✓ Synced %d rates (created: %d, updated: %d, deleted: %d, quarantined: %d)",
+		len(rateEntries), created, updated, deleted, quarantined)
 
 	// Update worker status
 	if err := database.UpdateWorkerStatus(context.Background(), "exchange_rates", true); err != nil {
@@ -288,3 +198,152 @@ func SyncExchangeRates(database *db.DB) error {
 
 	return nil
 }
+
+// dropProtectedPairs removes from the stale currency/type pairs those that a
+// user has favorited or that an asset account converts with, logging each.
+func dropProtectedPairs(ctx context.Context, tx pgx.Tx, currencies, types []string) ([]string, []string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT stale.currency, stale.type
+		FROM unnest($1::text[], $2::text[]) AS stale(currency, type)
+		WHERE EXISTS (
+			SELECT 1 FROM exchange_rate_favorite f
+			JOIN exchange_rate er ON er.id = f."exchangeRateId"
+			WHERE er.currency = stale.currency AND er.type = stale.type
+		) OR EXISTS (
+			SELECT 1 FROM asset_account a
+			WHERE a.currency = stale.currency AND a."exchangeRateType" = stale.type
+		)
+	`, currencies, types)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query protected rates: %w", err)
+	}
+	protected := make(map[string]bool)
+	for rows.Next() {
+		var currency, rateType string
+		if err := rows.Scan(&currency, &rateType); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan protected rate: %w", err)
+		}
+		protected[currency+"_"+rateType] = true
+		log.Printf("[EXCHANGE_RATES] Safe delete: keeping %s %s, which is favorited or used by an asset account", currency, rateType)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to query protected rates: %w", err)
+	}
+
+	var keptCurrencies, keptTypes []string
+	for i := range currencies {
+		if !protected[currencies[i]+"_"+types[i]] {
+			keptCurrencies = append(keptCurrencies, currencies[i])
+			keptTypes = append(keptTypes, types[i])
+		}
+	}
+	return keptCurrencies, keptTypes, nil
+}
+
+// gonePairs picks the currency/type pairs of latest that this sync did not
+// offer and that are gone rather than missing for a day: those no longer
+// supported, such as a crypto dropped from crypto_currency.json, and those
+// whose latest rate is more than staleRateDays before today.
+func gonePairs(latest map[string]storedRate, synced map[string]bool, today time.Time) []string {
+	cutoff := today.AddDate(0, 0, -staleRateDays)
+	var gone []string
+	for key, stored := range latest {
+		if synced[key] {
+			continue
+		}
+		currency, rateType, _ := strings.Cut(key, "_")
+		if !rateSupported(currency, rateType) || stored.Date.Before(cutoff) {
+			gone = append(gone, key)
+		}
+	}
+	return gone
+}
+
+// keepFavorites moves the favorites of the stale currency/type pairs onto
+// the pair's latest backfilled row, which survives their deletion, rather
+// than letting the delete cascade to them. Pairs without backfilled rows
+// lose their favorites with their rates.
+func keepFavorites(ctx context.Context, tx pgx.Tx, currencies, types []string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE exchange_rate_favorite f
+		SET "exchangeRateId" = kept.id
+		FROM exchange_rate er
+		JOIN unnest($1::text[], $2::text[]) AS stale(currency, type)
+		  ON stale.currency = er.currency AND stale.type = er.type,
+		LATERAL (
+			SELECT id FROM exchange_rate k
+			WHERE k.currency = er.currency AND k.type = er.type AND k.backfilled
+			ORDER BY k.date DESC
+			LIMIT 1
+		) AS kept
+		WHERE f."exchangeRateId" = er.id AND NOT er.backfilled
+	`, currencies, types)
+	if err != nil {
+		return fmt.Errorf("failed to keep favorites of stale rates: %w", err)
+	}
+	return nil
+}
+
+// storedRate is the latest exchange_rate row of a currency/type pair.
+type storedRate struct {
+	Rate float64
+	Date time.Time
+}
+
+// upsertRate stores a rate as its currency/type's row for its date,
+// reporting whether the row is new.
+// Note: "createdAt" and "updatedAt" are quoted because they're camelCase in DB
+func upsertRate(ctx context.Context, tx pgx.Tx, entry ParsedRate) (bool, error) {
+	var inserted bool
+	err := tx.QueryRow(ctx, `
+		INSERT INTO exchange_rate (id, date, currency, type, rate, source, "createdAt", "updatedAt")
+		VALUES (gen_random_uuid()::text, $1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (date, currency, type)
+		DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, backfilled = false, "updatedAt" = NOW()
+		RETURNING (xmax = 0)
+	`, entry.Date, entry.Currency, entry.Type, entry.Rate, entry.Source).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert rate: %w", err)
+	}
+	return inserted, nil
+}
+
+// repointFavorites moves each favorite to the latest row of its currency and
+// type, so favorites follow the current rate rather than the day they were
+// added. A user's duplicate favorites of one currency/type, which would clash
+// once re-pointed, are merged into the first.
+func repointFavorites(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `
+		DELETE FROM exchange_rate_favorite f
+		USING exchange_rate er
+		WHERE f."exchangeRateId" = er.id
+		  AND EXISTS (
+			SELECT 1 FROM exchange_rate_favorite f2
+			JOIN exchange_rate er2 ON er2.id = f2."exchangeRateId"
+			WHERE f2."userId" = f."userId"
+			  AND er2.currency = er.currency AND er2.type = er.type
+			  AND (f2."order" < f."order" OR (f2."order" = f."order" AND f2.id < f.id))
+		  )
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to merge duplicate favorites: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE exchange_rate_favorite f
+		SET "exchangeRateId" = latest.id
+		FROM exchange_rate er,
+			(SELECT DISTINCT ON (currency, type) id, currency, type
+			 FROM exchange_rate
+			 ORDER BY currency, type, date DESC) AS latest
+		WHERE f."exchangeRateId" = er.id
+		  AND latest.currency = er.currency AND latest.type = er.type
+		  AND f."exchangeRateId" <> latest.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to re-point favorites: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+
+	"retrospend-sidecar/currencies"
+)
+
+// ── Stale rate deletion ───────────────────────────────────────────────────────
+
+func TestGonePairs_GapKeepsPair(t *testing.T) {
+	today := day("2027-03-10")
+	latest := map[string]storedRate{
+		"ARS_blue":     {Rate: 1200, Date: day("2027-03-09")},
+		"ARS_official": {Rate: 900, Date: day("2027-03-09")},
+	}
+
+	// ARS blue is missing from one payload: it keeps its rates
+	if got := gonePairs(latest, map[string]bool{"ARS_official": true}, today); len(got) != 0 {
+		t.Errorf("expected a one-day gap to keep the pair, got %v", got)
+	}
+	// Still missing at the cutoff, it is kept
+	if got := gonePairs(latest, map[string]bool{"ARS_official": true}, day("2027-03-16")); len(got) != 0 {
+		t.Errorf("expected the pair kept through %d days, got %v", staleRateDays, got)
+	}
+}
+
+func TestGonePairs_MissingPastCutoff(t *testing.T) {
+	latest := map[string]storedRate{
+		"ARS_blue":     {Rate: 1200, Date: day("2027-03-01")},
+		"ARS_official": {Rate: 900, Date: day("2027-03-09")},
+		"VES_official": {Rate: 36, Date: day("2027-02-20")},
+	}
+	got := gonePairs(latest, map[string]bool{"ARS_official": true}, day("2027-03-10"))
+	slices.Sort(got)
+	if want := []string{"ARS_blue", "VES_official"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v gone, got %v", want, got)
+	}
+}
+
+func TestGonePairs_SyncedPairsStay(t *testing.T) {
+	latest := map[string]storedRate{"EUR_official": {Rate: 0.9, Date: day("2026-01-01")}}
+	if got := gonePairs(latest, map[string]bool{"EUR_official": true}, day("2027-03-10")); len(got) != 0 {
+		t.Errorf("expected a synced pair to stay, got %v", got)
+	}
+}
+
+func TestGonePairs_UnsupportedCryptoGoesAtOnce(t *testing.T) {
+	if !currencies.CryptoLoaded() {
+		t.Skip("crypto_currency.json not found")
+	}
+	latest := map[string]storedRate{"NOTACOIN_crypto": {Rate: 1, Date: day("2027-03-09")}}
+	got := gonePairs(latest, nil, day("2027-03-10"))
+	if !slices.Equal(got, []string{"NOTACOIN_crypto"}) {
+		t.Errorf("expected the unsupported crypto gone, got %v", got)
+	}
+}
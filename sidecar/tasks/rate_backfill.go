@@ -0,0 +1,259 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"retrospend-sidecar/db"
+)
+
+// maxBackfillDays bounds one backfill to about ten years.
+const maxBackfillDays = 3660
+
+// ErrBackfillRunning is returned when a backfill starts while another runs.
+var ErrBackfillRunning = errors.New("exchange rate backfill already in progress")
+
+var backfillMu sync.Mutex
+
+// BackfillRequest selects the rates BackfillExchangeRates fills in.
+type BackfillRequest struct {
+	From       time.Time
+	To         time.Time
+	Currencies []string
+}
+
+// ParseBackfillRequest validates a backfill's YYYY-MM-DD dates and currency
+// codes. An empty to means today. USD, which needs no rate, is dropped.
+func ParseBackfillRequest(from, to string, currencies []string) (BackfillRequest, error) {
+	var req BackfillRequest
+	var err error
+	if req.From, err = time.Parse(time.DateOnly, from); err != nil {
+		return req, fmt.Errorf("invalid from date %q: expected YYYY-MM-DD", from)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	req.To = today
+	if to != "" {
+		if req.To, err = time.Parse(time.DateOnly, to); err != nil {
+			return req, fmt.Errorf("invalid to date %q: expected YYYY-MM-DD", to)
+		}
+	}
+	if req.To.After(today) {
+		return req, fmt.Errorf("to date %s is in the future", to)
+	}
+	if req.From.After(req.To) {
+		return req, fmt.Errorf("from date %s is after to date %s", req.From.Format(time.DateOnly), req.To.Format(time.DateOnly))
+	}
+	if days := int(req.To.Sub(req.From).Hours()/24) + 1; days > maxBackfillDays {
+		return req, fmt.Errorf("date range of %d days exceeds the limit of %d", days, maxBackfillDays)
+	}
+
+	for _, c := range currencies {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" || c == "USD" || slices.Contains(req.Currencies, c) {
+			continue
+		}
+		if !validCurrency.MatchString(c) {
+			return req, fmt.Errorf("invalid currency code %q", c)
+		}
+		req.Currencies = append(req.Currencies, c)
+	}
+	if len(req.Currencies) == 0 {
+		return req, fmt.Errorf("at least one currency other than USD is required")
+	}
+	return req, nil
+}
+
+// BackfillReport summarizes a backfill in date/currency pairs.
+type BackfillReport struct {
+	Days     int            `json:"days"`
+	Skipped  int            `json:"skipped"`  // Pairs that already had a rate
+	Inserted int            `json:"inserted"` // Rates added, one per pair and rate type
+	Missing  int            `json:"missing"`  // Pairs no source had a rate for
+	BySource map[string]int `json:"bySource"` // Rates added per source
+}
+
+func (r BackfillReport) String() string {
+	return fmt.Sprintf("days=%d skipped=%d inserted=%d missing=%d", r.Days, r.Skipped, r.Inserted, r.Missing)
+}
+
+// BackfillExchangeRates fills exchange_rate for each date of the request and
+// each of its currencies that has no rate on that date yet, asking the
+// sources that support historical queries in priority order. Rows are marked
+// backfilled, which the daily sync's stale-rate deletion and the retention
+// thinning leave alone, and never replace an existing rate, so running the
+// same backfill again only fills what is still missing. onProgress receives
+// the share of the date range a source has covered.
+func BackfillExchangeRates(ctx context.Context, database *db.DB, sources []RateSource, req BackfillRequest, onProgress func(percent float64, message string)) (BackfillReport, error) {
+	report := BackfillReport{BySource: map[string]int{}}
+	if !backfillMu.TryLock() {
+		return report, ErrBackfillRunning
+	}
+	defer backfillMu.Unlock()
+
+	var dates []time.Time
+	for d := req.From; !d.After(req.To); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+	report.Days = len(dates)
+
+	// A date/currency pair with a rate of any type is already covered
+	have := make(map[string]bool)
+	rows, err := database.Pool.Query(ctx, `
+		SELECT DISTINCT date, currency FROM exchange_rate
+		WHERE date >= $1 AND date <= $2 AND currency = ANY($3)
+	`, req.From, req.To, req.Currencies)
+	if err != nil {
+		return report, fmt.Errorf("failed to query existing rates: %w", err)
+	}
+	for rows.Next() {
+		var date time.Time
+		var currency string
+		if err := rows.Scan(&date, &currency); err != nil {
+			rows.Close()
+			return report, fmt.Errorf("failed to scan existing rate: %w", err)
+		}
+		have[backfillKey(date, currency)] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("failed to query existing rates: %w", err)
+	}
+	report.Skipped = len(have)
+
+	// missingDates lists the dates that still lack a rate for some currency
+	missingDates := func() []time.Time {
+		var missing []time.Time
+		for _, date := range dates {
+			for _, currency := range req.Currencies {
+				if !have[backfillKey(date, currency)] {
+					missing = append(missing, date)
+					break
+				}
+			}
+		}
+		return missing
+	}
+
+	log.Printf("[EXCHANGE_RATES] Backfill %s to %s for %s: %d of %d pairs already present",
+		req.From.Format(time.DateOnly), req.To.Format(time.DateOnly), strings.Join(req.Currencies, ", "),
+		report.Skipped, len(dates)*len(req.Currencies))
+
+	historical := 0
+	var errs []error
+	for _, source := range sources {
+		hs, ok := source.(HistoricalRateSource)
+		if !ok {
+			continue
+		}
+		wanted := missingDates()
+		if len(wanted) == 0 {
+			break
+		}
+
+		emit := func(entries []ParsedRate) error {
+			inserted, latest, err := insertBackfilledRates(ctx, database, source.Name(), entries, req.Currencies, have)
+			if err != nil {
+				return err
+			}
+			report.Inserted += inserted
+			report.BySource[source.Name()] += inserted
+			if onProgress != nil && !latest.IsZero() {
+				span := req.To.Sub(req.From).Hours() + 24
+				percent := (latest.Sub(req.From).Hours() + 24) / span * 100
+				onProgress(percent, fmt.Sprintf("%s: %d rates added through %s", source.Name(), report.BySource[source.Name()], latest.Format(time.DateOnly)))
+			}
+			return nil
+		}
+
+		err := hs.FetchHistory(ctx, wanted, emit)
+		if errors.Is(err, errNoHistory) {
+			continue
+		}
+		historical++
+		if err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			log.Printf("[EXCHANGE_RATES] Backfill from %s failed: %v", source.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+		}
+	}
+	if historical == 0 {
+		return report, fmt.Errorf("no configured rate source provides historical rates")
+	}
+
+	for _, date := range dates {
+		for _, currency := range req.Currencies {
+			if !have[backfillKey(date, currency)] {
+				report.Missing++
+			}
+		}
+	}
+	if report.Inserted == 0 && len(errs) > 0 {
+		return report, fmt.Errorf("backfill failed: %w", errors.Join(errs...))
+	}
+
+	log.Printf("[EXCHANGE_RATES] ✓ Backfill complete: %s", report)
+	return report, nil
+}
+
+// insertBackfilledRates stores the entries for requested currencies whose
+// date/currency pair is not in have, and adds the pairs it stored to have.
+// It returns the number of rows inserted and the latest date among entries.
+func insertBackfilledRates(ctx context.Context, database *db.DB, source string, entries []ParsedRate, currencies []string, have map[string]bool) (int, time.Time, error) {
+	var latest time.Time
+	var pending []ParsedRate
+	for _, entry := range entries {
+		entry, ok := cleanRate(entry)
+		if !ok {
+			continue
+		}
+		entry.Date = entry.Date.UTC().Truncate(24 * time.Hour)
+		if entry.Date.After(latest) {
+			latest = entry.Date
+		}
+		if slices.Contains(currencies, entry.Currency) && !have[backfillKey(entry.Date, entry.Currency)] {
+			pending = append(pending, entry)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, latest, nil
+	}
+
+	tx, err := database.Pool.Begin(ctx)
+	if err != nil {
+		return 0, latest, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	inserted := 0
+	for _, entry := range pending {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO exchange_rate (id, date, currency, type, rate, source, backfilled, "createdAt", "updatedAt")
+			VALUES (gen_random_uuid()::text, $1, $2, $3, $4, $5, true, NOW(), NOW())
+			ON CONFLICT (date, currency, type) DO NOTHING
+		`, entry.Date, entry.Currency, entry.Type, entry.Rate, source)
+		if err != nil {
+			return 0, latest, fmt.Errorf("failed to insert backfilled rate: %w", err)
+		}
+		inserted += int(tag.RowsAffected())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, latest, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, entry := range pending {
+		have[backfillKey(entry.Date, entry.Currency)] = true
+	}
+	return inserted, latest, nil
+}
+
+func backfillKey(date time.Time, currency string) string {
+	return date.UTC().Format(time.DateOnly) + "_" + currency
+}
@@ -0,0 +1,110 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ── ParseBackfillRequest ──────────────────────────────────────────────────────
+
+func TestParseBackfillRequest(t *testing.T) {
+	req, err := ParseBackfillRequest("2024-01-01", "2024-01-31", []string{"eur", " GBP", "USD", "EUR", ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.Currencies) != 2 || req.Currencies[0] != "EUR" || req.Currencies[1] != "GBP" {
+		t.Errorf("expected EUR and GBP, got %v", req.Currencies)
+	}
+	if req.To.Format(time.DateOnly) != "2024-01-31" {
+		t.Errorf("unexpected to date %v", req.To)
+	}
+}
+
+func TestParseBackfillRequest_DefaultsToToday(t *testing.T) {
+	req, err := ParseBackfillRequest("2024-01-01", "", []string{"EUR"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !req.To.Equal(time.Now().UTC().Truncate(24 * time.Hour)) {
+		t.Errorf("expected to date of today, got %v", req.To)
+	}
+}
+
+func TestParseBackfillRequest_Invalid(t *testing.T) {
+	future := time.Now().AddDate(0, 0, 3).Format(time.DateOnly)
+	cases := []struct {
+		from, to   string
+		currencies []string
+	}{
+		{"01/02/2024", "", []string{"EUR"}},
+		{"2024-02-01", "2024-01-01", []string{"EUR"}},
+		{"2024-01-01", future, []string{"EUR"}},
+		{"2000-01-01", "2024-01-01", []string{"EUR"}},
+		{"2024-01-01", "2024-01-31", []string{"USD"}},
+		{"2024-01-01", "2024-01-31", []string{"E-R"}},
+	}
+	for _, c := range cases {
+		if _, err := ParseBackfillRequest(c.from, c.to, c.currencies); err == nil {
+			t.Errorf("expected an error for %s..%s %v", c.from, c.to, c.currencies)
+		}
+	}
+}
+
+// ── FetchHistory ──────────────────────────────────────────────────────────────
+
+func TestECBSource_FetchHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hist.xml")
+	os.WriteFile(path, []byte(`<Envelope><Cube>
+		<Cube time="2024-01-03"><Cube currency="USD" rate="1.1"/><Cube currency="GBP" rate="0.88"/></Cube>
+		<Cube time="2024-01-02"><Cube currency="USD" rate="1.0"/><Cube currency="GBP" rate="0.8"/></Cube>
+	</Cube></Envelope>`), 0o644)
+
+	var got []ParsedRate
+	err := ECBSource{HistoryURL: path}.FetchHistory(context.Background(), []time.Time{
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), // Saturday
+	}, func(rates []ParsedRate) error {
+		got = append(got, rates...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected EUR and GBP for 2024-01-02 only, got %+v", got)
+	}
+	if r, _ := rateOf(got, "GBP", "official"); !near(r.Rate, 0.8) {
+		t.Errorf("expected 0.8 GBP per USD, got %v", r.Rate)
+	}
+}
+
+func TestJSONSource_FetchHistoryFillsDate(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "2024-01-02.json"), []byte(`{"rates":{"EUR":0.9}}`), 0o644)
+	source := JSONSource{Location: filepath.Join(dir, "{date}.json"), Mapping: JSONMapping{Rates: "rates"}}
+
+	var got []ParsedRate
+	err := source.FetchHistory(context.Background(), []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), // No file
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}, func(rates []ParsedRate) error {
+		got = append(got, rates...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Date.Format(time.DateOnly) != "2024-01-02" {
+		t.Errorf("expected one EUR rate dated 2024-01-02, got %+v", got)
+	}
+}
+
+func TestJSONSource_NoHistoryWithoutTemplate(t *testing.T) {
+	err := JSONSource{Location: "/srv/rates.json"}.FetchHistory(context.Background(), nil, nil)
+	if err != errNoHistory {
+		t.Errorf("expected errNoHistory, got %v", err)
+	}
+}
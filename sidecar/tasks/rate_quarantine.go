@@ -0,0 +1,207 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"retrospend-sidecar/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const quarantineStatusKey = "exchange_rate_quarantine"
+
+// AnomalyThresholds are the largest changes, in percent, SyncExchangeRates
+// applies without review, by rate type. "default" covers the types not
+// listed; a threshold of 0 turns the check off. A change is measured both
+// ways, so 50 quarantines a rise by half or a fall by a third, and a decimal
+// shift exceeds any threshold below 900.
+type AnomalyThresholds map[string]float64
+
+// ParseAnomalyThresholds reads "type=percent" pairs such as
+// "official=20,crypto=50" over the defaults: official 25, blue 40, crypto 50
+// and 25 for other types. Malformed or negative entries are ignored.
+func ParseAnomalyThresholds(spec string) AnomalyThresholds {
+	t := AnomalyThresholds{"official": 25, "blue": 40, "crypto": 50, "default": 25}
+	for _, item := range strings.Split(spec, ",") {
+		name, raw, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || v < 0 {
+			continue
+		}
+		t[strings.ToLower(strings.TrimSpace(name))] = v
+	}
+	return t
+}
+
+// exceeds reports whether moving from previous to next is beyond the
+// threshold for the rate type.
+func (t AnomalyThresholds) exceeds(rateType string, previous, next float64) bool {
+	limit, ok := t[rateType]
+	if !ok {
+		limit = t["default"]
+	}
+	if limit <= 0 || previous <= 0 {
+		return false
+	}
+	ratio := math.Max(next/previous, previous/next)
+	return (ratio-1)*100 > limit
+}
+
+// QuarantinedRate is a synced rate held back for review because it moved too
+// far from the stored rate before it.
+type QuarantinedRate struct {
+	ID            string    `json:"id"`
+	Currency      string    `json:"currency"`
+	Type          string    `json:"type"`
+	Date          time.Time `json:"date"`
+	Rate          float64   `json:"rate"`
+	PreviousRate  float64   `json:"previousRate"`
+	PreviousDate  time.Time `json:"previousDate"`
+	ChangePercent float64   `json:"changePercent"`
+	Source        string    `json:"source,omitempty"`
+}
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// ListQuarantinedRates returns the rates awaiting review, by currency.
+func ListQuarantinedRates(ctx context.Context, database *db.DB) ([]QuarantinedRate, error) {
+	return listQuarantinedRates(ctx, database.Pool)
+}
+
+func listQuarantinedRates(ctx context.Context, q querier) ([]QuarantinedRate, error) {
+	rows, err := q.Query(ctx, `
+		SELECT id, currency, type, date, rate::float8, "previousRate"::float8, "previousDate", COALESCE(source, '')
+		FROM exchange_rate_quarantine
+		ORDER BY currency, type
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantined rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []QuarantinedRate{}
+	for rows.Next() {
+		var r QuarantinedRate
+		if err := rows.Scan(&r.ID, &r.Currency, &r.Type, &r.Date, &r.Rate, &r.PreviousRate, &r.PreviousDate, &r.Source); err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined rate: %w", err)
+		}
+		r.ChangePercent = (r.Rate - r.PreviousRate) / r.PreviousRate * 100
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// quarantineRate holds back a rate, replacing any earlier quarantined rate
+// of its currency and type.
+func quarantineRate(ctx context.Context, tx pgx.Tx, entry ParsedRate, previous storedRate) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO exchange_rate_quarantine (id, date, currency, type, rate, "previousRate", "previousDate", source, "createdAt", "updatedAt")
+		VALUES (gen_random_uuid()::text, $1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (currency, type)
+		DO UPDATE SET date = EXCLUDED.date, rate = EXCLUDED.rate, "previousRate" = EXCLUDED."previousRate",
+			"previousDate" = EXCLUDED."previousDate", source = EXCLUDED.source, "updatedAt" = NOW()
+	`, entry.Date, entry.Currency, entry.Type, entry.Rate, previous.Rate, previous.Date, entry.Source)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine rate: %w", err)
+	}
+	return nil
+}
+
+// ResolveQuarantinedRates approves or rejects quarantined rates by id. An
+// approved rate is stored as if it had passed the check; a rejected one is
+// dropped, leaving the previous rate current. Returns how many were resolved.
+func ResolveQuarantinedRates(ctx context.Context, database *db.DB, ids []string, approve bool) (int, error) {
+	tx, err := database.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		DELETE FROM exchange_rate_quarantine
+		WHERE id = ANY($1)
+		RETURNING date, currency, type, rate::float8, COALESCE(source, '')
+	`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve quarantined rates: %w", err)
+	}
+	var resolved []ParsedRate
+	for rows.Next() {
+		var r ParsedRate
+		if err := rows.Scan(&r.Date, &r.Currency, &r.Type, &r.Rate, &r.Source); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan quarantined rate: %w", err)
+		}
+		resolved = append(resolved, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to resolve quarantined rates: %w", err)
+	}
+
+	if approve {
+		for _, entry := range resolved {
+			if _, err := upsertRate(ctx, tx, entry); err != nil {
+				return 0, err
+			}
+		}
+		if err := repointFavorites(ctx, tx); err != nil {
+			return 0, err
+		}
+	}
+	if err := updateQuarantineStatus(ctx, tx); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(resolved), nil
+}
+
+// updateQuarantineStatus publishes the quarantined rates to system_status,
+// where the admin dashboard reads them.
+func updateQuarantineStatus(ctx context.Context, q querier) error {
+	rates, err := listQuarantinedRates(ctx, q)
+	if err != nil {
+		return err
+	}
+	currencies := []string{}
+	for _, r := range rates {
+		if !slices.Contains(currencies, r.Currency) {
+			currencies = append(currencies, r.Currency)
+		}
+	}
+	value, err := json.Marshal(map[string]any{
+		"currencies": currencies,
+		"rates":      rates,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine status: %w", err)
+	}
+
+	_, err = q.Exec(ctx, `
+		INSERT INTO system_status (key, value, "updatedAt")
+		VALUES ($1, $2::jsonb, NOW())
+		ON CONFLICT (key)
+		DO UPDATE SET value = $2::jsonb, "updatedAt" = NOW()
+	`, quarantineStatusKey, string(value))
+	if err != nil {
+		return fmt.Errorf("failed to update quarantine status: %w", err)
+	}
+	return nil
+}
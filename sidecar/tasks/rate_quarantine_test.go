@@ -0,0 +1,51 @@
+package tasks
+
+import "testing"
+
+// ── AnomalyThresholds ─────────────────────────────────────────────────────────
+
+func TestParseAnomalyThresholds(t *testing.T) {
+	th := ParseAnomalyThresholds(" Blue=10 ,crypto=0,official=x,gold=-5,junk")
+	if th["blue"] != 10 {
+		t.Errorf("expected blue override of 10, got %v", th["blue"])
+	}
+	if th["crypto"] != 0 {
+		t.Errorf("expected crypto check turned off, got %v", th["crypto"])
+	}
+	if th["official"] != 25 {
+		t.Errorf("expected malformed override to keep the default, got %v", th["official"])
+	}
+	if _, ok := th["gold"]; ok {
+		t.Error("expected negative threshold to be ignored")
+	}
+}
+
+func TestAnomalyThresholds_Exceeds(t *testing.T) {
+	th := ParseAnomalyThresholds("")
+	cases := []struct {
+		rateType       string
+		previous, next float64
+		want           bool
+	}{
+		{"official", 1000, 1100, false}, // +10%
+		{"official", 1000, 10000, true}, // Decimal shift up
+		{"official", 1000, 100, true},   // Decimal shift down
+		{"blue", 1000, 1350, false},     // +35% within blue's 40%
+		{"crypto", 60000, 0.0001, true}, // Broken price
+		{"crypto", 60000, 45000, false}, // -25%, a third of the new price
+		{"official", 1, 0.7, true},      // -30%, a 43% move measured from the new rate
+		{"ars_mep", 1000, 1300, true},   // Unlisted type uses the default
+		{"official", 0, 1000, false},    // No usable previous rate
+	}
+	for _, c := range cases {
+		if got := th.exceeds(c.rateType, c.previous, c.next); got != c.want {
+			t.Errorf("exceeds(%s, %v, %v) = %t, want %t", c.rateType, c.previous, c.next, got, c.want)
+		}
+	}
+}
+
+func TestAnomalyThresholds_ZeroDisables(t *testing.T) {
+	if ParseAnomalyThresholds("official=0").exceeds("official", 1, 1000) {
+		t.Error("expected a zero threshold to turn the check off")
+	}
+}
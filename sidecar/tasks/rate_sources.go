@@ -0,0 +1,558 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"retrospend-sidecar/currencies"
+)
+
+const (
+	defaultOracleURL     = "https://raw.githubusercontent.com/syntheit/exchange-rates/refs/heads/main/rates.json"
+	defaultECBURL        = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	defaultECB90DayURL   = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+	defaultECBHistoryURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml"
+	maxSourceBytes       = 10 << 20
+	maxHistoryBytes      = 64 << 20 // The ECB's full history is about 10MB
+)
+
+// RateSource is a provider of exchange rates for SyncExchangeRates. Rates are
+// units of the currency per US dollar, except crypto rates, which are US
+// dollars per coin.
+type RateSource interface {
+	Name() string
+	Fetch(ctx context.Context) ([]ParsedRate, error)
+}
+
+// HistoricalRateSource is a RateSource that can also return past rates, for
+// BackfillExchangeRates. FetchHistory passes the rates of the requested
+// dates to emit, in one or several calls, and returns errNoHistory when this
+// configuration of the source cannot look back.
+type HistoricalRateSource interface {
+	RateSource
+	FetchHistory(ctx context.Context, dates []time.Time, emit func([]ParsedRate) error) error
+}
+
+var errNoHistory = errors.New("source does not provide historical rates")
+
+// RateSourceOptions configures the sources built by NewRateSources. Empty
+// URLs use the public defaults.
+type RateSourceOptions struct {
+	OracleURL    string
+	ECBURL       string
+	JSONLocation string // URL or file path of the generic JSON source
+	JSONMapping  string // JSON-encoded JSONMapping
+	FileDir      string // Directory watched by the file drop source
+}
+
+// NewRateSources builds the named sources ("oracle", "ecb", "json", "file")
+// in priority order.
+func NewRateSources(names []string, opts RateSourceOptions) ([]RateSource, error) {
+	var sources []RateSource
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "oracle":
+			sources = append(sources, OracleSource{URL: opts.OracleURL})
+		case "ecb":
+			sources = append(sources, ECBSource{URL: opts.ECBURL})
+		case "json":
+			if opts.JSONLocation == "" {
+				return nil, fmt.Errorf("json rate source needs a URL or file path")
+			}
+			var mapping JSONMapping
+			if err := json.Unmarshal([]byte(opts.JSONMapping), &mapping); err != nil {
+				return nil, fmt.Errorf("invalid json rate source mapping: %w", err)
+			}
+			if mapping.Rates == "" {
+				return nil, fmt.Errorf("json rate source mapping needs a rates path")
+			}
+			sources = append(sources, JSONSource{Location: opts.JSONLocation, Mapping: mapping})
+		case "file":
+			if opts.FileDir == "" {
+				return nil, fmt.Errorf("file rate source needs a directory")
+			}
+			sources = append(sources, FileSource{Dir: opts.FileDir})
+		default:
+			return nil, fmt.Errorf("unknown rate source %q", name)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no rate sources configured")
+	}
+	return sources, nil
+}
+
+// fetchRates queries the sources in priority order and merges their rates:
+// each currency and type comes from the first source that has it. complete
+// is false when a source failed, so a rate missing from the result may only
+// be unavailable for now.
+func fetchRates(ctx context.Context, sources []RateSource) (rates []ParsedRate, complete bool, err error) {
+	complete = true
+	seen := make(map[string]bool)
+	var errs []error
+	for _, source := range sources {
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		entries, err := source.Fetch(fetchCtx)
+		cancel()
+		if err != nil {
+			log.Printf("[EXCHANGE_RATES] Source %s failed: %v", source.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			complete = false
+			continue
+		}
+
+		added := 0
+		for _, entry := range entries {
+			entry, ok := cleanRate(entry)
+			if !ok {
+				continue
+			}
+			key := entry.Currency + "_" + entry.Type
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			entry.Source = source.Name()
+			rates = append(rates, entry)
+			added++
+		}
+		log.Printf("[EXCHANGE_RATES] Source %s: %d rates (%d used)", source.Name(), len(entries), added)
+	}
+
+	if len(rates) == 0 {
+		if len(errs) > 0 {
+			return nil, false, fmt.Errorf("all rate sources failed: %w", errors.Join(errs...))
+		}
+		return nil, false, fmt.Errorf("no valid rate entries found")
+	}
+	return rates, complete, nil
+}
+
+// cleanRate normalizes a source's rate, reporting false for one that is
+// invalid or for an unsupported crypto.
+func cleanRate(entry ParsedRate) (ParsedRate, bool) {
+	entry.Currency = strings.ToUpper(entry.Currency)
+	entry.Type = strings.ToLower(entry.Type)
+	if !validCurrency.MatchString(entry.Currency) || !validType.MatchString(entry.Type) || !(entry.Rate > 0) {
+		return entry, false
+	}
+	return entry, rateSupported(entry.Currency, entry.Type)
+}
+
+// rateSupported reports whether a currency/type pair is synced. Only the
+// cryptos in crypto_currency.json are; the sync deletes the stored rates of
+// the others, see gonePairs. Without the list, every crypto is kept.
+func rateSupported(currency, rateType string) bool {
+	return rateType != "crypto" || !currencies.CryptoLoaded() || currencies.IsCrypto(currency)
+}
+
+// ── Oracle ────────────────────────────────────────────────────────────────────
+
+// OracleSource reads the rates.json published by syntheit/exchange-rates,
+// which includes parallel-market ("blue") and crypto rates.
+type OracleSource struct {
+	URL string
+}
+
+func (s OracleSource) Name() string { return "oracle" }
+
+func (s OracleSource) Fetch(ctx context.Context) ([]ParsedRate, error) {
+	url := s.URL
+	if url == "" {
+		url = defaultOracleURL
+	}
+	body, err := fetchLocation(ctx, url, maxSourceBytes)
+	if err != nil {
+		return nil, err
+	}
+	return parseOracleRates(body, time.Now().UTC())
+}
+
+// parseOracleRates parses the oracle format. fallback dates the rates when
+// the document's updatedAt is unreadable.
+func parseOracleRates(body []byte, fallback time.Time) ([]ParsedRate, error) {
+	var data OracleRatesResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if data.Rates == nil && data.CryptoRates == nil {
+		return nil, fmt.Errorf("invalid response: missing both rates and cryptoRates objects")
+	}
+
+	date, err := time.Parse(time.RFC3339, data.UpdatedAt)
+	if err != nil {
+		log.Printf("[EXCHANGE_RATES] Warning: failed to parse UpdatedAt '%s': %v. Using %s.", data.UpdatedAt, err, fallback.Format(time.DateOnly))
+		date = fallback
+	}
+	date = date.UTC().Truncate(24 * time.Hour)
+
+	var rates []ParsedRate
+	for key, rate := range data.Rates {
+		currency, rateType := parseRateKey(key)
+		rates = append(rates, ParsedRate{Currency: currency, Type: rateType, Rate: rate, Date: date})
+	}
+	for currency, rate := range data.CryptoRates {
+		// Stored as-is (USD per coin) to avoid precision loss
+		rates = append(rates, ParsedRate{Currency: currency, Type: "crypto", Rate: rate, Date: date})
+	}
+	return rates, nil
+}
+
+// ── ECB ───────────────────────────────────────────────────────────────────────
+
+// ECBSource reads the European Central Bank's daily reference rates, about
+// thirty official rates quoted against the euro.
+type ECBSource struct {
+	URL        string
+	HistoryURL string // Used for backfills; default the ECB's full or 90-day history
+}
+
+func (s ECBSource) Name() string { return "ecb" }
+
+func (s ECBSource) Fetch(ctx context.Context) ([]ParsedRate, error) {
+	url := s.URL
+	if url == "" {
+		url = defaultECBURL
+	}
+	body, err := fetchLocation(ctx, url, maxSourceBytes)
+	if err != nil {
+		return nil, err
+	}
+	return parseECBRates(body)
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// parseECBRates parses eurofxref XML, taking the latest day it contains.
+func parseECBRates(body []byte) ([]ParsedRate, error) {
+	days, err := parseECBDays(body)
+	if err != nil {
+		return nil, err
+	}
+	var latest time.Time
+	for date := range days {
+		if date.After(latest) {
+			latest = date
+		}
+	}
+	return days[latest], nil
+}
+
+// parseECBDays parses eurofxref XML into the rates per US dollar of each day
+// it contains.
+func parseECBDays(body []byte) (map[time.Time][]ParsedRate, error) {
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	days := make(map[time.Time][]ParsedRate, len(envelope.Days))
+	for _, day := range envelope.Days {
+		date, err := time.Parse(time.DateOnly, day.Time)
+		if err != nil {
+			continue
+		}
+		perEUR := map[string]float64{"EUR": 1}
+		for _, r := range day.Rates {
+			perEUR[r.Currency] = r.Rate
+		}
+		perUSD, err := rebaseToUSD(perEUR, "EUR")
+		if err != nil {
+			continue
+		}
+		rates := make([]ParsedRate, 0, len(perUSD))
+		for currency, rate := range perUSD {
+			rates = append(rates, ParsedRate{Currency: currency, Type: "official", Rate: rate, Date: date})
+		}
+		days[date] = rates
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("invalid response: no dated rates")
+	}
+	return days, nil
+}
+
+// FetchHistory reads the ECB's history file, the 90-day one when it reaches
+// back far enough, and emits the rates of the requested dates at once. The
+// ECB publishes no rates on weekends and TARGET holidays.
+func (s ECBSource) FetchHistory(ctx context.Context, dates []time.Time, emit func([]ParsedRate) error) error {
+	url := s.HistoryURL
+	if url == "" {
+		url = defaultECBHistoryURL
+		if earliest := slices.MinFunc(dates, time.Time.Compare); time.Since(earliest) < 85*24*time.Hour {
+			url = defaultECB90DayURL
+		}
+	}
+	body, err := fetchLocation(ctx, url, maxHistoryBytes)
+	if err != nil {
+		return err
+	}
+	days, err := parseECBDays(body)
+	if err != nil {
+		return err
+	}
+	var rates []ParsedRate
+	for _, date := range dates {
+		rates = append(rates, days[date]...)
+	}
+	return emit(rates)
+}
+
+// rebaseToUSD converts rates quoted in units per base currency to units per
+// US dollar. The quotes must include USD.
+func rebaseToUSD(rates map[string]float64, base string) (map[string]float64, error) {
+	if base == "USD" {
+		return rates, nil
+	}
+	usd := rates["USD"]
+	if !(usd > 0) {
+		return nil, fmt.Errorf("rates quoted in %s have no USD rate to convert with", base)
+	}
+	perUSD := make(map[string]float64, len(rates))
+	for currency, rate := range rates {
+		if currency != "USD" {
+			perUSD[currency] = rate / usd
+		}
+	}
+	perUSD[base] = 1 / usd
+	return perUSD, nil
+}
+
+// ── Generic JSON ──────────────────────────────────────────────────────────────
+
+// JSONMapping locates the rates in an arbitrary JSON document. Paths are
+// dot-separated object keys, e.g. "data.rates".
+type JSONMapping struct {
+	Rates  string `json:"rates"`            // Object of currency code to rate
+	Date   string `json:"date,omitempty"`   // RFC 3339 or YYYY-MM-DD string, or Unix seconds; default today
+	Base   string `json:"base,omitempty"`   // Currency the rates are quoted against; default USD
+	Type   string `json:"type,omitempty"`   // Rate type; default official
+	Crypto bool   `json:"crypto,omitempty"` // Rates are US dollars per coin
+}
+
+// JSONSource reads rates from a JSON document at a URL or file path, laid
+// out as described by its mapping. A location containing "{date}" is filled
+// with the day wanted as YYYY-MM-DD, which also lets the source backfill.
+type JSONSource struct {
+	Location string
+	Mapping  JSONMapping
+}
+
+func (s JSONSource) Name() string { return "json" }
+
+func (s JSONSource) Fetch(ctx context.Context) ([]ParsedRate, error) {
+	return s.fetchDate(ctx, time.Now().UTC())
+}
+
+func (s JSONSource) fetchDate(ctx context.Context, date time.Time) ([]ParsedRate, error) {
+	location := strings.ReplaceAll(s.Location, "{date}", date.Format(time.DateOnly))
+	body, err := fetchLocation(ctx, location, maxSourceBytes)
+	if err != nil {
+		return nil, err
+	}
+	return s.Mapping.parse(body, date)
+}
+
+// FetchHistory fetches the document of each date in turn, emitting each
+// day's rates as it arrives. Dates the source has no document for are
+// skipped.
+func (s JSONSource) FetchHistory(ctx context.Context, dates []time.Time, emit func([]ParsedRate) error) error {
+	if !strings.Contains(s.Location, "{date}") {
+		return errNoHistory
+	}
+	for _, date := range dates {
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		rates, err := s.fetchDate(fetchCtx, date)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("[EXCHANGE_RATES] Source json has no rates for %s: %v", date.Format(time.DateOnly), err)
+			continue
+		}
+		if err := emit(rates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse reads the mapped rates; day dates them when the mapping has no date.
+func (m JSONMapping) parse(body []byte, day time.Time) ([]ParsedRate, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	object, ok := lookupPath(doc, m.Rates).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("no rates object at %q", m.Rates)
+	}
+	quotes := make(map[string]float64, len(object))
+	for currency, value := range object {
+		if rate, ok := jsonNumber(value); ok {
+			quotes[strings.ToUpper(currency)] = rate
+		}
+	}
+
+	date := day
+	if m.Date != "" {
+		parsed, err := jsonDate(lookupPath(doc, m.Date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date at %q: %w", m.Date, err)
+		}
+		date = parsed
+	}
+	date = date.UTC().Truncate(24 * time.Hour)
+
+	rateType := strings.ToLower(m.Type)
+	if m.Crypto {
+		rateType = "crypto"
+	} else if rateType == "" {
+		rateType = "official"
+	}
+
+	base := strings.ToUpper(m.Base)
+	if base == "" {
+		base = "USD"
+	}
+	if m.Crypto && base != "USD" {
+		return nil, fmt.Errorf("crypto rates must be quoted in USD, not %s", base)
+	}
+	quotes, err := rebaseToUSD(quotes, base)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]ParsedRate, 0, len(quotes))
+	for currency, rate := range quotes {
+		rates = append(rates, ParsedRate{Currency: currency, Type: rateType, Rate: rate, Date: date})
+	}
+	return rates, nil
+}
+
+func lookupPath(doc any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		doc = object[key]
+	}
+	return doc
+}
+
+func jsonNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jsonDate(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, v)
+	}
+	return time.Time{}, fmt.Errorf("unexpected value %v", value)
+}
+
+// ── File drop ─────────────────────────────────────────────────────────────────
+
+// FileSource reads the newest *.json file dropped into a directory, in the
+// oracle format. It lets an operator supply rates by hand when no feed
+// covers a currency or every feed is down. Files without a readable
+// updatedAt are dated by their modification time.
+type FileSource struct {
+	Dir string
+}
+
+func (s FileSource) Name() string { return "file" }
+
+func (s FileSource) Fetch(ctx context.Context) ([]ParsedRate, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var newest string
+	var modified time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.ModTime().After(modified) {
+			newest, modified = path, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return nil, fmt.Errorf("no rate files in %s", s.Dir)
+	}
+
+	body, err := fetchLocation(ctx, newest, maxSourceBytes)
+	if err != nil {
+		return nil, err
+	}
+	return parseOracleRates(body, modified.UTC())
+}
+
+// fetchLocation reads an http(s) URL or a local file, up to limit bytes.
+func fetchLocation(ctx context.Context, location string, limit int64) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
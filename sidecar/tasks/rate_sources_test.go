@@ -0,0 +1,184 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type stubSource struct {
+	name  string
+	rates []ParsedRate
+	err   error
+}
+
+func (s stubSource) Name() string { return s.name }
+
+func (s stubSource) Fetch(context.Context) ([]ParsedRate, error) { return s.rates, s.err }
+
+func rateOf(rates []ParsedRate, currency, rateType string) (ParsedRate, bool) {
+	for _, r := range rates {
+		if r.Currency == currency && r.Type == rateType {
+			return r, true
+		}
+	}
+	return ParsedRate{}, false
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+// ── fetchRates ────────────────────────────────────────────────────────────────
+
+func TestFetchRates_EarlierSourceWinsPerCurrency(t *testing.T) {
+	rates, complete, err := fetchRates(context.Background(), []RateSource{
+		stubSource{name: "oracle", rates: []ParsedRate{{Currency: "ars", Type: "BLUE", Rate: 1200}, {Currency: "EUR", Type: "official", Rate: 0.9}}},
+		stubSource{name: "ecb", rates: []ParsedRate{{Currency: "EUR", Type: "official", Rate: 0.95}, {Currency: "CHF", Type: "official", Rate: 0.88}}},
+	})
+	if err != nil || !complete {
+		t.Fatalf("unexpected result: complete=%t err=%v", complete, err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("expected 3 rates, got %d", len(rates))
+	}
+	if r, _ := rateOf(rates, "EUR", "official"); r.Rate != 0.9 || r.Source != "oracle" {
+		t.Errorf("expected EUR from oracle, got %+v", r)
+	}
+	if r, _ := rateOf(rates, "CHF", "official"); r.Source != "ecb" {
+		t.Errorf("expected CHF from ecb, got %+v", r)
+	}
+	if _, ok := rateOf(rates, "ARS", "blue"); !ok {
+		t.Error("expected the ARS blue rate to be normalized and kept")
+	}
+}
+
+func TestFetchRates_FailoverIsIncomplete(t *testing.T) {
+	rates, complete, err := fetchRates(context.Background(), []RateSource{
+		stubSource{name: "oracle", err: errors.New("timeout")},
+		stubSource{name: "ecb", rates: []ParsedRate{{Currency: "EUR", Type: "official", Rate: 0.95}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if complete {
+		t.Error("expected a failed source to leave the sync incomplete")
+	}
+	if r, _ := rateOf(rates, "EUR", "official"); r.Source != "ecb" {
+		t.Errorf("expected EUR from ecb, got %+v", r)
+	}
+}
+
+func TestFetchRates_AllSourcesFail(t *testing.T) {
+	_, _, err := fetchRates(context.Background(), []RateSource{
+		stubSource{name: "oracle", err: errors.New("timeout")},
+	})
+	if err == nil {
+		t.Fatal("expected an error when every source fails")
+	}
+}
+
+func TestFetchRates_SkipsInvalidAndUnsupported(t *testing.T) {
+	rates, _, err := fetchRates(context.Background(), []RateSource{
+		stubSource{name: "oracle", rates: []ParsedRate{
+			{Currency: "EUR", Type: "official", Rate: 0},
+			{Currency: "E1", Type: "official", Rate: 1},
+			{Currency: "SHIB", Type: "crypto", Rate: 0.00001},
+			{Currency: "BTC", Type: "crypto", Rate: 60000},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rates) != 1 || rates[0].Currency != "BTC" {
+		t.Errorf("expected only BTC, got %+v", rates)
+	}
+}
+
+// ── Parsers ───────────────────────────────────────────────────────────────────
+
+func TestParseOracleRates(t *testing.T) {
+	body := []byte(`{"updatedAt":"2026-03-04T09:00:00Z","base":"USD","rates":{"ARS_blue":1200,"EUR":0.9},"cryptoRates":{"BTC":60000}}`)
+	rates, err := parseOracleRates(body, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, ok := rateOf(rates, "ARS", "blue")
+	if !ok || !r.Date.Equal(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected ARS blue dated 2026-03-04, got %+v", r)
+	}
+	if _, ok := rateOf(rates, "BTC", "crypto"); !ok {
+		t.Error("expected a BTC crypto rate")
+	}
+}
+
+func TestParseECBRates_RebasesToUSD(t *testing.T) {
+	body := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-03-04">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="JPY" rate="150"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`)
+	rates, err := parseECBRates(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r, _ := rateOf(rates, "EUR", "official"); !near(r.Rate, 0.8) {
+		t.Errorf("expected 0.8 EUR per USD, got %v", r.Rate)
+	}
+	if r, _ := rateOf(rates, "JPY", "official"); !near(r.Rate, 120) {
+		t.Errorf("expected 120 JPY per USD, got %v", r.Rate)
+	}
+	if _, ok := rateOf(rates, "USD", "official"); ok {
+		t.Error("expected no USD rate")
+	}
+	if r, _ := rateOf(rates, "EUR", "official"); r.Date.Format(time.DateOnly) != "2026-03-04" {
+		t.Errorf("expected the cube's date, got %v", r.Date)
+	}
+}
+
+func TestJSONMapping_NestedPathsAndBase(t *testing.T) {
+	mapping := JSONMapping{Rates: "data.quotes", Date: "data.asOf", Base: "eur", Type: "Blue"}
+	body := []byte(`{"data":{"asOf":"2026-03-04","quotes":{"USD":"1.25","ars":1500}}}`)
+	rates, err := mapping.parse(body, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r, _ := rateOf(rates, "ARS", "blue"); !near(r.Rate, 1200) || r.Date.Format(time.DateOnly) != "2026-03-04" {
+		t.Errorf("expected 1200 ARS per USD on 2026-03-04, got %+v", r)
+	}
+	if r, _ := rateOf(rates, "EUR", "blue"); !near(r.Rate, 0.8) {
+		t.Errorf("expected 0.8 EUR per USD, got %v", r.Rate)
+	}
+}
+
+func TestJSONMapping_MissingRates(t *testing.T) {
+	if _, err := (JSONMapping{Rates: "rates"}).parse([]byte(`{"data":{}}`), time.Now()); err == nil {
+		t.Error("expected an error for a missing rates object")
+	}
+}
+
+// ── NewRateSources ────────────────────────────────────────────────────────────
+
+func TestNewRateSources(t *testing.T) {
+	sources, err := NewRateSources([]string{"ORACLE", "ecb", "json"}, RateSourceOptions{
+		JSONLocation: "/srv/rates.json",
+		JSONMapping:  `{"rates":"rates"}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sources) != 3 || sources[0].Name() != "oracle" || sources[2].Name() != "json" {
+		t.Errorf("unexpected sources: %+v", sources)
+	}
+	if _, err := NewRateSources([]string{"file"}, RateSourceOptions{}); err == nil {
+		t.Error("expected the file source to need a directory")
+	}
+	if _, err := NewRateSources([]string{"fixer"}, RateSourceOptions{}); err == nil {
+		t.Error("expected an unknown source to be rejected")
+	}
+}
@@ -0,0 +1,129 @@
+package tasks
+
+import "time"
+
+// RecurrenceRule describes when a recurring template falls due. Monthly
+// rules remember the day they aim for, so a template due on the 31st is due
+// on the 30th in April and the 28th or 29th in February, and back on the
+// 31st in May, rather than drifting to whichever day the shorter month left
+// it on.
+type RecurrenceRule struct {
+	Frequency      string     // DAILY, WEEKLY, BIWEEKLY, MONTHLY, QUARTERLY, YEARLY, SEMI_MONTHLY or LAST_BUSINESS_DAY
+	Interval       int        // Every N periods of Frequency; below 1 means 1
+	AnchorDay      int        // Day of month monthly rules aim for; 0 takes the current due date's day
+	EndDate        *time.Time // Last day an occurrence may fall on
+	MaxOccurrences int        // Occurrences after which the rule ends; 0 for no limit
+}
+
+// Anchor returns the day of month the rule aims for when advancing from
+// current, which is what a template stores to keep clamped months from
+// moving its due day.
+func (r RecurrenceRule) Anchor(current time.Time) int {
+	if r.AnchorDay >= 1 && r.AnchorDay <= 31 {
+		return r.AnchorDay
+	}
+	return current.Day()
+}
+
+// UsesAnchor reports whether the rule aims for a day of month, which is the
+// case for MONTHLY, QUARTERLY and YEARLY.
+func (r RecurrenceRule) UsesAnchor() bool {
+	switch r.Frequency {
+	case "MONTHLY", "QUARTERLY", "YEARLY":
+		return true
+	}
+	return false
+}
+
+func (r RecurrenceRule) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// Next returns the occurrence after current, keeping its time of day.
+// Unknown frequencies advance by a month.
+func (r RecurrenceRule) Next(current time.Time) time.Time {
+	n := r.interval()
+	switch r.Frequency {
+	case "DAILY":
+		return current.AddDate(0, 0, n)
+	case "WEEKLY":
+		return current.AddDate(0, 0, 7*n)
+	case "BIWEEKLY":
+		return current.AddDate(0, 0, 14*n)
+	case "QUARTERLY":
+		return addMonthsClamped(current, 3*n, r.Anchor(current))
+	case "YEARLY":
+		return addMonthsClamped(current, 12*n, r.Anchor(current))
+	case "SEMI_MONTHLY":
+		// The 1st and the 15th; the interval does not apply
+		if current.Day() < 15 {
+			return withDay(current, 15)
+		}
+		return addMonthsClamped(current, 1, 1)
+	case "LAST_BUSINESS_DAY":
+		return lastBusinessDay(addMonthsClamped(current, n, 1))
+	default:
+		return addMonthsClamped(current, n, r.Anchor(current))
+	}
+}
+
+// Ended reports whether an occurrence on date is past the rule's end, given
+// that done occurrences have already been generated.
+func (r RecurrenceRule) Ended(date time.Time, done int) bool {
+	if r.MaxOccurrences > 0 && done >= r.MaxOccurrences {
+		return true
+	}
+	return r.EndDate != nil && dateOnly(date).After(dateOnly(*r.EndDate))
+}
+
+// Preview lists up to count occurrences starting with next, the template's
+// current due date, stopping early where the rule ends. done is the number
+// of occurrences already generated.
+func (r RecurrenceRule) Preview(next time.Time, done, count int) []time.Time {
+	var dates []time.Time
+	if r.AnchorDay == 0 {
+		r.AnchorDay = r.Anchor(next)
+	}
+	for len(dates) < count && !r.Ended(next, done) {
+		dates = append(dates, next)
+		done++
+		next = r.Next(next)
+	}
+	return dates
+}
+
+// addMonthsClamped moves t by months to the given day, or to the last day of
+// the target month when it is shorter.
+func addMonthsClamped(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	return withDay(first, day)
+}
+
+// withDay moves t to day within its month, clamped to the month's length.
+func withDay(t time.Time, day int) time.Time {
+	if last := daysIn(t.Year(), t.Month()); day > last {
+		day = last
+	}
+	return time.Date(t.Year(), t.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// lastBusinessDay returns the last weekday of t's month. Holidays are not
+// taken into account.
+func lastBusinessDay(t time.Time) time.Time {
+	d := withDay(t, 31)
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func formatDays(dates []time.Time) []string {
+	out := make([]string, len(dates))
+	for i, d := range dates {
+		out[i] = d.Format(time.DateOnly)
+	}
+	return out
+}
+
+func assertDays(t *testing.T, got []time.Time, want ...string) {
+	t.Helper()
+	g := formatDays(got)
+	if len(g) != len(want) {
+		t.Fatalf("expected %v, got %v", want, g)
+	}
+	for i := range want {
+		if g[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, g)
+		}
+	}
+}
+
+// ── RecurrenceRule.Next ───────────────────────────────────────────────────────
+
+func TestRecurrenceRule_MonthlyKeepsAnchorDay(t *testing.T) {
+	rule := RecurrenceRule{Frequency: "MONTHLY"}
+	assertDays(t, rule.Preview(day("2027-01-31"), 0, 5),
+		"2027-01-31", "2027-02-28", "2027-03-31", "2027-04-30", "2027-05-31")
+}
+
+func TestRecurrenceRule_StoredAnchorOverridesDueDay(t *testing.T) {
+	// A template already clamped to the 28th keeps aiming for the 31st
+	rule := RecurrenceRule{Frequency: "MONTHLY", AnchorDay: 31}
+	if got := rule.Next(day("2027-02-28")); !got.Equal(day("2027-03-31")) {
+		t.Errorf("expected 2027-03-31, got %s", got.Format(time.DateOnly))
+	}
+}
+
+func TestRecurrenceRule_YearlyLeapDay(t *testing.T) {
+	rule := RecurrenceRule{Frequency: "YEARLY"}
+	assertDays(t, rule.Preview(day("2028-02-29"), 0, 3), "2028-02-29", "2029-02-28", "2030-02-28")
+	assertDays(t, RecurrenceRule{Frequency: "YEARLY", Interval: 4}.Preview(day("2028-02-29"), 0, 2), "2028-02-29", "2032-02-29")
+}
+
+func TestRecurrenceRule_Intervals(t *testing.T) {
+	cases := []struct {
+		rule RecurrenceRule
+		want []string
+	}{
+		{RecurrenceRule{Frequency: "DAILY", Interval: 3}, []string{"2027-01-30", "2027-02-02", "2027-02-05"}},
+		{RecurrenceRule{Frequency: "WEEKLY", Interval: 2}, []string{"2027-01-30", "2027-02-13", "2027-02-27"}},
+		{RecurrenceRule{Frequency: "BIWEEKLY"}, []string{"2027-01-30", "2027-02-13", "2027-02-27"}},
+		{RecurrenceRule{Frequency: "MONTHLY", Interval: 2}, []string{"2027-01-30", "2027-03-30", "2027-05-30"}},
+		{RecurrenceRule{Frequency: "QUARTERLY"}, []string{"2027-01-30", "2027-04-30", "2027-07-30"}},
+		{RecurrenceRule{Frequency: "DAILY", Interval: 0}, []string{"2027-01-30", "2027-01-31", "2027-02-01"}},
+	}
+	for _, c := range cases {
+		assertDays(t, c.rule.Preview(day("2027-01-30"), 0, 3), c.want...)
+	}
+}
+
+func TestRecurrenceRule_SemiMonthly(t *testing.T) {
+	rule := RecurrenceRule{Frequency: "SEMI_MONTHLY"}
+	assertDays(t, rule.Preview(day("2027-01-15"), 0, 4), "2027-01-15", "2027-02-01", "2027-02-15", "2027-03-01")
+	// A start between the two days moves onto the schedule
+	assertDays(t, rule.Preview(day("2027-01-20"), 0, 2), "2027-01-20", "2027-02-01")
+}
+
+func TestRecurrenceRule_LastBusinessDay(t *testing.T) {
+	// Jan 29 2027 is a Friday; Feb 26 a Friday; Apr 30 a Friday; May 31 a Monday
+	rule := RecurrenceRule{Frequency: "LAST_BUSINESS_DAY"}
+	assertDays(t, rule.Preview(day("2027-01-29"), 0, 4), "2027-01-29", "2027-02-26", "2027-03-31", "2027-04-30")
+	assertDays(t, RecurrenceRule{Frequency: "LAST_BUSINESS_DAY", Interval: 4}.Preview(day("2027-01-29"), 0, 2), "2027-01-29", "2027-05-31")
+}
+
+func TestRecurrenceRule_KeepsTimeOfDay(t *testing.T) {
+	start := time.Date(2027, 1, 31, 9, 30, 0, 0, time.UTC)
+	got := RecurrenceRule{Frequency: "MONTHLY"}.Next(start)
+	if want := time.Date(2027, 2, 28, 9, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+// ── RecurrenceRule end conditions ─────────────────────────────────────────────
+
+func TestRecurrenceRule_EndDateIsInclusive(t *testing.T) {
+	end := day("2027-03-15")
+	rule := RecurrenceRule{Frequency: "MONTHLY", EndDate: &end}
+	start := time.Date(2027, 1, 15, 18, 0, 0, 0, time.UTC)
+	assertDays(t, rule.Preview(start, 0, 10), "2027-01-15", "2027-02-15", "2027-03-15")
+}
+
+func TestRecurrenceRule_MaxOccurrencesCountsDone(t *testing.T) {
+	rule := RecurrenceRule{Frequency: "WEEKLY", MaxOccurrences: 5}
+	assertDays(t, rule.Preview(day("2027-01-01"), 3, 10), "2027-01-01", "2027-01-08")
+	if !rule.Ended(day("2027-01-15"), 5) {
+		t.Error("expected rule to end after 5 occurrences")
+	}
+	if rule.Ended(day("2030-01-01"), 4) {
+		t.Error("expected rule without end date to continue")
+	}
+}
+
+func TestRecurringTemplate_Rule(t *testing.T) {
+	anchor, limit := 31, 12
+	rule := RecurringTemplate{Frequency: "MONTHLY", Interval: 2, AnchorDay: &anchor, MaxOccurrences: &limit}.Rule()
+	if rule.Interval != 2 || rule.AnchorDay != 31 || rule.MaxOccurrences != 12 || rule.EndDate != nil {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+	if rule := (RecurringTemplate{Frequency: "WEEKLY"}).Rule(); rule.AnchorDay != 0 || rule.MaxOccurrences != 0 {
+		t.Errorf("expected unset limits to be zero, got %+v", rule)
+	}
+}
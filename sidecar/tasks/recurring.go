@@ -7,60 +7,45 @@ import (
 	"time"
 
 	"retrospend-sidecar/db"
+
+	"github.com/shopspring/decimal"
 )
 
 type RecurringTemplate struct {
-	ID          string
-	UserID      string
-	Name        string
-	Amount      float64
-	Currency    string
-	CategoryID  *string
-	Frequency   string
-	NextDueDate time.Time
-}
-
-func calculateNextDueDate(current time.Time, frequency string) time.Time {
-	next := current
-	switch frequency {
-	case "WEEKLY":
-		next = next.AddDate(0, 0, 7)
-	case "BIWEEKLY":
-		next = next.AddDate(0, 0, 14)
-	case "MONTHLY":
-		next = next.AddDate(0, 1, 0)
-	case "QUARTERLY":
-		next = next.AddDate(0, 3, 0)
-	case "YEARLY":
-		next = next.AddDate(1, 0, 0)
-	}
-	return next
+	ID              string
+	UserID          string
+	Name            string
+	Amount          float64
+	Currency        string
+	CategoryID      *string
+	Frequency       string
+	NextDueDate     time.Time
+	Interval        int
+	AnchorDay       *int
+	EndDate         *time.Time
+	MaxOccurrences  *int
+	OccurrenceCount int
 }
 
-func getBestExchangeRate(ctx context.Context, database *db.DB, currency string, date time.Time) (float64, string, error) {
-	if currency == "USD" {
-		return 1.0, "official", nil
+// Rule returns the template's recurrence rule.
+func (t RecurringTemplate) Rule() RecurrenceRule {
+	rule := RecurrenceRule{Frequency: t.Frequency, Interval: t.Interval, EndDate: t.EndDate}
+	if t.AnchorDay != nil {
+		rule.AnchorDay = *t.AnchorDay
 	}
-
-	var rate float64
-	var rateType string
-	err := database.Pool.QueryRow(ctx, `
-		SELECT rate, type FROM exchange_rate
-		WHERE currency = $1 AND date <= $2
-		ORDER BY
-			CASE WHEN type = 'crypto' THEN 0 WHEN type = 'blue' THEN 1 WHEN type = 'official' THEN 2 ELSE 3 END,
-			date DESC
-		LIMIT 1
-	`, currency, date).Scan(&rate, &rateType)
-
-	if err != nil {
-		return 0, "", fmt.Errorf("no exchange rate found for %s: %w", currency, err)
+	if t.MaxOccurrences != nil {
+		rule.MaxOccurrences = *t.MaxOccurrences
 	}
-
-	return rate, rateType, nil
+	return rule
 }
 
-func ProcessRecurringExpenses(database *db.DB) error {
+// ProcessRecurringExpenses creates the expenses of auto-paid templates that
+// have fallen due, catching up on every occurrence missed since the
+// template's due date, each converted at its own date's rate. A template
+// owing more than maxCatchup occurrences gets the first maxCatchup of them
+// and is left due from there, so later runs work through the rest; 0 means
+// no limit.
+func ProcessRecurringExpenses(database *db.DB, maxCatchup int) error {
 	log.Println("[RECURRING] Processing due expenses...")
 
 	ctx := context.Background()
@@ -69,7 +54,8 @@ func ProcessRecurringExpenses(database *db.DB) error {
 	// Get all due templates
 	// Note: using camelCase "userId", "categoryId", "nextDueDate", "isActive", "autoPay"
 	rows, err := database.Pool.Query(ctx, `
-		SELECT id, "userId", name, amount, currency, "categoryId", frequency, "nextDueDate"
+		SELECT id, "userId", name, amount, currency, "categoryId", frequency, "nextDueDate",
+		       interval, "anchorDay", "endDate", "maxOccurrences", "occurrenceCount"
 		FROM recurring_template
 		WHERE "isActive" = true
 		  AND "autoPay" = true
@@ -83,7 +69,8 @@ func ProcessRecurringExpenses(database *db.DB) error {
 	var templates []RecurringTemplate
 	for rows.Next() {
 		var t RecurringTemplate
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Amount, &t.Currency, &t.CategoryID, &t.Frequency, &t.NextDueDate); err != nil {
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Amount, &t.Currency, &t.CategoryID, &t.Frequency, &t.NextDueDate,
+			&t.Interval, &t.AnchorDay, &t.EndDate, &t.MaxOccurrences, &t.OccurrenceCount); err != nil {
 			return fmt.Errorf("failed to scan template: %w", err)
 		}
 		templates = append(templates, t)
@@ -99,30 +86,108 @@ func ProcessRecurringExpenses(database *db.DB) error {
 	}
 
 	createdCount := 0
+	endedCount := 0
+	pendingCount := 0
 
 	for _, template := range templates {
-		// Get exchange rate
-		exchangeRate, rateType, err := getBestExchangeRate(ctx, database, template.Currency, template.NextDueDate)
+		rule := template.Rule()
+		if rule.UsesAnchor() && rule.AnchorDay == 0 {
+			// Remember the anchor day so a clamped month does not move later
+			// occurrences
+			rule.AnchorDay = rule.Anchor(template.NextDueDate)
+		}
+
+		dates, more := dueOccurrences(rule, template.NextDueDate, template.OccurrenceCount, now, maxCatchup)
+		if len(dates) == 0 {
+			// Ended before this occurrence, e.g. after its end date was moved up
+			if err := deactivateTemplate(ctx, database, template.ID); err != nil {
+				log.Printf("[RECURRING] Error deactivating template %s: %v", template.ID, err)
+				continue
+			}
+			endedCount++
+			continue
+		}
+
+		created, active, err := createOccurrences(ctx, database, template, rule, dates)
 		if err != nil {
 			log.Printf("[RECURRING] Warning: %v, skipping template %s", err, template.ID)
 			continue
 		}
+		if more {
+			// Most likely a start date far in the past; the next run carries
+			// on from the new due date
+			log.Printf("[RECURRING] Template %s has more than %d missed occurrences, created %s to %s and left the rest for the next run",
+				template.ID, maxCatchup, dates[0].Format(time.DateOnly), dates[len(dates)-1].Format(time.DateOnly))
+			pendingCount++
+		} else if created > 1 {
+			log.Printf("[RECURRING] Caught up template %s: %d occurrences from %s to %s",
+				template.ID, created, dates[0].Format(time.DateOnly), dates[len(dates)-1].Format(time.DateOnly))
+		}
 
-		var amountInUSD float64
-		if rateType == "crypto" {
-			amountInUSD = template.Amount * exchangeRate
-		} else {
-			amountInUSD = template.Amount / exchangeRate
+		createdCount += created
+		if !active {
+			endedCount++
 		}
+	}
+
+	log.Printf("[RECURRING] ✓ Processed %d templates, created %d expenses, %d templates ended, %d still catching up",
+		len(templates), createdCount, endedCount, pendingCount)
+
+	// Update worker status
+	if err := database.UpdateWorkerStatus(ctx, "recurring_expenses", true); err != nil {
+		log.Printf("[RECURRING] Failed to update worker status: %v", err)
+	}
 
-		// Wrap INSERT + UPDATE in a transaction for atomicity
-		tx, err := database.Pool.Begin(ctx)
+	return nil
+}
+
+// deactivateTemplate stops a template whose recurrence has ended.
+func deactivateTemplate(ctx context.Context, database *db.DB, id string) error {
+	_, err := database.Pool.Exec(ctx, `
+		UPDATE recurring_template
+		SET "isActive" = false, "updatedAt" = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// dueOccurrences lists the occurrences of rule from next through now, given
+// that done have already been generated. When limit is positive it stops
+// after limit occurrences and reports whether more were due.
+func dueOccurrences(rule RecurrenceRule, next time.Time, done int, now time.Time, limit int) ([]time.Time, bool) {
+	var dates []time.Time
+	for !next.After(now) && !rule.Ended(next, done+len(dates)) {
+		if limit > 0 && len(dates) == limit {
+			return dates, true
+		}
+		dates = append(dates, next)
+		next = rule.Next(next)
+	}
+	return dates, false
+}
+
+// createOccurrences creates an expense for each date and advances the
+// template past the last, all in one transaction, so a failure leaves the
+// template due from where it was. It returns the number of expenses created
+// and whether the template is still active.
+func createOccurrences(ctx context.Context, database *db.DB, template RecurringTemplate, rule RecurrenceRule, dates []time.Time) (int, bool, error) {
+	// Convert at the rate of each due date, as /rates/convert would
+	conversions := make([]db.Conversion, len(dates))
+	for i, date := range dates {
+		conversion, err := database.Convert(ctx, decimal.NewFromFloat(template.Amount), template.Currency, "USD", date, "")
 		if err != nil {
-			log.Printf("[RECURRING] Error starting transaction for template %s: %v", template.ID, err)
-			continue
+			return 0, false, err
 		}
+		conversions[i] = conversion
+	}
+
+	tx, err := database.Pool.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-		// Create expense
+	for i, date := range dates {
 		_, err = tx.Exec(ctx, `
 			INSERT INTO expense (
 				id, "userId", title, amount, currency, date, "categoryId",
@@ -131,43 +196,33 @@ func ProcessRecurringExpenses(database *db.DB) error {
 			) VALUES (
 				gen_random_uuid()::text, $1, $2, $3, $4, $5, $6, $7, $8, 'RECURRING', $9, 'FINALIZED', NOW(), NOW()
 			)
-		`, template.UserID, template.Name, template.Amount, template.Currency, template.NextDueDate,
-			template.CategoryID, amountInUSD, exchangeRate, template.ID)
-
+		`, template.UserID, template.Name, template.Amount, template.Currency, date,
+			template.CategoryID, conversions[i].Converted, conversions[i].FromRate.Rate, template.ID)
 		if err != nil {
-			tx.Rollback(ctx)
-			log.Printf("[RECURRING] Error creating expense for template %s: %v", template.ID, err)
-			continue
+			return 0, false, fmt.Errorf("failed to create expense for %s: %w", date.Format(time.DateOnly), err)
 		}
-
-		// Update next due date
-		nextDueDate := calculateNextDueDate(template.NextDueDate, template.Frequency)
-		_, err = tx.Exec(ctx, `
-			UPDATE recurring_template
-			SET "nextDueDate" = $1, "updatedAt" = NOW()
-			WHERE id = $2
-		`, nextDueDate, template.ID)
-
-		if err != nil {
-			tx.Rollback(ctx)
-			log.Printf("[RECURRING] Error updating template %s: %v", template.ID, err)
-			continue
-		}
-
-		if err := tx.Commit(ctx); err != nil {
-			log.Printf("[RECURRING] Error committing transaction for template %s: %v", template.ID, err)
-			continue
-		}
-
-		createdCount++
 	}
 
-	log.Printf("[RECURRING] ✓ Processed %d templates, created %d expenses", len(templates), createdCount)
-
-	// Update worker status
-	if err := database.UpdateWorkerStatus(ctx, "recurring_expenses", true); err != nil {
-		log.Printf("[RECURRING] Failed to update worker status: %v", err)
+	// Advance past the last occurrence and end the template once its rule
+	// runs out
+	var anchorDay *int
+	if rule.AnchorDay != 0 {
+		anchorDay = &rule.AnchorDay
+	}
+	nextDueDate := rule.Next(dates[len(dates)-1])
+	occurrences := template.OccurrenceCount + len(dates)
+	active := !rule.Ended(nextDueDate, occurrences)
+	_, err = tx.Exec(ctx, `
+		UPDATE recurring_template
+		SET "nextDueDate" = $1, "anchorDay" = $2, "occurrenceCount" = $3, "isActive" = $4, "updatedAt" = NOW()
+		WHERE id = $5
+	`, nextDueDate, anchorDay, occurrences, active, template.ID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to update template: %w", err)
 	}
 
-	return nil
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(dates), active, nil
 }
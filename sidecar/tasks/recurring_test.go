@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+// ── dueOccurrences ────────────────────────────────────────────────────────────
+
+func TestDueOccurrences_CatchesUpThroughNow(t *testing.T) {
+	rule := RecurrenceRule{Frequency: "MONTHLY", AnchorDay: 31}
+	now := time.Date(2027, 5, 10, 12, 0, 0, 0, time.UTC)
+	dates, more := dueOccurrences(rule, day("2027-01-31"), 0, now, 0)
+	if more {
+		t.Error("expected no limit to be reported without one")
+	}
+	assertDays(t, dates, "2027-01-31", "2027-02-28", "2027-03-31", "2027-04-30")
+}
+
+func TestDueOccurrences_IncludesOccurrenceDueNow(t *testing.T) {
+	now := time.Date(2027, 1, 8, 9, 0, 0, 0, time.UTC)
+	dates, _ := dueOccurrences(RecurrenceRule{Frequency: "WEEKLY"}, time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC), 0, now, 0)
+	if len(dates) != 2 {
+		t.Fatalf("expected 2 occurrences, got %d", len(dates))
+	}
+}
+
+func TestDueOccurrences_StopsAtRuleEnd(t *testing.T) {
+	end := day("2027-01-20")
+	now := day("2027-03-01")
+	dates, _ := dueOccurrences(RecurrenceRule{Frequency: "WEEKLY", EndDate: &end}, day("2027-01-01"), 0, now, 0)
+	assertDays(t, dates, "2027-01-01", "2027-01-08", "2027-01-15")
+
+	dates, _ = dueOccurrences(RecurrenceRule{Frequency: "WEEKLY", MaxOccurrences: 4}, day("2027-01-01"), 3, now, 0)
+	assertDays(t, dates, "2027-01-01")
+
+	// A template that already ended has nothing due
+	dates, _ = dueOccurrences(RecurrenceRule{Frequency: "WEEKLY", MaxOccurrences: 4}, day("2027-01-01"), 4, now, 0)
+	if len(dates) != 0 {
+		t.Errorf("expected no occurrences, got %v", formatDays(dates))
+	}
+}
+
+func TestDueOccurrences_Limit(t *testing.T) {
+	rule := RecurrenceRule{Frequency: "DAILY"}
+	now := day("2027-01-10")
+
+	dates, more := dueOccurrences(rule, day("2027-01-01"), 0, now, 5)
+	if !more || len(dates) != 5 {
+		t.Errorf("expected 5 occurrences and more due, got %d (more=%v)", len(dates), more)
+	}
+
+	// The next run continues from the day after the last one created
+	dates, more = dueOccurrences(rule, rule.Next(dates[len(dates)-1]), 5, now, 5)
+	if more || len(dates) != 5 {
+		t.Errorf("expected the remaining 5 occurrences, got %d (more=%v)", len(dates), more)
+	}
+	assertDays(t, dates[:1], "2027-01-06")
+
+	// Exactly the limit is not over it
+	dates, more = dueOccurrences(rule, day("2027-01-06"), 0, now, 5)
+	if more || len(dates) != 5 {
+		t.Errorf("expected 5 occurrences and none left, got %d (more=%v)", len(dates), more)
+	}
+}
@@ -49,7 +49,7 @@ export function RecurringCalendar({
 					return (templates ?? [])
 						.filter(
 							(t: RecurringTemplate) =>
-								t.isActive && isProjectedOnDate(t.frequency, t.nextDueDate, date),
+								t.isActive && isProjectedOnDate(t, date),
 						)
 						.map((t: RecurringTemplate) => ({ ...t, date }));
 				})
@@ -63,7 +63,7 @@ export function RecurringCalendar({
 				? (templates ?? [])
 						.filter(
 							(t: RecurringTemplate) =>
-								t.isActive && isProjectedOnDate(t.frequency, t.nextDueDate, selectedDate),
+								t.isActive && isProjectedOnDate(t, selectedDate),
 						)
 						.map((t: RecurringTemplate) => ({ ...t, date: selectedDate }))
 				: [],
@@ -81,7 +81,7 @@ export function RecurringCalendar({
 		for (let i = 0; i < 60; i++) {
 			const date = addDays(now, i);
 			for (const t of active) {
-				if (isProjectedOnDate(t.frequency, t.nextDueDate, date)) {
+				if (isProjectedOnDate(t, date)) {
 					dates.push(date);
 					break; // one dot per day is enough
 				}
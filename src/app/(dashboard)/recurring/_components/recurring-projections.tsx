@@ -39,6 +39,7 @@ export function RecurringProjections({
 				monthlyAmount: toMonthlyEquivalent(
 					Number(t.amountInHomeCurrency),
 					t.frequency,
+					t.interval,
 				),
 			})),
 		[activeTemplates],
@@ -35,6 +35,7 @@ export function RecurringStatsCards({
 					toMonthlyEquivalent(
 						Number(t.amountInHomeCurrency),
 						t.frequency,
+						t.interval,
 					),
 				0,
 			) ?? 0;
@@ -93,6 +93,22 @@ export async function POST(request: NextRequest) {
 		importerFormData.append("currency", currency);
 	}
 
+	// The browser's language settles ambiguous symbols such as "$" in amounts
+	const locale = request.headers
+		.get("accept-language")
+		?.split(",")[0]
+		?.split(";")[0]
+		?.trim();
+	if (locale && /^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$/.test(locale)) {
+		importerFormData.append("locale", locale);
+	}
+
+	// Password for encrypted PDF statements. Forwarded as-is and never logged.
+	const password = formData.get("password");
+	if (password && typeof password === "string" && ext.endsWith(".pdf")) {
+		importerFormData.append("password", password);
+	}
+
 	const controller = new AbortController();
 	const timeout = setTimeout(() => controller.abort(), 5 * 60 * 1000); // 5 minutes
 
@@ -12,6 +12,7 @@ import { useTranslations } from "next-intl";
 import { useCallback, useMemo, useRef, useState } from "react";
 import { toast } from "sonner";
 import { z } from "zod";
+import { CurrencyPicker } from "~/components/currency-picker";
 import { Alert, AlertDescription, AlertTitle } from "~/components/ui/alert";
 import { Button } from "~/components/ui/button";
 import { Input } from "~/components/ui/input";
@@ -26,6 +27,7 @@ import { UnsavedChangesDialog } from "~/components/ui/unsaved-changes-dialog";
 import { useCurrency } from "~/hooks/use-currency";
 import { useNavigationGuard } from "~/hooks/use-navigation-guard";
 import { parseRawCsv } from "~/lib/csv";
+import type { CurrencyCode } from "~/lib/currencies";
 import { ExpenseImportSchema } from "~/lib/schemas/data-importer";
 import { cn } from "~/lib/utils";
 import { api } from "~/trpc/react";
@@ -461,6 +463,12 @@ function BankStatementImport({
 
 	const fileInputRef = useRef<HTMLInputElement | null>(null);
 	const [isDragging, setIsDragging] = useState(false);
+	// Settles "$" and other shared symbols in the statement's amounts; the
+	// home currency until the user picks another
+	const [pickedCurrency, setPickedCurrency] = useState<CurrencyCode | null>(
+		null,
+	);
+	const statementCurrency = pickedCurrency ?? (mainCurrency as CurrencyCode);
 
 	const createJobMutation = api.importQueue.createJob.useMutation({
 		onSuccess: () => {
@@ -520,11 +528,12 @@ function BankStatementImport({
 					fileType,
 					type: "BANK_STATEMENT",
 					fileData: base64,
+					currency: statementCurrency,
 				});
 			};
 			reader.readAsArrayBuffer(file);
 		},
-		[createJobMutation],
+		[createJobMutation, statementCurrency],
 	);
 
 	const handleFileChange = useCallback(
@@ -615,6 +624,17 @@ function BankStatementImport({
 				</p>
 			</div>
 
+			<div className="flex items-center justify-between gap-4">
+				<p className="text-muted-foreground text-sm">
+					{t("statementCurrency")}
+				</p>
+				<CurrencyPicker
+					onValueChange={setPickedCurrency}
+					triggerDisplay="flag+code"
+					value={statementCurrency}
+				/>
+			</div>
+
 			{state.step === "error" && (
 				<div className="relative rounded-md border border-destructive/50 bg-destructive/10 p-3 font-mono text-destructive text-sm">
 					<div className="whitespace-pre-wrap">{state.message}</div>
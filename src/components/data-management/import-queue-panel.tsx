@@ -13,6 +13,17 @@ import {
 	AlertDialogHeader,
 	AlertDialogTitle,
 } from "~/components/ui/alert-dialog";
+import { Button } from "~/components/ui/button";
+import {
+	Dialog,
+	DialogContent,
+	DialogDescription,
+	DialogFooter,
+	DialogHeader,
+	DialogTitle,
+} from "~/components/ui/dialog";
+import { Input } from "~/components/ui/input";
+import { Label } from "~/components/ui/label";
 import { Separator } from "~/components/ui/separator";
 import { api } from "~/trpc/react";
 import { JobCard, type JobCardData } from "./job-card";
@@ -29,6 +40,8 @@ export function ImportQueuePanel({ onReviewJob }: ImportQueuePanelProps) {
 	const t = useTranslations("dataManagement");
 	const utils = api.useUtils();
 	const [jobToDelete, setJobToDelete] = useState<string | null>(null);
+	const [jobToUnlock, setJobToUnlock] = useState<JobCardData | null>(null);
+	const [pdfPassword, setPdfPassword] = useState("");
 
 	// Poll queue status every 2 seconds
 	const { data: queueStatus } = api.importQueue.getQueueStatus.useQuery(
@@ -74,6 +87,29 @@ export function ImportQueuePanel({ onReviewJob }: ImportQueuePanelProps) {
 		},
 	});
 
+	const retryWithPasswordMutation =
+		api.importQueue.retryWithPassword.useMutation({
+			onSuccess: () => {
+				void utils.importQueue.getQueueStatus.invalidate();
+				void utils.importQueue.listJobs.invalidate();
+				setJobToUnlock(null);
+				setPdfPassword("");
+			},
+			onError: (error) => {
+				toast.error(t("failedToUnlockPdf", { message: error.message }));
+			},
+		});
+
+	const handleUnlockSubmit = (e: React.FormEvent) => {
+		e.preventDefault();
+		if (jobToUnlock && pdfPassword) {
+			retryWithPasswordMutation.mutate({
+				jobId: jobToUnlock.id,
+				password: pdfPassword,
+			});
+		}
+	};
+
 	const handleDeleteClick = (jobId: string) => {
 		setJobToDelete(jobId);
 	};
@@ -178,6 +214,7 @@ export function ImportQueuePanel({ onReviewJob }: ImportQueuePanelProps) {
 									job={job as JobCardData}
 									key={job.id}
 									onDelete={() => handleDeleteClick(job.id)}
+									onUnlock={() => setJobToUnlock(job as JobCardData)}
 								/>
 							))}
 						</div>
@@ -207,6 +244,58 @@ export function ImportQueuePanel({ onReviewJob }: ImportQueuePanelProps) {
 				</>
 			)}
 
+			{/* PDF Password Dialog */}
+			<Dialog
+				onOpenChange={(open) => {
+					if (!open) {
+						setJobToUnlock(null);
+						setPdfPassword("");
+					}
+				}}
+				open={jobToUnlock !== null}
+			>
+				<DialogContent>
+					<form className="space-y-4" onSubmit={handleUnlockSubmit}>
+						<DialogHeader>
+							<DialogTitle>{t("pdfPasswordTitle")}</DialogTitle>
+							<DialogDescription>
+								{jobToUnlock?.errorType === "password_incorrect"
+									? t("pdfPasswordIncorrect", { fileName: jobToUnlock.fileName })
+									: t("pdfPasswordDescription", {
+											fileName: jobToUnlock?.fileName ?? "",
+										})}
+							</DialogDescription>
+						</DialogHeader>
+						<div className="space-y-2">
+							<Label htmlFor="pdf-password">{t("pdfPasswordLabel")}</Label>
+							<Input
+								autoComplete="off"
+								autoFocus
+								id="pdf-password"
+								onChange={(e) => setPdfPassword(e.target.value)}
+								type="password"
+								value={pdfPassword}
+							/>
+						</div>
+						<DialogFooter>
+							<Button
+								onClick={() => setJobToUnlock(null)}
+								type="button"
+								variant="outline"
+							>
+								{t("cancel")}
+							</Button>
+							<Button
+								disabled={!pdfPassword || retryWithPasswordMutation.isPending}
+								type="submit"
+							>
+								{t("unlockPdf")}
+							</Button>
+						</DialogFooter>
+					</form>
+				</DialogContent>
+			</Dialog>
+
 			{/* Delete Confirmation Dialog */}
 			<AlertDialog
 				onOpenChange={(open) => !open && setJobToDelete(null)}
@@ -9,6 +9,7 @@ import {
 	Copy,
 	Eye,
 	EyeOff,
+	FileText,
 	Trash2,
 	X,
 } from "lucide-react";
@@ -47,6 +48,14 @@ export interface ImporterTransaction {
 	pricingSource: string;
 	category: string;
 	categoryId?: string;
+	/** Provenance: where the row came from in the uploaded file */
+	importId?: string;
+	sourceFile?: string;
+	sourceRow?: number;
+	sourcePage?: number;
+	sourceLineStart?: number;
+	sourceLineEnd?: number;
+	rawText?: string;
 }
 
 /**
@@ -79,6 +88,9 @@ export interface ImportTransaction {
 	categoryId: string | null;
 	pricingSource: string;
 	isDuplicate?: boolean;
+	/** Where the row came from, e.g. "Row 12"; the raw text is masked */
+	sourceRef?: string;
+	rawText?: string;
 }
 
 interface ImporterReviewManagerProps {
@@ -148,6 +160,25 @@ export function ImporterReviewManager({
 	// Build initial transactions from importer data
 	const [transactions, setTransactions] = useState<ImportTransaction[]>([]);
 
+	const formatSourceRef = useCallback(
+		(tx: ImporterTransaction): string | undefined => {
+			if (tx.sourceRow) return t("sourceRow", { row: tx.sourceRow });
+			if (!tx.sourceLineStart) return undefined;
+			if (tx.sourceLineEnd && tx.sourceLineEnd > tx.sourceLineStart) {
+				return t("sourceLines", {
+					page: tx.sourcePage ?? 1,
+					start: tx.sourceLineStart,
+					end: tx.sourceLineEnd,
+				});
+			}
+			return t("sourceLine", {
+				page: tx.sourcePage ?? 1,
+				line: tx.sourceLineStart,
+			});
+		},
+		[t],
+	);
+
 	// Initialize transactions when data is ready
 	useEffect(() => {
 		if (importerData.length === 0 || !existingFingerprints) return;
@@ -185,6 +216,8 @@ export function ImporterReviewManager({
 				categoryId: matchedCat?.id ?? null,
 				pricingSource: tx.pricingSource || "IMPORTED",
 				isDuplicate,
+				sourceRef: formatSourceRef(tx),
+				rawText: tx.rawText,
 			};
 		});
 
@@ -195,7 +228,7 @@ export function ImporterReviewManager({
 			.filter((t) => !t.isDuplicate)
 			.map((t) => t.id);
 		setSelectedIds(new Set(nonDuplicateIds));
-	}, [importerData, existingFingerprints, categoryLookup]);
+	}, [importerData, existingFingerprints, categoryLookup, formatSourceRef]);
 
 	const [selectedIds, setSelectedIds] = useState<Set<string>>(new Set());
 	const [mainCurrency, setMainCurrency] = useState<CurrencyCode>(
@@ -418,7 +451,7 @@ export function ImporterReviewManager({
 				header: t("title"),
 				enableSorting: true,
 				cell: ({ row }) => {
-					const isDuplicate = row.original.isDuplicate;
+					const { isDuplicate, sourceRef, rawText } = row.original;
 					return (
 						<div className="flex items-center gap-2">
 							<EditableCell
@@ -443,6 +476,26 @@ export function ImporterReviewManager({
 									</TooltipContent>
 								</Tooltip>
 							)}
+							{(sourceRef || rawText) && (
+								<Tooltip>
+									<TooltipTrigger asChild>
+										<div
+											aria-label={t("sourceInFile")}
+											className="flex shrink-0 items-center gap-1 text-[10px] text-muted-foreground"
+										>
+											<FileText className="h-3 w-3" />
+											{sourceRef}
+										</div>
+									</TooltipTrigger>
+									{rawText && (
+										<TooltipContent className="max-w-md">
+											<pre className="whitespace-pre-wrap break-all font-mono text-xs">
+												{rawText}
+											</pre>
+										</TooltipContent>
+									)}
+								</Tooltip>
+							)}
 						</div>
 					);
 				},
@@ -7,6 +7,7 @@ import {
 	Clock,
 	Eye,
 	FileText,
+	KeyRound,
 	Loader2,
 	Trash2,
 	XCircle,
@@ -35,6 +36,7 @@ export interface JobCardData {
 	importedCount: number | null;
 	skippedDuplicates: number | null;
 	errorMessage: string | null;
+	errorType: string | null;
 	progressPercent: number | null;
 	statusMessage: string | null;
 	createdAt: Date;
@@ -50,6 +52,7 @@ interface JobCardProps {
 	onReview?: () => void;
 	onDelete?: () => void;
 	onCancel?: () => void;
+	onUnlock?: () => void; // Asks for the password of an encrypted PDF
 	compact?: boolean;
 }
 
@@ -111,6 +114,7 @@ export function JobCard({
 	onReview,
 	onDelete,
 	onCancel,
+	onUnlock,
 	compact = false,
 }: JobCardProps) {
 	const t = useTranslations("dataManagement");
@@ -223,6 +227,16 @@ export function JobCard({
 								</Button>
 							)}
 
+						{job.status === "FAILED" &&
+							(job.errorType === "password_required" ||
+								job.errorType === "password_incorrect") &&
+							onUnlock && (
+								<Button onClick={onUnlock} size="sm" variant="outline">
+									<KeyRound className="mr-1.5 h-3.5 w-3.5" />
+									{t("unlockPdf")}
+								</Button>
+							)}
+
 						{job.status === "QUEUED" && onCancel && (
 							<Button
 								aria-label={t("cancelImportJob")}
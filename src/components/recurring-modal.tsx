@@ -33,6 +33,7 @@ import { useCurrencyConversion } from "~/hooks/use-currency-conversion";
 import { useCurrencyInput } from "~/hooks/use-currency-input";
 import { useCurrencyFormatter } from "~/hooks/use-currency-formatter";
 import { useExchangeRates } from "~/hooks/use-exchange-rates";
+import { previewOccurrences, RECURRING_FREQUENCIES } from "~/lib/recurring";
 import { getSubscriptionMetadata } from "~/lib/subscription-metadata";
 import { cn } from "~/lib/utils";
 import { api } from "~/trpc/react";
@@ -42,8 +43,11 @@ const recurringSchema = z.object({
 	amount: z.number().positive("Amount must be positive"),
 	currency: z.string().length(3),
 	categoryId: z.string().min(1, "Please select a category"),
-	frequency: z.enum(["WEEKLY", "BIWEEKLY", "MONTHLY", "QUARTERLY", "YEARLY"]),
+	frequency: z.enum(RECURRING_FREQUENCIES),
+	interval: z.number().int().min(1).max(366),
 	nextDueDate: z.date(),
+	endDate: z.date().nullable(),
+	maxOccurrences: z.number().int().positive().nullable(),
 	websiteUrl: z.string().url().optional().or(z.literal("")),
 	paymentSource: z.string().optional(),
 	autoPay: z.boolean(),
@@ -51,6 +55,19 @@ const recurringSchema = z.object({
 
 type RecurringFormData = z.infer<typeof recurringSchema>;
 
+// Upcoming dates listed under the schedule fields
+const PREVIEW_COUNT = 5;
+
+function formatOccurrence(date: Date) {
+	// Occurrences are UTC dates, as the sidecar generates them
+	return date.toLocaleDateString(undefined, {
+		timeZone: "UTC",
+		month: "short",
+		day: "numeric",
+		year: "numeric",
+	});
+}
+
 interface RecurringModalProps {
 	templateId?: string | null;
 	open: boolean;
@@ -99,7 +116,7 @@ export function RecurringModal({
 		watch,
 		setValue,
 		reset,
-		formState: { errors },
+		formState: { errors, dirtyFields },
 	} = useForm<RecurringFormData>({
 		resolver: zodResolver(recurringSchema),
 		defaultValues: {
@@ -108,7 +125,10 @@ export function RecurringModal({
 			currency: settings?.defaultCurrency ?? "USD",
 			categoryId: "",
 			frequency: "MONTHLY",
+			interval: 1,
 			nextDueDate: new Date(),
+			endDate: null,
+			maxOccurrences: null,
 			websiteUrl: "",
 			paymentSource: "",
 			autoPay: true,
@@ -137,7 +157,10 @@ export function RecurringModal({
 				currency: template.currency,
 				categoryId: template.categoryId ?? "",
 				frequency: template.frequency,
+				interval: template.interval,
 				nextDueDate: new Date(template.nextDueDate),
+				endDate: template.endDate ? new Date(template.endDate) : null,
+				maxOccurrences: template.maxOccurrences,
 				websiteUrl: template.websiteUrl ?? "",
 				paymentSource: template.paymentSource ?? "",
 				autoPay: template.autoPay,
@@ -163,6 +186,40 @@ export function RecurringModal({
 
 	const activeRate = getDefaultRate();
 
+	const watchedFrequency = watch("frequency");
+	const watchedInterval = watch("interval");
+	const watchedNextDueDate = watch("nextDueDate");
+	const watchedEndDate = watch("endDate");
+	const watchedMaxOccurrences = watch("maxOccurrences");
+
+	// A saved schedule previews from the server; edits preview locally
+	const scheduleEdited =
+		!templateId ||
+		!!dirtyFields.frequency ||
+		!!dirtyFields.interval ||
+		!!dirtyFields.nextDueDate ||
+		!!dirtyFields.endDate ||
+		!!dirtyFields.maxOccurrences;
+
+	const { data: savedPreview } = api.recurring.preview.useQuery(
+		{ id: templateId ?? "", count: PREVIEW_COUNT },
+		{ enabled: !!templateId && !scheduleEdited },
+	);
+
+	const upcoming = scheduleEdited
+		? previewOccurrences(
+				{
+					frequency: watchedFrequency,
+					interval: watchedInterval,
+					endDate: watchedEndDate,
+					maxOccurrences: watchedMaxOccurrences,
+				},
+				watchedNextDueDate,
+				template?.occurrenceCount ?? 0,
+				PREVIEW_COUNT,
+			)
+		: savedPreview;
+
 	const onSubmit = async (data: RecurringFormData) => {
 		if (templateId) {
 			await updateMutation.mutateAsync({
@@ -175,6 +232,8 @@ export function RecurringModal({
 		} else {
 			await createMutation.mutateAsync({
 				...data,
+				endDate: data.endDate ?? undefined,
+				maxOccurrences: data.maxOccurrences ?? undefined,
 				categoryId: data.categoryId || undefined,
 				websiteUrl: data.websiteUrl || undefined,
 				paymentSource: data.paymentSource || undefined,
@@ -308,7 +367,9 @@ export function RecurringModal({
 						<Label htmlFor="recurring-frequency">{t("fieldFrequency")}</Label>
 						<Select
 							onValueChange={(value) =>
-								setValue("frequency", value as RecurringFormData["frequency"])
+								setValue("frequency", value as RecurringFormData["frequency"], {
+									shouldDirty: true,
+								})
 							}
 							value={watch("frequency")}
 						>
@@ -316,22 +377,119 @@ export function RecurringModal({
 								<SelectValue />
 							</SelectTrigger>
 							<SelectContent>
+								<SelectItem value="DAILY">{t("frequencyDaily")}</SelectItem>
 								<SelectItem value="WEEKLY">{t("frequencyWeekly")}</SelectItem>
 								<SelectItem value="BIWEEKLY">{t("frequencyBiweekly")}</SelectItem>
+								<SelectItem value="SEMI_MONTHLY">{t("frequencySemiMonthly")}</SelectItem>
 								<SelectItem value="MONTHLY">{t("frequencyMonthly")}</SelectItem>
+								<SelectItem value="LAST_BUSINESS_DAY">
+									{t("frequencyLastBusinessDay")}
+								</SelectItem>
 								<SelectItem value="QUARTERLY">{t("frequencyQuarterly")}</SelectItem>
 								<SelectItem value="YEARLY">{t("frequencyYearly")}</SelectItem>
 							</SelectContent>
 						</Select>
 					</div>
 
+					{/* Interval */}
+					{watchedFrequency !== "SEMI_MONTHLY" && (
+						<div className="space-y-2">
+							<Label htmlFor="recurring-interval">{t("fieldInterval")}</Label>
+							<Input
+								id="recurring-interval"
+								max={366}
+								min={1}
+								step={1}
+								type="number"
+								{...register("interval", { valueAsNumber: true })}
+							/>
+							<p className="text-muted-foreground text-xs">
+								{t("fieldIntervalDescription")}
+							</p>
+							{errors.interval && (
+								<p className="text-destructive text-sm">
+									{errors.interval.message}
+								</p>
+							)}
+						</div>
+					)}
+
 					{/* Next Due Date */}
 					<div className="space-y-2">
 						<Label>{t("fieldNextDueDate")}</Label>
 						<DatePicker
-							date={watch("nextDueDate")}
-							onSelect={(date) => date && setValue("nextDueDate", date)}
+							date={watchedNextDueDate}
+							onSelect={(date) =>
+								date && setValue("nextDueDate", date, { shouldDirty: true })
+							}
+						/>
+					</div>
+
+					{/* End Date */}
+					<div className="space-y-2">
+						<div className="flex items-center justify-between">
+							<Label>{t("fieldEndDate")}</Label>
+							{watchedEndDate && (
+								<Button
+									className="h-auto p-0 text-xs"
+									onClick={() =>
+										setValue("endDate", null, { shouldDirty: true })
+									}
+									type="button"
+									variant="link"
+								>
+									{t("clear")}
+								</Button>
+							)}
+						</div>
+						<DatePicker
+							date={watchedEndDate ?? undefined}
+							onSelect={(date) =>
+								setValue("endDate", date ?? null, { shouldDirty: true })
+							}
+							placeholder={t("fieldEndDateNever")}
+						/>
+					</div>
+
+					{/* Occurrence Limit */}
+					<div className="space-y-2">
+						<Label htmlFor="recurring-max-occurrences">
+							{t("fieldMaxOccurrences")}
+						</Label>
+						<Input
+							id="recurring-max-occurrences"
+							min={1}
+							placeholder={t("fieldMaxOccurrencesPlaceholder")}
+							step={1}
+							type="number"
+							{...register("maxOccurrences", {
+								setValueAs: (value) =>
+									value === "" || value === null ? null : Number(value),
+							})}
 						/>
+						{errors.maxOccurrences && (
+							<p className="text-destructive text-sm">
+								{errors.maxOccurrences.message}
+							</p>
+						)}
+					</div>
+
+					{/* Upcoming Occurrences */}
+					<div className="space-y-2 rounded-lg border p-4">
+						<Label>{t("upcomingOccurrences")}</Label>
+						{upcoming && upcoming.length > 0 ? (
+							<ul className="space-y-1 text-muted-foreground text-sm">
+								{upcoming.map((date) => (
+									<li key={new Date(date).toISOString()}>
+										{formatOccurrence(new Date(date))}
+									</li>
+								))}
+							</ul>
+						) : (
+							<p className="text-muted-foreground text-sm">
+								{t("noUpcomingOccurrences")}
+							</p>
+						)}
 					</div>
 
 					{/* Auto Pay */}
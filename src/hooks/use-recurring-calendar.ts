@@ -34,7 +34,7 @@ export function useRecurringCalendar(
 		return days.map((date): CalendarDay => {
 			const dayTemplates =
 				templates?.filter((template) =>
-					isProjectedOnDate(template.frequency, template.nextDueDate, date),
+					isProjectedOnDate(template, date),
 				) ?? [];
 
 			return {
@@ -1,5 +1,6 @@
 import {
 	differenceInDays,
+	subDays,
 	format,
 	startOfToday,
 	subMonths,
@@ -17,6 +18,15 @@ export function useRecurringStatus(template: RecurringTemplate) {
 		// Calculate the start of the current period based on frequency
 		let cycleStart = new Date(nextDate);
 		switch (template.frequency) {
+			case "DAILY":
+				cycleStart = subDays(cycleStart, template.interval);
+				break;
+			case "SEMI_MONTHLY":
+				cycleStart = subDays(cycleStart, 15);
+				break;
+			case "LAST_BUSINESS_DAY":
+				cycleStart = subMonths(cycleStart, template.interval);
+				break;
 			case "WEEKLY":
 				cycleStart = subWeeks(cycleStart, 1);
 				break;
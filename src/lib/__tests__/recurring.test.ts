@@ -0,0 +1,110 @@
+import { describe, expect, it } from "vitest";
+import {
+	isProjectedOnDate,
+	nextOccurrence,
+	previewOccurrences,
+	toMonthlyEquivalent,
+} from "../recurring";
+
+const utc = (day: string) => new Date(`${day}T00:00:00.000Z`);
+const days = (dates: Date[]) => dates.map((d) => d.toISOString().slice(0, 10));
+
+// These cases mirror sidecar/tasks/recurrence_test.go, which generates the
+// expenses these dates project.
+describe("nextOccurrence", () => {
+	it("keeps a monthly template on its anchor day", () => {
+		expect(
+			days(previewOccurrences({ frequency: "MONTHLY" }, utc("2027-01-31"), 0, 5)),
+		).toEqual(["2027-01-31", "2027-02-28", "2027-03-31", "2027-04-30", "2027-05-31"]);
+	});
+
+	it("returns to a stored anchor day after a clamped month", () => {
+		expect(
+			days([nextOccurrence({ frequency: "MONTHLY", anchorDay: 31 }, utc("2027-02-28"))]),
+		).toEqual(["2027-03-31"]);
+	});
+
+	it("applies intervals", () => {
+		const start = utc("2027-01-30");
+		expect(days(previewOccurrences({ frequency: "DAILY", interval: 3 }, start, 0, 3))).toEqual([
+			"2027-01-30",
+			"2027-02-02",
+			"2027-02-05",
+		]);
+		expect(days(previewOccurrences({ frequency: "MONTHLY", interval: 2 }, start, 0, 3))).toEqual([
+			"2027-01-30",
+			"2027-03-30",
+			"2027-05-30",
+		]);
+	});
+
+	it("handles the 1st & 15th and the last business day", () => {
+		expect(
+			days(previewOccurrences({ frequency: "SEMI_MONTHLY" }, utc("2027-01-15"), 0, 4)),
+		).toEqual(["2027-01-15", "2027-02-01", "2027-02-15", "2027-03-01"]);
+		expect(
+			days(previewOccurrences({ frequency: "LAST_BUSINESS_DAY" }, utc("2027-01-29"), 0, 4)),
+		).toEqual(["2027-01-29", "2027-02-26", "2027-03-31", "2027-04-30"]);
+	});
+});
+
+describe("previewOccurrences", () => {
+	it("stops at an inclusive end date", () => {
+		const rule = { frequency: "MONTHLY", endDate: utc("2027-03-15") };
+		expect(days(previewOccurrences(rule, utc("2027-01-15"), 0, 10))).toEqual([
+			"2027-01-15",
+			"2027-02-15",
+			"2027-03-15",
+		]);
+	});
+
+	it("counts occurrences already generated against the limit", () => {
+		const rule = { frequency: "WEEKLY", maxOccurrences: 5 };
+		expect(days(previewOccurrences(rule, utc("2027-01-01"), 3, 10))).toEqual([
+			"2027-01-01",
+			"2027-01-08",
+		]);
+	});
+});
+
+describe("isProjectedOnDate", () => {
+	// Calendar cells are local midnights
+	const cell = (y: number, m: number, d: number) => new Date(y, m - 1, d);
+
+	it("honours the interval", () => {
+		const template = {
+			frequency: "DAILY",
+			interval: 3,
+			nextDueDate: utc("2027-01-01"),
+		};
+		expect(isProjectedOnDate(template, cell(2027, 1, 1))).toBe(true);
+		expect(isProjectedOnDate(template, cell(2027, 1, 2))).toBe(false);
+		expect(isProjectedOnDate(template, cell(2027, 1, 4))).toBe(true);
+	});
+
+	it("follows the anchor day through short months", () => {
+		const template = { frequency: "MONTHLY", nextDueDate: utc("2027-01-31") };
+		expect(isProjectedOnDate(template, cell(2027, 2, 28))).toBe(true);
+		expect(isProjectedOnDate(template, cell(2027, 3, 28))).toBe(false);
+		expect(isProjectedOnDate(template, cell(2027, 3, 31))).toBe(true);
+	});
+
+	it("projects nothing before the due date or past the rule's end", () => {
+		const template = {
+			frequency: "WEEKLY",
+			nextDueDate: utc("2027-01-08"),
+			maxOccurrences: 2,
+			occurrenceCount: 1,
+		};
+		expect(isProjectedOnDate(template, cell(2027, 1, 1))).toBe(false);
+		expect(isProjectedOnDate(template, cell(2027, 1, 8))).toBe(true);
+		expect(isProjectedOnDate(template, cell(2027, 1, 15))).toBe(false);
+	});
+});
+
+describe("toMonthlyEquivalent", () => {
+	it("divides by the interval", () => {
+		expect(toMonthlyEquivalent(12, "MONTHLY", 3)).toBe(4);
+		expect(toMonthlyEquivalent(100, "SEMI_MONTHLY")).toBe(200);
+	});
+});
@@ -1,48 +1,199 @@
-import { differenceInCalendarDays, getDay, isSameDay } from "date-fns";
+import { format } from "date-fns";
 
 export const FREQUENCY_LABELS: Record<string, string> = {
+	DAILY: "Daily",
 	WEEKLY: "Weekly",
 	BIWEEKLY: "Biweekly",
+	SEMI_MONTHLY: "1st & 15th",
 	MONTHLY: "Monthly",
+	LAST_BUSINESS_DAY: "Last business day",
 	QUARTERLY: "Quarterly",
 	YEARLY: "Yearly",
 };
 
+export const RECURRING_FREQUENCIES = [
+	"DAILY",
+	"WEEKLY",
+	"BIWEEKLY",
+	"SEMI_MONTHLY",
+	"MONTHLY",
+	"LAST_BUSINESS_DAY",
+	"QUARTERLY",
+	"YEARLY",
+] as const;
+
+export type RecurringFrequency = (typeof RECURRING_FREQUENCIES)[number];
+
 /**
- * Check if a recurring template is projected to occur on a given date
- * based on its frequency and nextDueDate.
+ * When a recurring template falls due. Mirrors RecurrenceRule in
+ * sidecar/tasks/recurrence.go, which generates the expenses; keep the two in
+ * step.
  */
-export function isProjectedOnDate(
-	frequency: string,
-	nextDueDate: Date,
-	date: Date,
-): boolean {
-	const dueDate = new Date(nextDueDate);
+export interface RecurrenceRule {
+	frequency: string;
+	/** Every N periods of frequency; below 1 means 1. */
+	interval?: number | null;
+	/** Day of month monthly frequencies aim for; unset takes the due date's day. */
+	anchorDay?: number | null;
+	/** Last day an occurrence may fall on. */
+	endDate?: Date | null;
+	/** Occurrences after which the rule ends. */
+	maxOccurrences?: number | null;
+}
 
-	if (isSameDay(dueDate, date)) return true;
+const ANCHORED_FREQUENCIES = new Set(["MONTHLY", "QUARTERLY", "YEARLY"]);
 
-	switch (frequency) {
-		case "MONTHLY":
-			return dueDate.getDate() === date.getDate();
+/**
+ * Whether the rule aims for a day of month, which the template stores so
+ * that a due date clamped in a short month moves back afterwards.
+ */
+export function usesAnchor(frequency: string): boolean {
+	return ANCHORED_FREQUENCIES.has(frequency);
+}
+
+function utcDayKey(date: Date): string {
+	return date.toISOString().slice(0, 10);
+}
+
+function daysInMonth(year: number, month: number): number {
+	return new Date(Date.UTC(year, month + 1, 0)).getUTCDate();
+}
+
+// Dates are handled in UTC, as the sidecar does
+function withDay(date: Date, day: number): Date {
+	const next = new Date(date);
+	next.setUTCDate(
+		Math.min(day, daysInMonth(date.getUTCFullYear(), date.getUTCMonth())),
+	);
+	return next;
+}
+
+function addMonthsClamped(date: Date, months: number, day: number): Date {
+	const first = new Date(date);
+	first.setUTCDate(1);
+	first.setUTCMonth(first.getUTCMonth() + months);
+	return withDay(first, day);
+}
+
+function lastBusinessDay(date: Date): Date {
+	const last = withDay(date, 31);
+	while (last.getUTCDay() === 0 || last.getUTCDay() === 6) {
+		last.setUTCDate(last.getUTCDate() - 1);
+	}
+	return last;
+}
+
+/**
+ * The occurrence after current, keeping its time of day. Monthly rules keep
+ * to their anchor day, so a template due on the 31st falls on the 30th in
+ * April and on the 31st again in May.
+ */
+export function nextOccurrence(rule: RecurrenceRule, current: Date): Date {
+	const n = Math.max(rule.interval ?? 1, 1);
+	const anchor = rule.anchorDay ?? current.getUTCDate();
+	const next = new Date(current);
+
+	switch (rule.frequency) {
+		case "DAILY":
+			next.setUTCDate(next.getUTCDate() + n);
+			return next;
 		case "WEEKLY":
-			return getDay(dueDate) === getDay(date);
-		case "BIWEEKLY": {
-			const dayDiff = Math.abs(differenceInCalendarDays(date, dueDate));
-			return dayDiff % 14 === 0;
-		}
+			next.setUTCDate(next.getUTCDate() + 7 * n);
+			return next;
+		case "BIWEEKLY":
+			next.setUTCDate(next.getUTCDate() + 14 * n);
+			return next;
 		case "QUARTERLY":
-			return (
-				dueDate.getDate() === date.getDate() &&
-				Math.abs(date.getMonth() - dueDate.getMonth()) % 3 === 0
-			);
+			return addMonthsClamped(current, 3 * n, anchor);
 		case "YEARLY":
-			return (
-				dueDate.getDate() === date.getDate() &&
-				dueDate.getMonth() === date.getMonth()
-			);
+			return addMonthsClamped(current, 12 * n, anchor);
+		case "SEMI_MONTHLY":
+			// The 1st and the 15th; the interval does not apply
+			return current.getUTCDate() < 15
+				? withDay(current, 15)
+				: addMonthsClamped(current, 1, 1);
+		case "LAST_BUSINESS_DAY":
+			return lastBusinessDay(addMonthsClamped(current, n, 1));
 		default:
-			return false;
+			return addMonthsClamped(current, n, anchor);
+	}
+}
+
+/**
+ * Whether an occurrence on date is past the rule's end, given that done
+ * occurrences have already been generated. The end date is inclusive.
+ */
+export function recurrenceEnded(
+	rule: RecurrenceRule,
+	date: Date,
+	done: number,
+): boolean {
+	if (rule.maxOccurrences && done >= rule.maxOccurrences) return true;
+	if (!rule.endDate) return false;
+	return utcDayKey(date) > utcDayKey(new Date(rule.endDate));
+}
+
+/**
+ * Up to count occurrences starting with next, the template's current due
+ * date, stopping early where the rule ends.
+ */
+export function previewOccurrences(
+	rule: RecurrenceRule,
+	next: Date,
+	done: number,
+	count: number,
+): Date[] {
+	const anchored: RecurrenceRule = {
+		...rule,
+		anchorDay: rule.anchorDay ?? next.getUTCDate(),
+	};
+	const dates: Date[] = [];
+	let current = new Date(next);
+	while (dates.length < count && !recurrenceEnded(anchored, current, done)) {
+		dates.push(current);
+		done++;
+		current = nextOccurrence(anchored, current);
+	}
+	return dates;
+}
+
+/** A template's rule with where it stands, as the calendar projects it. */
+export interface ProjectedTemplate extends RecurrenceRule {
+	nextDueDate: Date;
+	occurrenceCount?: number;
+}
+
+// Bounds the walk to a far-off date, e.g. years ahead of a daily template
+const MAX_PROJECTED_OCCURRENCES = 1000;
+
+/**
+ * Check if a recurring template is projected to occur on a given calendar
+ * date, walking its rule from nextDueDate the way the sidecar generates its
+ * expenses. Dates before nextDueDate are never projected.
+ */
+export function isProjectedOnDate(
+	template: ProjectedTemplate,
+	date: Date,
+): boolean {
+	// Calendar cells are local days; occurrences are UTC dates
+	const target = format(date, "yyyy-MM-dd");
+	const next = new Date(template.nextDueDate);
+	const rule: RecurrenceRule = {
+		...template,
+		anchorDay: template.anchorDay ?? next.getUTCDate(),
+	};
+
+	let current = next;
+	let done = template.occurrenceCount ?? 0;
+	for (let i = 0; i < MAX_PROJECTED_OCCURRENCES; i++) {
+		if (recurrenceEnded(rule, current, done)) return false;
+		const key = utcDayKey(current);
+		if (key === target) return true;
+		if (key > target) return false;
+		done++;
+		current = nextOccurrence(rule, current);
 	}
+	return false;
 }
 
 /**
@@ -51,17 +202,23 @@ export function isProjectedOnDate(
 export function toMonthlyEquivalent(
 	amount: number,
 	frequency: string,
+	interval = 1,
 ): number {
+	const n = Math.max(interval, 1);
 	switch (frequency) {
+		case "DAILY":
+			return (amount * (365 / 12)) / n;
 		case "WEEKLY":
-			return amount * (52 / 12);
+			return (amount * (52 / 12)) / n;
 		case "BIWEEKLY":
-			return amount * (26 / 12);
+			return (amount * (26 / 12)) / n;
+		case "SEMI_MONTHLY":
+			return amount * 2;
 		case "QUARTERLY":
-			return amount / 3;
+			return amount / 3 / n;
 		case "YEARLY":
-			return amount / 12;
+			return amount / 12 / n;
 		default:
-			return amount;
+			return amount / n;
 	}
 }
@@ -116,4 +116,47 @@ export const exchangeRateRouter = createTRPCRouter({
 			});
 		}
 	}),
+
+	/**
+	 * Rates the sync held back because they moved beyond the anomaly
+	 * thresholds, awaiting approval or rejection.
+	 */
+	getQuarantined: adminProcedure.query(async ({ ctx }) => {
+		const rates = await ctx.db.exchangeRateQuarantine.findMany({
+			orderBy: [{ currency: "asc" }, { type: "asc" }],
+		});
+
+		return rates.map((rate) => ({
+			...rate,
+			changePercent: rate.previousRate.isZero()
+				? null
+				: rate.rate.minus(rate.previousRate).div(rate.previousRate).times(100).toNumber(),
+		}));
+	}),
+
+	resolveQuarantined: adminProcedure
+		.input(
+			z.object({
+				ids: z.array(z.string().min(1)).min(1).max(500),
+				action: z.enum(["approve", "reject"]),
+			}),
+		)
+		.mutation(async ({ input }) => {
+			try {
+				const response = await IntegrationService.requestWorker(
+					`${env.SIDECAR_URL}/rates/quarantine/${input.action}`,
+					{
+						method: "POST",
+						headers: { "Content-Type": "application/json" },
+						body: JSON.stringify({ ids: input.ids }),
+					},
+				);
+				return (await response.json()) as { resolved: number };
+			} catch (error) {
+				throw new TRPCError({
+					code: "INTERNAL_SERVER_ERROR",
+					message: `Failed to ${input.action} rates: ${error instanceof Error ? error.message : "Unknown error"}`,
+				});
+			}
+		}),
 });
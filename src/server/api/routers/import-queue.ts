@@ -14,6 +14,8 @@ const createJobSchema = z.object({
 	fileType: z.enum(["csv", "xlsx", "pdf"]),
 	type: z.enum(["CSV", "BANK_STATEMENT"]),
 	fileData: z.string().min(1).max(14_000_000), // base64 encoded, ~10MB file limit
+	password: z.string().min(1).max(256).optional(), // Encrypted PDF statements
+	currency: z.string().min(3).max(10).optional(), // Statement account currency
 });
 
 const importerTransactionSchema = z.object({
@@ -78,6 +80,25 @@ export const importQueueRouter = createTRPCRouter({
 		return await service.getQueueStatus(ctx.session.user.id);
 	}),
 
+	/**
+	 * Retries a bank statement that failed for want of its PDF password.
+	 */
+	retryWithPassword: protectedProcedure
+		.input(
+			z.object({
+				jobId: z.string(),
+				password: z.string().min(1).max(256),
+			}),
+		)
+		.mutation(async ({ ctx, input }) => {
+			const service = new ImportQueueService(ctx.db);
+			return await service.retryWithPassword(
+				ctx.session.user.id,
+				input.jobId,
+				input.password,
+			);
+		}),
+
 	/**
 	 * Marks a job as reviewing (when modal opens).
 	 */
@@ -1,39 +1,18 @@
 import { TRPCError } from "@trpc/server";
 import { z } from "zod";
 import { BASE_CURRENCY } from "~/lib/constants";
+import {
+	nextOccurrence,
+	previewOccurrences,
+	RECURRING_FREQUENCIES,
+	recurrenceEnded,
+	usesAnchor,
+} from "~/lib/recurring";
 import { createTRPCRouter, protectedProcedure } from "~/server/api/trpc";
 import { fromUSD, toUSD } from "~/server/currency";
 import { RateCache } from "~/server/services/rate-cache";
 import { getBestExchangeRate } from "./shared-currency";
 
-// Helper to calculate next due date based on frequency
-function calculateNextDueDate(
-	currentDate: Date,
-	frequency: "WEEKLY" | "BIWEEKLY" | "MONTHLY" | "QUARTERLY" | "YEARLY",
-): Date {
-	const next = new Date(currentDate);
-
-	switch (frequency) {
-		case "WEEKLY":
-			next.setDate(next.getDate() + 7);
-			break;
-		case "BIWEEKLY":
-			next.setDate(next.getDate() + 14);
-			break;
-		case "MONTHLY":
-			next.setMonth(next.getMonth() + 1);
-			break;
-		case "QUARTERLY":
-			next.setMonth(next.getMonth() + 3);
-			break;
-		case "YEARLY":
-			next.setFullYear(next.getFullYear() + 1);
-			break;
-	}
-
-	return next;
-}
-
 export const recurringRouter = createTRPCRouter({
 	list: protectedProcedure
 		.input(
@@ -171,17 +150,25 @@ export const recurringRouter = createTRPCRouter({
 
 	create: protectedProcedure
 		.input(
-			z.object({
-				name: z.string().min(1).max(191),
-				amount: z.number().positive(),
-				currency: z.string().length(3).default("USD"),
-				categoryId: z.string().cuid().optional(),
-				frequency: z.enum(["WEEKLY", "BIWEEKLY", "MONTHLY", "QUARTERLY", "YEARLY"]),
-				nextDueDate: z.date(),
-				websiteUrl: z.string().url().max(512).optional(),
-				paymentSource: z.string().min(1).max(191).optional(),
-				autoPay: z.boolean().default(true),
-			}),
+			z
+				.object({
+					name: z.string().min(1).max(191),
+					amount: z.number().positive(),
+					currency: z.string().length(3).default("USD"),
+					categoryId: z.string().cuid().optional(),
+					frequency: z.enum(RECURRING_FREQUENCIES),
+					interval: z.number().int().min(1).max(366).default(1),
+					nextDueDate: z.date(),
+					endDate: z.date().optional(),
+					maxOccurrences: z.number().int().positive().optional(),
+					websiteUrl: z.string().url().max(512).optional(),
+					paymentSource: z.string().min(1).max(191).optional(),
+					autoPay: z.boolean().default(true),
+				})
+				.refine((data) => !data.endDate || data.endDate >= data.nextDueDate, {
+					message: "End date cannot be before the next due date",
+					path: ["endDate"],
+				}),
 		)
 		.mutation(async ({ ctx, input }) => {
 			const { session, db } = ctx;
@@ -207,7 +194,13 @@ export const recurringRouter = createTRPCRouter({
 					currency: input.currency,
 					categoryId: input.categoryId,
 					frequency: input.frequency,
+					interval: input.interval,
+					anchorDay: usesAnchor(input.frequency)
+						? input.nextDueDate.getUTCDate()
+						: null,
 					nextDueDate: input.nextDueDate,
+					endDate: input.endDate,
+					maxOccurrences: input.maxOccurrences,
 					websiteUrl: input.websiteUrl,
 					paymentSource: input.paymentSource,
 					autoPay: input.autoPay,
@@ -234,8 +227,11 @@ export const recurringRouter = createTRPCRouter({
 				amount: z.number().positive().optional(),
 				currency: z.string().length(3).optional(),
 				categoryId: z.string().cuid().nullable().optional(),
-				frequency: z.enum(["WEEKLY", "BIWEEKLY", "MONTHLY", "QUARTERLY", "YEARLY"]).optional(),
+				frequency: z.enum(RECURRING_FREQUENCIES).optional(),
+				interval: z.number().int().min(1).max(366).optional(),
 				nextDueDate: z.date().optional(),
+				endDate: z.date().nullable().optional(),
+				maxOccurrences: z.number().int().positive().nullable().optional(),
 				websiteUrl: z.string().url().max(512).nullable().optional(),
 				paymentSource: z.string().min(1).max(191).nullable().optional(),
 				autoPay: z.boolean().optional(),
@@ -272,6 +268,23 @@ export const recurringRouter = createTRPCRouter({
 				}
 			}
 
+			const nextDueDate = input.nextDueDate ?? existing.nextDueDate;
+			const endDate =
+				input.endDate !== undefined ? input.endDate : existing.endDate;
+			if (endDate && endDate < nextDueDate) {
+				throw new TRPCError({
+					code: "BAD_REQUEST",
+					message: "End date cannot be before the next due date",
+				});
+			}
+
+			// A new schedule re-anchors monthly rules on its due date
+			let anchorDay: number | null | undefined;
+			if (input.frequency || input.nextDueDate) {
+				const frequency = input.frequency ?? existing.frequency;
+				anchorDay = usesAnchor(frequency) ? nextDueDate.getUTCDate() : null;
+			}
+
 			const template = await db.recurringTemplate.update({
 				where: { id: input.id },
 				data: {
@@ -280,7 +293,11 @@ export const recurringRouter = createTRPCRouter({
 					currency: input.currency,
 					categoryId: input.categoryId,
 					frequency: input.frequency,
+					interval: input.interval,
+					anchorDay,
 					nextDueDate: input.nextDueDate,
+					endDate: input.endDate,
+					maxOccurrences: input.maxOccurrences,
 					websiteUrl: input.websiteUrl,
 					paymentSource: input.paymentSource,
 					autoPay: input.autoPay,
@@ -326,6 +343,43 @@ export const recurringRouter = createTRPCRouter({
 			return { success: true };
 		}),
 
+	/**
+	 * List the next occurrences of a template, stopping where its end date
+	 * or occurrence limit ends it
+	 */
+	preview: protectedProcedure
+		.input(
+			z.object({
+				id: z.string().cuid(),
+				count: z.number().int().min(1).max(100).default(12),
+			}),
+		)
+		.query(async ({ ctx, input }) => {
+			const { session, db } = ctx;
+
+			const template = await db.recurringTemplate.findFirst({
+				where: {
+					id: input.id,
+					userId: session.user.id,
+				},
+			});
+
+			if (!template) {
+				throw new TRPCError({
+					code: "NOT_FOUND",
+					message: "Recurring template not found",
+				});
+			}
+
+			if (!template.isActive) return [];
+			return previewOccurrences(
+				template,
+				template.nextDueDate,
+				template.occurrenceCount,
+				input.count,
+			);
+		}),
+
 	/**
 	 * Get templates that are pending confirmation (autoPay = false and due)
 	 */
@@ -402,11 +456,17 @@ export const recurringRouter = createTRPCRouter({
 				}
 			}
 
-			// Calculate next due date
-			const nextDueDate = calculateNextDueDate(
-				template.nextDueDate,
-				template.frequency,
-			);
+			// Advance to the next occurrence, remembering the anchor day so a
+			// clamped month does not move later ones
+			const anchorDay =
+				template.anchorDay ??
+				(usesAnchor(template.frequency)
+					? template.nextDueDate.getUTCDate()
+					: null);
+			const rule = { ...template, anchorDay };
+			const nextDueDate = nextOccurrence(rule, template.nextDueDate);
+			const occurrenceCount = template.occurrenceCount + 1;
+			const isActive = !recurrenceEnded(rule, nextDueDate, occurrenceCount);
 
 			// Create expense and update template in a transaction
 			// This prevents orphaned expenses if the nextDueDate update fails
@@ -429,10 +489,10 @@ export const recurringRouter = createTRPCRouter({
 					},
 				});
 
-				// Update next due date
+				// Update next due date, ending the template once its rule runs out
 				await tx.recurringTemplate.update({
 					where: { id: template.id },
-					data: { nextDueDate },
+					data: { nextDueDate, anchorDay, occurrenceCount, isActive },
 				});
 
 				return createdExpense;
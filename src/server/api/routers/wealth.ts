@@ -372,6 +372,7 @@ export const wealthRouter = createTRPCRouter({
 					in: currencies,
 				},
 			},
+			distinct: ["currency", "type"],
 			orderBy: {
 				date: "desc",
 			},
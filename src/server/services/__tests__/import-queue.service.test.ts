@@ -359,6 +359,129 @@ describe("ImportQueueService", () => {
 		});
 	});
 
+	// ── PDF passwords ─────────────────────────────────────────────────────────
+
+	describe("PDF passwords", () => {
+		const PDF_BASE64 = Buffer.from("%PDF-1.7").toString("base64");
+
+		it("stores the importer's errorType and keeps the file of a password-protected PDF", async () => {
+			const queuedJob = makeJob({
+				type: "BANK_STATEMENT",
+				fileName: "statement.pdf",
+				fileType: "pdf",
+				fileData: PDF_BASE64,
+			});
+			db.importJob.findFirst
+				.mockResolvedValueOnce(queuedJob)
+				.mockResolvedValueOnce(null);
+			db.importJob.update.mockResolvedValue(queuedJob);
+			db.user.findUnique.mockResolvedValue({ aiMode: "LOCAL" });
+			const fetchMock = vi.fn().mockResolvedValue(
+				new Response(
+					`${JSON.stringify({
+						type: "error",
+						message: "This PDF is password protected. Enter its password to continue.",
+						errorType: "password_required",
+					})}\n`,
+				),
+			);
+			vi.stubGlobal("fetch", fetchMock);
+
+			await service.processQueue("user-1");
+			vi.unstubAllGlobals();
+
+			const failed = db.importJob.update.mock.calls
+				.map(([args]) => args.data)
+				.find((data) => data.status === "FAILED");
+			expect(failed).toMatchObject({
+				errorType: "password_required",
+				errorMessage: expect.stringContaining("password protected"),
+			});
+			expect(failed).not.toHaveProperty("fileData");
+		});
+
+		it("retryWithPassword rejects jobs that are not waiting for a password", async () => {
+			db.importJob.findUnique.mockResolvedValue(
+				makeJob({ status: "FAILED", errorType: null, fileData: PDF_BASE64 }),
+			);
+
+			await expect(
+				service.retryWithPassword("user-1", "job-1", "secret"),
+			).rejects.toMatchObject({ code: "BAD_REQUEST" });
+		});
+
+		it("retryWithPassword rejects another user's job", async () => {
+			db.importJob.findUnique.mockResolvedValue(
+				makeJob({
+					userId: "other-user",
+					status: "FAILED",
+					errorType: "password_required",
+					fileData: PDF_BASE64,
+				}),
+			);
+
+			await expect(
+				service.retryWithPassword("user-1", "job-1", "secret"),
+			).rejects.toMatchObject({ code: "FORBIDDEN" });
+		});
+
+		it("retryWithPassword re-queues the job without storing the password", async () => {
+			const failedJob = makeJob({
+				status: "FAILED",
+				errorType: "password_incorrect",
+				fileData: PDF_BASE64,
+			});
+			db.importJob.findUnique.mockResolvedValue(failedJob);
+			db.importJob.findFirst.mockResolvedValue(null);
+			db.importJob.update.mockResolvedValue({ ...failedJob, status: "QUEUED" });
+
+			await service.retryWithPassword("user-1", "job-1", "secret");
+
+			const [[args]] = db.importJob.update.mock.calls;
+			expect(args.data).toMatchObject({ status: "QUEUED", errorType: null });
+			expect(JSON.stringify(args)).not.toContain("secret");
+		});
+
+		it("cancelJob forgets the password of the cancelled job", async () => {
+			const failedJob = makeJob({
+				type: "BANK_STATEMENT",
+				fileName: "statement.pdf",
+				fileType: "pdf",
+				status: "FAILED",
+				errorType: "password_required",
+				fileData: PDF_BASE64,
+			});
+			db.importJob.findUnique.mockResolvedValueOnce(failedJob);
+			db.importJob.findFirst.mockResolvedValueOnce(null);
+			db.importJob.update.mockResolvedValue(failedJob);
+			await service.retryWithPassword("user-1", "job-1", "secret");
+			// Let the queue run started by retryWithPassword finish first
+			await new Promise((resolve) => setTimeout(resolve, 0));
+
+			db.importJob.findUnique.mockResolvedValueOnce({
+				...failedJob,
+				status: "QUEUED",
+			});
+			await service.cancelJob("user-1", "job-1");
+
+			// The same job ID reaching the importer again carries no password
+			db.importJob.findFirst
+				.mockResolvedValueOnce({ ...failedJob, status: "QUEUED" })
+				.mockResolvedValueOnce(null);
+			db.user.findUnique.mockResolvedValue({ aiMode: "LOCAL" });
+			const fetchMock = vi
+				.fn()
+				.mockResolvedValue(new Response("", { status: 500 }));
+			vi.stubGlobal("fetch", fetchMock);
+
+			await service.processQueue("user-1");
+			vi.unstubAllGlobals();
+
+			const [, init] = fetchMock.mock.calls[0] as [string, RequestInit];
+			expect((init.body as FormData).get("password")).toBeNull();
+		});
+	});
+
 	// ── cancelJob ─────────────────────────────────────────────────────────────
 
 	describe("cancelJob", () => {
@@ -23,6 +23,14 @@ export interface ImporterTransaction {
 	pricingSource: string;
 	category: string;
 	categoryId?: string;
+	/** Provenance: where the row came from in the uploaded file */
+	importId?: string;
+	sourceFile?: string;
+	sourceRow?: number;
+	sourcePage?: number;
+	sourceLineStart?: number;
+	sourceLineEnd?: number;
+	rawText?: string;
 }
 
 export interface CreateJobInput {
@@ -31,6 +39,8 @@ export interface CreateJobInput {
 	fileType: string; // "csv" | "xlsx" | "pdf"
 	type: "CSV" | "BANK_STATEMENT";
 	fileData: string; // base64 encoded
+	password?: string; // Opens encrypted PDF statements; never persisted
+	currency?: string; // Currency of the statement's account
 }
 
 export interface FinalizeImportInput {
@@ -44,6 +54,45 @@ const MAX_FILE_SIZE = 10 * 1024 * 1024; // 10MB
 const MAX_COMPLETED_JOBS = 10; // Max completed/cancelled/failed jobs to keep per user
 const COMPLETED_JOB_TTL_MS = 24 * 60 * 60 * 1000; // 24 hours
 
+/** Importer error types that the UI answers by asking for the PDF password. */
+export const PASSWORD_ERROR_TYPES = ["password_required", "password_incorrect"];
+
+// ── Helpers ───────────────────────────────────────────────────────────
+
+/**
+ * Passwords for encrypted PDF statements by job ID. They live only in memory
+ * until the job's importer request is sent, and are never written to the
+ * database. After a restart the job fails with "password_required" and the
+ * user is asked again.
+ */
+const pdfPasswords = new Map<string, string>();
+
+/** An importer failure, with the sidecar's errorType when it sent one. */
+class ImporterError extends Error {
+	constructor(
+		message: string,
+		readonly errorType?: string,
+	) {
+		super(message);
+		this.name = "ImporterError";
+	}
+}
+
+/**
+ * The sidecar sends money fields as exact decimal strings ("12.50") so no
+ * precision is lost in transit; the review UI works with numbers.
+ */
+function parseImporterAmounts(
+	raw: Array<Record<string, unknown>>,
+): ImporterTransaction[] {
+	return raw.map((tx) => ({
+		...tx,
+		amount: Number(tx.amount),
+		exchangeRate: Number(tx.exchangeRate),
+		amountInUSD: Number(tx.amountInUSD),
+	})) as unknown as ImporterTransaction[];
+}
+
 // ── Service ───────────────────────────────────────────────────────────
 
 export class ImportQueueService {
@@ -87,8 +136,12 @@ export class ImportQueueService {
 				fileSize: input.fileSize,
 				fileType: input.fileType,
 				fileData: input.fileData,
+				currency: input.currency,
 			},
 		});
+		if (input.password) {
+			pdfPasswords.set(job.id, input.password);
+		}
 
 		// Trigger queue processing asynchronously (don't await)
 		// This allows the mutation to return immediately
@@ -158,8 +211,14 @@ export class ImportQueueService {
 			await this.processQueue(userId);
 		} catch (error) {
 			console.error(`Job processing error for ${job.id}:`, error);
-
-			// Mark as FAILED
+			pdfPasswords.delete(job.id);
+
+			// Mark as FAILED. A statement that needs its password keeps its file
+			// so the user can retry with one.
+			const errorType =
+				error instanceof ImporterError ? error.errorType : undefined;
+			const needsPassword =
+				errorType !== undefined && PASSWORD_ERROR_TYPES.includes(errorType);
 			await this.db.importJob.update({
 				where: { id: job.id },
 				data: {
@@ -168,8 +227,9 @@ export class ImportQueueService {
 						error instanceof Error
 							? error.message.substring(0, 1000)
 							: "Unknown error",
+					errorType: errorType ?? null,
 					failedAt: new Date(),
-					fileData: null, // Clear file data
+					...(needsPassword ? {} : { fileData: null }), // Clear file data
 				},
 			});
 
@@ -418,6 +478,7 @@ export class ImportQueueService {
 		fileData: string | null;
 		fileName: string;
 		fileType: string;
+		currency: string | null;
 	}): Promise<void> {
 		if (!job.fileData) {
 			throw new Error("No file data found for bank statement job");
@@ -481,7 +542,7 @@ export class ImportQueueService {
 		// Resolve AI provider for this user
 		const user = await this.db.user.findUnique({
 			where: { id: job.userId },
-			select: { aiMode: true },
+			select: { aiMode: true, homeCurrency: true, language: true },
 		});
 		const requestedMode = user?.aiMode ?? "LOCAL";
 		const aiAccess = await resolveAiAccess(this.db, job.userId, requestedMode);
@@ -500,6 +561,21 @@ export class ImportQueueService {
 		});
 		formData.append("file", blob, fileName);
 		formData.append("provider", provider);
+		// The account currency and the user's locale settle symbols such as
+		// "$" in amounts; this is a server-side request, so the sidecar cannot
+		// fall back to the browser's Accept-Language
+		const currency = job.currency ?? user?.homeCurrency;
+		if (currency) {
+			formData.append("currency", currency);
+		}
+		if (user?.language) {
+			formData.append("locale", user.language);
+		}
+		const password = pdfPasswords.get(job.id);
+		pdfPasswords.delete(job.id);
+		if (password && fileType === "pdf") {
+			formData.append("password", password);
+		}
 
 		// Call Go importer service
 		const controller = new AbortController();
@@ -533,6 +609,7 @@ export class ImportQueueService {
 			const warnings: string[] = [...additionalWarnings];
 			let buffer = "";
 			let totalTokensUsed = 0;
+			let importerError: ImporterError | undefined;
 
 			while (true) {
 				const { done, value } = await reader.read();
@@ -562,12 +639,12 @@ export class ImportQueueService {
 						} else if (data.type === "warning") {
 							warnings.push(data.message);
 						} else if (data.type === "result") {
-							transactions = data.data;
+							transactions = parseImporterAmounts(data.data);
 							if (data.metadata?.totalTokensUsed) {
 								totalTokensUsed = data.metadata.totalTokensUsed;
 							}
 						} else if (data.type === "error") {
-							throw new Error(data.message);
+							importerError = new ImporterError(data.message, data.errorType);
 						}
 					} catch (error) {
 						console.error(
@@ -583,18 +660,22 @@ export class ImportQueueService {
 				try {
 					const data = JSON.parse(buffer);
 					if (data.type === "result") {
-						transactions = data.data;
+						transactions = parseImporterAmounts(data.data);
 						if (data.metadata?.totalTokensUsed) {
 							totalTokensUsed = data.metadata.totalTokensUsed;
 						}
 					} else if (data.type === "error") {
-						throw new Error(data.message);
+						importerError = new ImporterError(data.message, data.errorType);
 					}
 				} catch (error) {
 					console.error(`Failed to parse final buffer: ${buffer}`, error);
 				}
 			}
 
+			if (importerError) {
+				throw importerError;
+			}
+
 			if (transactions.length === 0) {
 				throw new Error("No transactions extracted from statement");
 			}
@@ -640,6 +721,61 @@ export class ImportQueueService {
 		}
 	}
 
+	/**
+	 * Re-queues a bank statement that failed for want of its PDF password,
+	 * this time with the password.
+	 */
+	async retryWithPassword(userId: string, jobId: string, password: string) {
+		const job = await this.db.importJob.findUnique({
+			where: { id: jobId },
+		});
+
+		if (!job) {
+			throw new TRPCError({
+				code: "NOT_FOUND",
+				message: "Import job not found",
+			});
+		}
+
+		if (job.userId !== userId) {
+			throw new TRPCError({
+				code: "FORBIDDEN",
+				message: "Not authorized to access this job",
+			});
+		}
+
+		if (
+			job.status !== "FAILED" ||
+			!job.errorType ||
+			!PASSWORD_ERROR_TYPES.includes(job.errorType) ||
+			!job.fileData
+		) {
+			throw new TRPCError({
+				code: "BAD_REQUEST",
+				message: "Job is not waiting for a PDF password",
+			});
+		}
+
+		pdfPasswords.set(job.id, password);
+		const queued = await this.db.importJob.update({
+			where: { id: jobId },
+			data: {
+				status: "QUEUED",
+				errorMessage: null,
+				errorType: null,
+				failedAt: null,
+				progressPercent: null,
+				statusMessage: null,
+			},
+		});
+
+		void this.processQueue(userId).catch((error) => {
+			console.error(`Queue processing error for user ${userId}:`, error);
+		});
+
+		return queued;
+	}
+
 	/**
 	 * Updates job status to REVIEWING (when modal opens).
 	 */
@@ -928,6 +1064,7 @@ export class ImportQueueService {
 				fileData: null, // Clear file data
 			},
 		});
+		pdfPasswords.delete(jobId);
 
 		// Trigger global queue processing (job slot potentially freed up)
 		void this.processGlobalQueue().catch((err) => {
@@ -977,6 +1114,7 @@ export class ImportQueueService {
 		await this.db.importJob.delete({
 			where: { id: jobId },
 		});
+		pdfPasswords.delete(jobId);
 
 		// Trigger global queue processing (in case job was stuck in PROCESSING)
 		void this.processGlobalQueue().catch((err) => {
@@ -206,6 +206,8 @@ export class WealthService {
 				currency: { in: currencies },
 				date: { lte: today },
 			},
+			// Latest row of each currency/type; earlier days are history
+			distinct: ["currency", "type"],
 			orderBy: [{ date: "desc" }, { type: "asc" }],
 		});
 